@@ -1,32 +1,123 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"strings"
 	"sync"
+	"time"
+	_ "time/tzdata" // часовые пояса городов проверяются и в контейнере без zoneinfo
 
+	"avito-pvz-service/internal/auth"
 	"avito-pvz-service/internal/database"
 	grpcSrv "avito-pvz-service/internal/grpc"
 	"avito-pvz-service/internal/handler"
 	"avito-pvz-service/internal/metrics"
-	"avito-pvz-service/internal/middleware"
+	"avito-pvz-service/internal/ratelimit"
+	"avito-pvz-service/internal/rbac"
+	"avito-pvz-service/internal/repository"
+	"avito-pvz-service/internal/scheduler"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RunServer() {
+// newRepositories выбирает хранилище по переменной STORAGE:
+// "memory" — в памяти процесса, иначе PostgreSQL.
+func newRepositories() repository.Repositories {
+	if os.Getenv("STORAGE") == "memory" {
+		log.Println("Используется хранилище в памяти")
+		return repository.NewMemoryRepositories()
+	}
+
 	if err := database.Init(); err != nil {
 		log.Fatalf("Не удалось инициализировать БД: %v", err)
 	}
 	log.Println("Соединение с БД установлено")
 
+	// Не обслуживаем запросы на устаревшей схеме
+	if err := verifySchema(); err != nil {
+		log.Fatalf("Схема БД не готова, выполните `migrate up`: %v", err)
+	}
+	return repository.NewPostgresRepositories(database.DB)
+}
+
+// durationEnv читает длительность из переменной окружения name
+// (например, "12h" или "5m"); пустая переменная — значение def.
+func durationEnv(name string, def time.Duration) time.Duration {
+	s := os.Getenv(name)
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		log.Fatalf("Неверное значение %s=%q: ожидается длительность вида 12h или 30m", name, s)
+	}
+	return d
+}
+
+// listEnv читает список через запятую из переменной окружения name;
+// пустая переменная — пустой список.
+func listEnv(name string) []string {
+	var list []string
+	for _, s := range strings.Split(os.Getenv(name), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func RunServer() {
+	// Без ключа подписи выпущенные токены нельзя было бы проверить
+	keys, err := auth.FromEnv()
+	if err != nil {
+		log.Fatalf("Не удалось загрузить ключи JWT: %v", err)
+	}
+
+	repos := newRepositories()
+	h := handler.NewHandler(repos, keys)
+
+	// Политика доступа: встроенная или из файла RBAC_POLICY_FILE
+	policy, err := rbac.Load(os.Getenv("RBAC_POLICY_FILE"))
+	if err != nil {
+		log.Fatalf("Не удалось загрузить политику доступа: %v", err)
+	}
+
+	// Лимиты частоты запросов: встроенные или из файла RATE_LIMIT_FILE
+	limits, err := ratelimit.Load(os.Getenv("RATE_LIMIT_FILE"))
+	if err != nil {
+		log.Fatalf("Не удалось загрузить лимиты запросов: %v", err)
+	}
+	limiter := ratelimit.NewLimiter(limits, ratelimit.NewMemoryStore())
+
+	// Сколько хранятся ответы для повторов с Idempotency-Key; IDEMPOTENCY_TTL=0 отключает ключи
+	idempotencyTTL := durationEnv("IDEMPOTENCY_TTL", 24*time.Hour)
+
 	var wg sync.WaitGroup
 
+	// Автозакрытие зависших приёмок; AUTO_CLOSE_IDLE=0 отключает его
+	if idle := durationEnv("AUTO_CLOSE_IDLE", 12*time.Hour); idle > 0 {
+		interval := durationEnv("AUTO_CLOSE_INTERVAL", 5*time.Minute)
+		if interval == 0 {
+			log.Fatal("AUTO_CLOSE_INTERVAL должен быть больше нуля")
+		}
+		log.Printf("Автозакрытие приёмок: простой %s, проверка каждые %s\n", idle, interval)
+		go scheduler.NewAutoCloser(repos, idle, interval).Run(context.Background())
+	}
+
+	// Рассылка вебхуков из outbox; WEBHOOK_DISPATCH_INTERVAL=0 отключает её
+	if interval := durationEnv("WEBHOOK_DISPATCH_INTERVAL", 2*time.Second); interval > 0 {
+		log.Printf("Рассылка вебхуков: проверка outbox каждые %s\n", interval)
+		go scheduler.NewWebhookDispatcher(repos, interval).Run(context.Background())
+	}
+
 	// gRPC‑сервер
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		log.Println("gRPC сервер запускается")
-		grpcSrv.RunGRPCServer()
+		grpcSrv.RunGRPCServer(repos, keys, policy, idempotencyTTL, limiter)
 	}()
 
 	// Prometheus‑метрики
@@ -45,21 +136,14 @@ func RunServer() {
 		metrics.GinMiddleware(),
 	)
 
-	// Публичные ручки
-	router.POST("/dummyLogin", handler.DummyLoginHandler)
-	router.POST("/register", handler.RegisterHandler)
-	router.POST("/login", handler.LoginHandler)
-
-	// Защищённые через JWT
-	auth := router.Group("/")
-	auth.Use(middleware.JWTMiddleware())
-	{
-		auth.POST("/pvz", handler.CreatePVZHandler)
-		auth.GET("/pvz", handler.PVZListHandler)
-		auth.POST("/pvz/:pvzId/close_last_reception", handler.CloseReceptionHandler)
-		auth.POST("/pvz/:pvzId/delete_last_product", handler.DeleteLastProductHandler)
-		auth.POST("/receptions", handler.CreateReceptionHandler)
-		auth.POST("/products", handler.AddProductHandler)
+	err = h.RegisterRoutes(router, handler.RouterConfig{
+		Policy:         policy,
+		Limiter:        limiter,
+		IdempotencyTTL: idempotencyTTL,
+		TrustedProxies: listEnv("TRUSTED_PROXIES"),
+	})
+	if err != nil {
+		log.Fatalf("Неверное значение TRUSTED_PROXIES: %v", err)
 	}
 
 	log.Println("HTTP сервер слушает на :8080")
@@ -71,5 +155,9 @@ func RunServer() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
 	RunServer()
 }
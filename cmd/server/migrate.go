@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"avito-pvz-service/internal/database"
+	"avito-pvz-service/internal/migrate"
+	"avito-pvz-service/migrations"
+)
+
+const migrateUsage = "использование: migrate up | down | status"
+
+func newMigrator() *migrate.Migrator {
+	m, err := migrate.New(database.DB, migrations.FS)
+	if err != nil {
+		log.Fatalf("Не удалось загрузить миграции: %v", err)
+	}
+	return m
+}
+
+// verifySchema проверяет, что все встроенные миграции применены.
+func verifySchema() error {
+	return newMigrator().Verify(context.Background())
+}
+
+// runMigrate выполняет подкоманду `migrate` и завершает процесс.
+func runMigrate(args []string) {
+	if len(args) != 1 {
+		log.Fatal(migrateUsage)
+	}
+	if err := database.Init(); err != nil {
+		log.Fatalf("Не удалось инициализировать БД: %v", err)
+	}
+	defer database.DB.Close()
+
+	ctx := context.Background()
+	m := newMigrator()
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			log.Printf("Применена миграция %04d_%s", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatalf("Ошибка миграции: %v", err)
+		}
+		if len(applied) == 0 {
+			log.Println("Схема актуальна, миграций для применения нет")
+		}
+	case "down":
+		mig, err := m.Down(ctx)
+		if err != nil {
+			log.Fatalf("Ошибка отката: %v", err)
+		}
+		log.Printf("Откачена миграция %04d_%s", mig.Version, mig.Name)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatalf("Ошибка получения статуса: %v", err)
+		}
+		for _, st := range statuses {
+			state := "pending"
+			switch {
+			case st.Missing:
+				state = "applied (нет файла)"
+			case st.Modified:
+				state = "applied (файл изменён!)"
+			case st.Applied:
+				state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%-30s %s\n", st.Version, st.Name, state)
+		}
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
@@ -57,5 +57,5 @@ require (
 	golang.org/x/text v0.24.0 // indirect
 	google.golang.org/grpc v1.71.1
 	google.golang.org/protobuf v1.36.5
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
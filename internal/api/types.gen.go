@@ -1,6 +1,6 @@
 // Package api provides primitives to interact with the openapi HTTP API.
 //
-// Code generated by github.com/deepmap/oapi-codegen version v1.13.1 DO NOT EDIT.
+// Code generated by github.com/deepmap/oapi-codegen version v1.12.4 DO NOT EDIT.
 package api
 
 import (
@@ -13,51 +13,181 @@ const (
 	BearerAuthScopes = "bearerAuth.Scopes"
 )
 
-// Defines values for PVZCity.
+// Defines values for AttributeSpecKind.
 const (
-	Казань         PVZCity = "Казань"
-	Москва         PVZCity = "Москва"
-	СанктПетербург PVZCity = "Санкт-Петербург"
+	Number AttributeSpecKind = "number"
+	String AttributeSpecKind = "string"
 )
 
-// Defines values for ProductType.
+// Defines values for AuditEntryEntityType.
 const (
-	ProductTypeОбувь       ProductType = "обувь"
-	ProductTypeОдежда      ProductType = "одежда"
-	ProductTypeЭлектроника ProductType = "электроника"
+	AuditEntryEntityTypeCity            AuditEntryEntityType = "city"
+	AuditEntryEntityTypeProduct         AuditEntryEntityType = "product"
+	AuditEntryEntityTypeProductType     AuditEntryEntityType = "product_type"
+	AuditEntryEntityTypePvz             AuditEntryEntityType = "pvz"
+	AuditEntryEntityTypeReception       AuditEntryEntityType = "reception"
+	AuditEntryEntityTypeStaffAssignment AuditEntryEntityType = "staff_assignment"
+	AuditEntryEntityTypeWebhook         AuditEntryEntityType = "webhook"
+)
+
+// Defines values for BatchResultMode.
+const (
+	BatchResultModeAllOrNothing BatchResultMode = "all_or_nothing"
+	BatchResultModeBestEffort   BatchResultMode = "best_effort"
+)
+
+// Defines values for DeletionReason.
+const (
+	Damaged   DeletionReason = "damaged"
+	Duplicate DeletionReason = "duplicate"
+	Other     DeletionReason = "other"
+	WrongScan DeletionReason = "wrong_scan"
+)
+
+// Defines values for ReceptionCloseReason.
+const (
+	AutoTimeout ReceptionCloseReason = "auto_timeout"
+	Manual      ReceptionCloseReason = "manual"
 )
 
 // Defines values for ReceptionStatus.
 const (
-	Close      ReceptionStatus = "close"
-	InProgress ReceptionStatus = "in_progress"
+	ReceptionStatusCancelled  ReceptionStatus = "cancelled"
+	ReceptionStatusClose      ReceptionStatus = "close"
+	ReceptionStatusInProgress ReceptionStatus = "in_progress"
+	ReceptionStatusReopened   ReceptionStatus = "reopened"
 )
 
 // Defines values for UserRole.
 const (
-	UserRoleEmployee  UserRole = "employee"
+	UserRoleClient    UserRole = "client"
 	UserRoleModerator UserRole = "moderator"
+	UserRoleStaff     UserRole = "staff"
+)
+
+// Defines values for WebhookDeliveryStatus.
+const (
+	WebhookDeliveryStatusDead      WebhookDeliveryStatus = "dead"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+)
+
+// Defines values for WebhookEventType.
+const (
+	ProductAdded       WebhookEventType = "product_added"
+	ProductRemoved     WebhookEventType = "product_removed"
+	ReceptionCancelled WebhookEventType = "reception_cancelled"
+	ReceptionClosed    WebhookEventType = "reception_closed"
+	ReceptionOpened    WebhookEventType = "reception_opened"
+	ReceptionReopened  WebhookEventType = "reception_reopened"
+)
+
+// Defines values for GetAuditParamsEntityType.
+const (
+	GetAuditParamsEntityTypeCity            GetAuditParamsEntityType = "city"
+	GetAuditParamsEntityTypeProduct         GetAuditParamsEntityType = "product"
+	GetAuditParamsEntityTypeProductType     GetAuditParamsEntityType = "product_type"
+	GetAuditParamsEntityTypePvz             GetAuditParamsEntityType = "pvz"
+	GetAuditParamsEntityTypeReception       GetAuditParamsEntityType = "reception"
+	GetAuditParamsEntityTypeStaffAssignment GetAuditParamsEntityType = "staff_assignment"
+	GetAuditParamsEntityTypeWebhook         GetAuditParamsEntityType = "webhook"
 )
 
 // Defines values for PostDummyLoginJSONBodyRole.
 const (
-	PostDummyLoginJSONBodyRoleEmployee  PostDummyLoginJSONBodyRole = "employee"
+	PostDummyLoginJSONBodyRoleClient    PostDummyLoginJSONBodyRole = "client"
 	PostDummyLoginJSONBodyRoleModerator PostDummyLoginJSONBodyRole = "moderator"
+	PostDummyLoginJSONBodyRoleStaff     PostDummyLoginJSONBodyRole = "staff"
+)
+
+// Defines values for PostProductsBatchJSONBodyMode.
+const (
+	PostProductsBatchJSONBodyModeAllOrNothing PostProductsBatchJSONBodyMode = "all_or_nothing"
+	PostProductsBatchJSONBodyModeBestEffort   PostProductsBatchJSONBodyMode = "best_effort"
 )
 
-// Defines values for PostProductsJSONBodyType.
+// Defines values for GetPvzPvzIdReceptionsParamsStatus.
 const (
-	PostProductsJSONBodyTypeОбувь       PostProductsJSONBodyType = "обувь"
-	PostProductsJSONBodyTypeОдежда      PostProductsJSONBodyType = "одежда"
-	PostProductsJSONBodyTypeЭлектроника PostProductsJSONBodyType = "электроника"
+	GetPvzPvzIdReceptionsParamsStatusCancelled  GetPvzPvzIdReceptionsParamsStatus = "cancelled"
+	GetPvzPvzIdReceptionsParamsStatusClose      GetPvzPvzIdReceptionsParamsStatus = "close"
+	GetPvzPvzIdReceptionsParamsStatusInProgress GetPvzPvzIdReceptionsParamsStatus = "in_progress"
+	GetPvzPvzIdReceptionsParamsStatusReopened   GetPvzPvzIdReceptionsParamsStatus = "reopened"
 )
 
 // Defines values for PostRegisterJSONBodyRole.
 const (
-	Employee  PostRegisterJSONBodyRole = "employee"
+	Client    PostRegisterJSONBodyRole = "client"
 	Moderator PostRegisterJSONBodyRole = "moderator"
 )
 
+// Defines values for GetWebhooksWebhookIdDeliveriesParamsStatus.
+const (
+	GetWebhooksWebhookIdDeliveriesParamsStatusDead      GetWebhooksWebhookIdDeliveriesParamsStatus = "dead"
+	GetWebhooksWebhookIdDeliveriesParamsStatusDelivered GetWebhooksWebhookIdDeliveriesParamsStatus = "delivered"
+	GetWebhooksWebhookIdDeliveriesParamsStatusPending   GetWebhooksWebhookIdDeliveriesParamsStatus = "pending"
+)
+
+// AttributeSpec defines model for AttributeSpec.
+type AttributeSpec struct {
+	Kind     AttributeSpecKind `json:"kind"`
+	Name     string            `json:"name"`
+	Required *bool             `json:"required,omitempty"`
+	Unit     *string           `json:"unit,omitempty"`
+}
+
+// AttributeSpecKind defines model for AttributeSpec.Kind.
+type AttributeSpecKind string
+
+// AuditEntry defines model for AuditEntry.
+type AuditEntry struct {
+	Action *string `json:"action,omitempty"`
+	Actor  *string `json:"actor,omitempty"`
+
+	// After Состояние сущности после изменения
+	After *map[string]interface{} `json:"after,omitempty"`
+
+	// Before Состояние сущности до изменения
+	Before     *map[string]interface{} `json:"before,omitempty"`
+	ClientIp   *string                 `json:"clientIp,omitempty"`
+	EntityId   *string                 `json:"entityId,omitempty"`
+	EntityType *AuditEntryEntityType   `json:"entityType,omitempty"`
+	Id         *int64                  `json:"id,omitempty"`
+	RequestId  *string                 `json:"requestId,omitempty"`
+	Role       *string                 `json:"role,omitempty"`
+	Time       *time.Time              `json:"time,omitempty"`
+}
+
+// AuditEntryEntityType defines model for AuditEntry.EntityType.
+type AuditEntryEntityType string
+
+// BatchResult defines model for BatchResult.
+type BatchResult struct {
+	// Created Сколько товаров добавлено
+	Created *int             `json:"created,omitempty"`
+	Mode    *BatchResultMode `json:"mode,omitempty"`
+	Results *[]struct {
+		// Error Причина, по которой позиция не добавлена
+		Error   *string  `json:"error,omitempty"`
+		Index   *int     `json:"index,omitempty"`
+		Product *Product `json:"product,omitempty"`
+	} `json:"results,omitempty"`
+}
+
+// BatchResultMode defines model for BatchResult.Mode.
+type BatchResultMode string
+
+// City defines model for City.
+type City struct {
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	Enabled    *bool      `json:"enabled,omitempty"`
+	Name       string     `json:"name"`
+	RegionCode string     `json:"regionCode"`
+	Timezone   string     `json:"timezone"`
+}
+
+// DeletionReason defines model for DeletionReason.
+type DeletionReason string
+
 // Error defines model for Error.
 type Error struct {
 	Message string `json:"message"`
@@ -65,39 +195,116 @@ type Error struct {
 
 // PVZ defines model for PVZ.
 type PVZ struct {
-	City             PVZCity             `json:"city"`
+	// Active В деактивированном ПВЗ нельзя открыть новую приемку
+	Active  *bool   `json:"active,omitempty"`
+	Address *string `json:"address,omitempty"`
+
+	// City Город из справочника GET /cities
+	City string `json:"city"`
+
+	// ClosedAt Время деактивации; нет у активного ПВЗ
+	ClosedAt         *time.Time          `json:"closed_at,omitempty"`
 	Id               *openapi_types.UUID `json:"id,omitempty"`
-	RegistrationDate *time.Time          `json:"registrationDate,omitempty"`
+	Name             *string             `json:"name,omitempty"`
+	RegistrationDate *time.Time          `json:"registration_date,omitempty"`
 }
 
-// PVZCity defines model for PVZ.City.
-type PVZCity string
+// PVZRecord defines model for PVZRecord.
+type PVZRecord struct {
+	Pvz        *PVZ               `json:"pvz,omitempty"`
+	Receptions *[]ReceptionRecord `json:"receptions,omitempty"`
+}
 
 // Product defines model for Product.
 type Product struct {
-	DateTime    *time.Time          `json:"dateTime,omitempty"`
+	Attributes *map[string]interface{} `json:"attributes,omitempty"`
+	Barcode    *string                 `json:"barcode,omitempty"`
+	DateTime   *time.Time              `json:"date_time,omitempty"`
+
+	// DeletedAt Только у удаленных товаров (GET /pvz?includeDeleted=true)
+	DeletedAt   *time.Time          `json:"deleted_at,omitempty"`
+	DeletedBy   *string             `json:"deleted_by,omitempty"`
+	Id          *openapi_types.UUID `json:"id,omitempty"`
+	PvzId       *openapi_types.UUID `json:"pvz_id,omitempty"`
+	ReceptionId openapi_types.UUID  `json:"reception_id"`
+
+	// Type Код из справочника GET /product-types
+	Type string `json:"type"`
+}
+
+// ProductDeletion defines model for ProductDeletion.
+type ProductDeletion struct {
+	Barcode     *string             `json:"barcode,omitempty"`
+	Comment     *string             `json:"comment,omitempty"`
+	DeletedAt   *time.Time          `json:"deletedAt,omitempty"`
+	DeletedBy   *string             `json:"deletedBy,omitempty"`
 	Id          *openapi_types.UUID `json:"id,omitempty"`
-	ReceptionId openapi_types.UUID  `json:"receptionId"`
-	Type        ProductType         `json:"type"`
+	ProductId   *openapi_types.UUID `json:"productId,omitempty"`
+	PvzId       *openapi_types.UUID `json:"pvzId,omitempty"`
+	Reason      *DeletionReason     `json:"reason,omitempty"`
+	ReceptionId *openapi_types.UUID `json:"receptionId,omitempty"`
+	Type        *string             `json:"type,omitempty"`
+}
+
+// ProductLocation defines model for ProductLocation.
+type ProductLocation struct {
+	Product   *Product   `json:"product,omitempty"`
+	Pvz       *PVZ       `json:"pvz,omitempty"`
+	Reception *Reception `json:"reception,omitempty"`
 }
 
-// ProductType defines model for Product.Type.
-type ProductType string
+// ProductType defines model for ProductType.
+type ProductType struct {
+	Attributes *[]AttributeSpec `json:"attributes,omitempty"`
+	Code       *string          `json:"code,omitempty"`
+	CreatedAt  *time.Time       `json:"createdAt,omitempty"`
+	Fragile    *bool            `json:"fragile,omitempty"`
+
+	// Names Язык -> название
+	Names *map[string]string `json:"names,omitempty"`
+}
 
 // Reception defines model for Reception.
 type Reception struct {
-	DateTime time.Time           `json:"dateTime"`
-	Id       *openapi_types.UUID `json:"id,omitempty"`
-	PvzId    openapi_types.UUID  `json:"pvzId"`
-	Status   ReceptionStatus     `json:"status"`
+	CloseReason *ReceptionCloseReason `json:"close_reason,omitempty"`
+	DateTime    time.Time             `json:"date_time"`
+	Id          *openapi_types.UUID   `json:"id,omitempty"`
+	PvzId       openapi_types.UUID    `json:"pvz_id"`
+	Status      ReceptionStatus       `json:"status"`
 }
 
+// ReceptionCloseReason defines model for Reception.CloseReason.
+type ReceptionCloseReason string
+
 // ReceptionStatus defines model for Reception.Status.
 type ReceptionStatus string
 
+// ReceptionRecord defines model for ReceptionRecord.
+type ReceptionRecord struct {
+	Products  *[]Product `json:"products,omitempty"`
+	Reception *Reception `json:"reception,omitempty"`
+}
+
+// StaffAssignment defines model for StaffAssignment.
+type StaffAssignment struct {
+	AssignedAt *time.Time          `json:"assignedAt,omitempty"`
+	AssignedBy *string             `json:"assignedBy,omitempty"`
+	PvzId      *openapi_types.UUID `json:"pvzId,omitempty"`
+
+	// Subject sub сотрудника из JWT (email или имя роли у /dummyLogin)
+	Subject *string `json:"subject,omitempty"`
+}
+
 // Token defines model for Token.
 type Token = string
 
+// TokenPair defines model for TokenPair.
+type TokenPair struct {
+	// RefreshToken Одноразовый токен для POST /token/refresh
+	RefreshToken string `json:"RefreshToken"`
+	Token        Token  `json:"Token"`
+}
+
 // User defines model for User.
 type User struct {
 	Email openapi_types.Email `json:"email"`
@@ -108,8 +315,110 @@ type User struct {
 // UserRole defines model for User.Role.
 type UserRole string
 
+// WebhookDelivery defines model for WebhookDelivery.
+type WebhookDelivery struct {
+	Attempts       *int                   `json:"attempts,omitempty"`
+	CreatedAt      *time.Time             `json:"createdAt,omitempty"`
+	DeliveredAt    *time.Time             `json:"deliveredAt,omitempty"`
+	Event          *WebhookEvent          `json:"event,omitempty"`
+	Id             *openapi_types.UUID    `json:"id,omitempty"`
+	LastError      *string                `json:"lastError,omitempty"`
+	NextAttemptAt  *time.Time             `json:"nextAttemptAt,omitempty"`
+	Status         *WebhookDeliveryStatus `json:"status,omitempty"`
+	SubscriptionId *openapi_types.UUID    `json:"subscriptionId,omitempty"`
+}
+
+// WebhookDeliveryStatus defines model for WebhookDelivery.Status.
+type WebhookDeliveryStatus string
+
+// WebhookEvent defines model for WebhookEvent.
+type WebhookEvent struct {
+	City        *string             `json:"city,omitempty"`
+	Id          *int64              `json:"id,omitempty"`
+	ProductId   *openapi_types.UUID `json:"productId,omitempty"`
+	ProductType *string             `json:"productType,omitempty"`
+	PvzId       *openapi_types.UUID `json:"pvzId,omitempty"`
+	ReceptionId *openapi_types.UUID `json:"receptionId,omitempty"`
+	Time        *time.Time          `json:"time,omitempty"`
+	Type        *WebhookEventType   `json:"type,omitempty"`
+}
+
+// WebhookEventType defines model for WebhookEventType.
+type WebhookEventType string
+
+// WebhookSubscription defines model for WebhookSubscription.
+type WebhookSubscription struct {
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	CreatedBy *string    `json:"createdBy,omitempty"`
+
+	// EventTypes Пустой список — все события
+	EventTypes *[]WebhookEventType `json:"eventTypes,omitempty"`
+	Id         *openapi_types.UUID `json:"id,omitempty"`
+
+	// Secret Есть только в ответе на создание подписки
+	Secret *string `json:"secret,omitempty"`
+	Url    *string `json:"url,omitempty"`
+}
+
+// Cursor defines model for Cursor.
+type Cursor = string
+
+// Limit defines model for Limit.
+type Limit = int
+
+// PVZId defines model for PVZId.
+type PVZId = openapi_types.UUID
+
+// Page defines model for Page.
+type Page = int
+
+// ReceptionId defines model for ReceptionId.
+type ReceptionId = openapi_types.UUID
+
+// WebhookId defines model for WebhookId.
+type WebhookId = openapi_types.UUID
+
+// BadRequest defines model for BadRequest.
+type BadRequest = Error
+
+// Forbidden defines model for Forbidden.
+type Forbidden = Error
+
+// NotFound defines model for NotFound.
+type NotFound = Error
+
+// Unauthorized defines model for Unauthorized.
+type Unauthorized = Error
+
+// GetAuditParams defines parameters for GetAudit.
+type GetAuditParams struct {
+	Actor      *string                   `form:"actor,omitempty" json:"actor,omitempty"`
+	Action     *string                   `form:"action,omitempty" json:"action,omitempty"`
+	EntityType *GetAuditParamsEntityType `form:"entityType,omitempty" json:"entityType,omitempty"`
+	EntityId   *string                   `form:"entityId,omitempty" json:"entityId,omitempty"`
+	From       *time.Time                `form:"from,omitempty" json:"from,omitempty"`
+	To         *time.Time                `form:"to,omitempty" json:"to,omitempty"`
+
+	// Page Номер страницы
+	Page *Page `form:"page,omitempty" json:"page,omitempty"`
+
+	// Limit Количество элементов на странице
+	Limit *Limit `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetAuditParamsEntityType defines parameters for GetAudit.
+type GetAuditParamsEntityType string
+
+// PatchCitiesNameJSONBody defines parameters for PatchCitiesName.
+type PatchCitiesNameJSONBody struct {
+	Enabled    *bool   `json:"enabled,omitempty"`
+	RegionCode *string `json:"regionCode,omitempty"`
+	Timezone   *string `json:"timezone,omitempty"`
+}
+
 // PostDummyLoginJSONBody defines parameters for PostDummyLogin.
 type PostDummyLoginJSONBody struct {
+	// Role Сотрудник ПВЗ — staff; устаревшее имя employee тоже принимается
 	Role PostDummyLoginJSONBodyRole `json:"role"`
 }
 
@@ -122,28 +431,99 @@ type PostLoginJSONBody struct {
 	Password string              `json:"password"`
 }
 
+// PostLogoutJSONBody defines parameters for PostLogout.
+type PostLogoutJSONBody struct {
+	RefreshToken *string `json:"refreshToken,omitempty"`
+}
+
 // PostProductsJSONBody defines parameters for PostProducts.
 type PostProductsJSONBody struct {
-	PvzId openapi_types.UUID       `json:"pvzId"`
-	Type  PostProductsJSONBodyType `json:"type"`
+	// Attributes Проверяются по описанию типа
+	Attributes *map[string]interface{} `json:"attributes,omitempty"`
+	Barcode    *string                 `json:"barcode,omitempty"`
+	PvzId      openapi_types.UUID      `json:"pvzId"`
+
+	// Type Код из справочника GET /product-types
+	Type string `json:"type"`
+}
+
+// PostProductsBatchJSONBody defines parameters for PostProductsBatch.
+type PostProductsBatchJSONBody struct {
+	Items []struct {
+		Attributes *map[string]interface{} `json:"attributes,omitempty"`
+		Barcode    *string                 `json:"barcode,omitempty"`
+		Type       string                  `json:"type"`
+	} `json:"items"`
+	Mode  *PostProductsBatchJSONBodyMode `json:"mode,omitempty"`
+	PvzId openapi_types.UUID             `json:"pvzId"`
 }
 
-// PostProductsJSONBodyType defines parameters for PostProducts.
-type PostProductsJSONBodyType string
+// PostProductsBatchJSONBodyMode defines parameters for PostProductsBatch.
+type PostProductsBatchJSONBodyMode string
+
+// DeleteProductsProductIdParams defines parameters for DeleteProductsProductId.
+type DeleteProductsProductIdParams struct {
+	Reason  DeletionReason `form:"reason" json:"reason"`
+	Comment *string        `form:"comment,omitempty" json:"comment,omitempty"`
+}
 
 // GetPvzParams defines parameters for GetPvz.
 type GetPvzParams struct {
 	// StartDate Начальная дата диапазона
-	StartDate *time.Time `form:"startDate,omitempty" json:"startDate,omitempty"`
+	StartDate time.Time `form:"startDate" json:"startDate"`
 
 	// EndDate Конечная дата диапазона
-	EndDate *time.Time `form:"endDate,omitempty" json:"endDate,omitempty"`
+	EndDate time.Time `form:"endDate" json:"endDate"`
 
 	// Page Номер страницы
-	Page *int `form:"page,omitempty" json:"page,omitempty"`
+	Page *Page `form:"page,omitempty" json:"page,omitempty"`
 
 	// Limit Количество элементов на странице
-	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+	Limit *Limit `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor Курсорная пагинация. Пустой — первая страница, иначе nextCursor из предыдущего ответа; с ним ответ — объект с items и nextCursor
+	Cursor *Cursor `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// IncludeDeleted Показать удаленные товары (только для модераторов)
+	IncludeDeleted *bool `form:"includeDeleted,omitempty" json:"includeDeleted,omitempty"`
+}
+
+// PostPvzJSONBody defines parameters for PostPvz.
+type PostPvzJSONBody struct {
+	// City Включенный город из справочника GET /cities
+	City string `json:"city"`
+}
+
+// PatchPvzPvzIdJSONBody defines parameters for PatchPvzPvzId.
+type PatchPvzPvzIdJSONBody struct {
+	Active  *bool   `json:"active,omitempty"`
+	Address *string `json:"address,omitempty"`
+	Name    *string `json:"name,omitempty"`
+}
+
+// GetPvzPvzIdReceptionsParams defines parameters for GetPvzPvzIdReceptions.
+type GetPvzPvzIdReceptionsParams struct {
+	Status    *GetPvzPvzIdReceptionsParamsStatus `form:"status,omitempty" json:"status,omitempty"`
+	StartDate *time.Time                         `form:"startDate,omitempty" json:"startDate,omitempty"`
+	EndDate   *time.Time                         `form:"endDate,omitempty" json:"endDate,omitempty"`
+
+	// Page Номер страницы
+	Page *Page `form:"page,omitempty" json:"page,omitempty"`
+
+	// Limit Количество элементов на странице
+	Limit *Limit `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor Курсорная пагинация. Пустой — первая страница, иначе nextCursor из предыдущего ответа; с ним ответ — объект с items и nextCursor
+	Cursor *Cursor `form:"cursor,omitempty" json:"cursor,omitempty"`
+}
+
+// GetPvzPvzIdReceptionsParamsStatus defines parameters for GetPvzPvzIdReceptions.
+type GetPvzPvzIdReceptionsParamsStatus string
+
+// PostPvzPvzIdStaffJSONBody defines parameters for PostPvzPvzIdStaff.
+type PostPvzPvzIdStaffJSONBody struct {
+	// Subject sub сотрудника; если это email пользователя, его роль должна быть staff
+	Subject string `json:"subject"`
 }
 
 // PostReceptionsJSONBody defines parameters for PostReceptions.
@@ -153,28 +533,83 @@ type PostReceptionsJSONBody struct {
 
 // PostRegisterJSONBody defines parameters for PostRegister.
 type PostRegisterJSONBody struct {
-	Email    openapi_types.Email      `json:"email"`
-	Password string                   `json:"password"`
-	Role     PostRegisterJSONBodyRole `json:"role"`
+	Email    openapi_types.Email `json:"email"`
+	Password string              `json:"password"`
+
+	// Role Роль staff (employee) самостоятельно получить нельзя
+	Role PostRegisterJSONBodyRole `json:"role"`
 }
 
 // PostRegisterJSONBodyRole defines parameters for PostRegister.
 type PostRegisterJSONBodyRole string
 
+// PostTokenRefreshJSONBody defines parameters for PostTokenRefresh.
+type PostTokenRefreshJSONBody struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// PostWebhooksJSONBody defines parameters for PostWebhooks.
+type PostWebhooksJSONBody struct {
+	EventTypes *[]WebhookEventType `json:"eventTypes,omitempty"`
+
+	// Secret Если не задан, сервис сгенерирует его
+	Secret *string `json:"secret,omitempty"`
+	Url    string  `json:"url"`
+}
+
+// GetWebhooksWebhookIdDeliveriesParams defines parameters for GetWebhooksWebhookIdDeliveries.
+type GetWebhooksWebhookIdDeliveriesParams struct {
+	Status *GetWebhooksWebhookIdDeliveriesParamsStatus `form:"status,omitempty" json:"status,omitempty"`
+	Limit  *int                                        `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetWebhooksWebhookIdDeliveriesParamsStatus defines parameters for GetWebhooksWebhookIdDeliveries.
+type GetWebhooksWebhookIdDeliveriesParamsStatus string
+
+// PostCitiesJSONRequestBody defines body for PostCities for application/json ContentType.
+type PostCitiesJSONRequestBody = City
+
+// PatchCitiesNameJSONRequestBody defines body for PatchCitiesName for application/json ContentType.
+type PatchCitiesNameJSONRequestBody PatchCitiesNameJSONBody
+
 // PostDummyLoginJSONRequestBody defines body for PostDummyLogin for application/json ContentType.
 type PostDummyLoginJSONRequestBody PostDummyLoginJSONBody
 
 // PostLoginJSONRequestBody defines body for PostLogin for application/json ContentType.
 type PostLoginJSONRequestBody PostLoginJSONBody
 
+// PostLogoutJSONRequestBody defines body for PostLogout for application/json ContentType.
+type PostLogoutJSONRequestBody PostLogoutJSONBody
+
+// PostProductTypesJSONRequestBody defines body for PostProductTypes for application/json ContentType.
+type PostProductTypesJSONRequestBody = ProductType
+
+// PutProductTypesCodeJSONRequestBody defines body for PutProductTypesCode for application/json ContentType.
+type PutProductTypesCodeJSONRequestBody = ProductType
+
 // PostProductsJSONRequestBody defines body for PostProducts for application/json ContentType.
 type PostProductsJSONRequestBody PostProductsJSONBody
 
+// PostProductsBatchJSONRequestBody defines body for PostProductsBatch for application/json ContentType.
+type PostProductsBatchJSONRequestBody PostProductsBatchJSONBody
+
 // PostPvzJSONRequestBody defines body for PostPvz for application/json ContentType.
-type PostPvzJSONRequestBody = PVZ
+type PostPvzJSONRequestBody PostPvzJSONBody
+
+// PatchPvzPvzIdJSONRequestBody defines body for PatchPvzPvzId for application/json ContentType.
+type PatchPvzPvzIdJSONRequestBody PatchPvzPvzIdJSONBody
+
+// PostPvzPvzIdStaffJSONRequestBody defines body for PostPvzPvzIdStaff for application/json ContentType.
+type PostPvzPvzIdStaffJSONRequestBody PostPvzPvzIdStaffJSONBody
 
 // PostReceptionsJSONRequestBody defines body for PostReceptions for application/json ContentType.
 type PostReceptionsJSONRequestBody PostReceptionsJSONBody
 
 // PostRegisterJSONRequestBody defines body for PostRegister for application/json ContentType.
 type PostRegisterJSONRequestBody PostRegisterJSONBody
+
+// PostTokenRefreshJSONRequestBody defines body for PostTokenRefresh for application/json ContentType.
+type PostTokenRefreshJSONRequestBody PostTokenRefreshJSONBody
+
+// PostWebhooksJSONRequestBody defines body for PostWebhooks for application/json ContentType.
+type PostWebhooksJSONRequestBody PostWebhooksJSONBody
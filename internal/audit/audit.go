@@ -0,0 +1,34 @@
+// Package audit передаёт сведения об инициаторе запроса от HTTP- и
+// gRPC-слоя до хранилища, которое пишет журнал аудита.
+package audit
+
+import "context"
+
+// System — инициатор действий фоновых задач, выполняемых без запроса.
+const System = "system"
+
+// Actor — кто и откуда выполняет запрос.
+type Actor struct {
+	// Subject — sub из JWT.
+	Subject   string
+	Role      string
+	RequestID string
+	ClientIP  string
+}
+
+type actorKey struct{}
+
+// WithActor кладёт инициатора в контекст запроса.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorFrom возвращает инициатора из контекста. Без него действие
+// приписывается System.
+func ActorFrom(ctx context.Context) Actor {
+	a, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok || a.Subject == "" {
+		a.Subject = System
+	}
+	return a
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FromEnv собирает связку ключей из переменных окружения:
+//
+//	JWT_SIGNING_KEY  — PEM-файл с закрытым ключом RSA или Ed25519;
+//	JWT_VERIFY_KEYS  — PEM-файлы открытых ключей через запятую, которые
+//	                   ещё принимаются после ротации;
+//	JWT_SECRET       — общий секрет HS256, если асимметричный ключ не задан.
+//
+// Без ключа возвращает ErrNoKey: сервис не должен стартовать с секретом по умолчанию.
+func FromEnv() (*Keyring, error) {
+	path := os.Getenv("JWT_SIGNING_KEY")
+	if path == "" {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return nil, ErrNoKey
+		}
+		return NewKeyring(NewHMACKey([]byte(secret))), nil
+	}
+
+	private, err := readPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+	signing, err := NewSigningKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var verify []*Key
+	for _, p := range strings.Split(os.Getenv("JWT_VERIFY_KEYS"), ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		public, err := readPublicKey(p)
+		if err != nil {
+			return nil, err
+		}
+		key, err := NewVerifyKey(public)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		verify = append(verify, key)
+	}
+	return NewKeyring(signing, verify...), nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: файл не в формате PEM", path)
+	}
+	return block, nil
+}
+
+// readPrivateKey читает ключ в PKCS#8 или, для RSA, в PKCS#1.
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: неподдерживаемый тип ключа %T", path, key)
+	}
+	return signer, nil
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,172 @@
+// Package auth выпускает и проверяет JWT. Это единственное место,
+// где сервис работает с ключами подписи.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"avito-pvz-service/internal/rbac"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoKey              = errors.New("не задан ключ подписи JWT: укажите JWT_SIGNING_KEY или JWT_SECRET")
+	ErrInvalidToken       = errors.New("Invalid token")
+	ErrInvalidTokenClaims = errors.New("Invalid token claims")
+	ErrTokenRevoked       = errors.New("Token revoked")
+)
+
+// AccessTokenTTL — срок жизни access-токена. Он короткий, потому что
+// проверяется без похода в базу; долгую сессию держит refresh-токен.
+const AccessTokenTTL = 15 * time.Minute
+
+// Key — ключ проверки подписи; у ключа подписи заполнен ещё и signer.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	public interface{}
+	signer interface{}
+}
+
+// Keyring подписывает токены одним активным ключом и принимает токены,
+// подписанные любым из ключей проверки. При ротации новый ключ становится
+// ключом подписи, а старый остаётся в списке проверки, пока не истекут его токены.
+type Keyring struct {
+	signing *Key
+	verify  map[string]*Key
+}
+
+// NewKeyring собирает связку из ключа подписи и дополнительных ключей проверки.
+func NewKeyring(signing *Key, verify ...*Key) *Keyring {
+	k := &Keyring{
+		signing: signing,
+		verify:  map[string]*Key{signing.ID: signing},
+	}
+	for _, v := range verify {
+		k.verify[v.ID] = v
+	}
+	return k
+}
+
+// NewHMACKey — симметричный ключ HS256 для локального запуска и тестов.
+// Такой ключ не публикуется в JWKS.
+func NewHMACKey(secret []byte) *Key {
+	return &Key{ID: "", Method: jwt.SigningMethodHS256, public: secret, signer: secret}
+}
+
+// NewSigningKey оборачивает закрытый ключ RSA (RS256) или Ed25519 (EdDSA).
+// kid вычисляется по открытому ключу, поэтому совпадает у всех сервисов.
+func NewSigningKey(private crypto.Signer) (*Key, error) {
+	key, err := NewVerifyKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.signer = private
+	return key, nil
+}
+
+// NewVerifyKey оборачивает открытый ключ, которым только проверяются подписи.
+func NewVerifyKey(public crypto.PublicKey) (*Key, error) {
+	var method jwt.SigningMethod
+	switch public.(type) {
+	case *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа %T: нужен RSA или Ed25519", public)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+	return &Key{
+		ID:     base64.RawURLEncoding.EncodeToString(sum[:12]),
+		Method: method,
+		public: public,
+	}, nil
+}
+
+// Issue выпускает access-токен для пользователя sub с ролью role.
+// jti позволяет отозвать токен до истечения срока.
+func (k *Keyring) Issue(sub string, role rbac.Role) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":  sub,
+		"role": string(role),
+		"jti":  uuid.New().String(),
+		"exp":  now.Add(AccessTokenTTL).Unix(),
+		"iat":  now.Unix(),
+	}
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	if k.signing.ID != "" {
+		token.Header["kid"] = k.signing.ID
+	}
+	return token.SignedString(k.signing.signer)
+}
+
+// Parse проверяет подпись и срок действия токена и возвращает его claims.
+// Ключ выбирается по kid, а алгоритм токена обязан совпадать с алгоритмом ключа.
+func (k *Keyring) Parse(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := k.verify[kid]
+		if !ok || t.Method.Alg() != key.Method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return key.public, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidTokenClaims
+	}
+	return claims, nil
+}
+
+// RevocationList — отозванные до истечения срока access-токены.
+type RevocationList interface {
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// Verify проверяет токен как Parse и дополнительно сверяет jti со списком отзыва.
+// Токены без jti не принимаются: их нельзя отозвать.
+func (k *Keyring) Verify(ctx context.Context, tokenString string, revoked RevocationList) (jwt.MapClaims, error) {
+	claims, err := k.Parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil, ErrInvalidTokenClaims
+	}
+	isRevoked, err := revoked.IsAccessTokenRevoked(ctx, jti)
+	if err != nil {
+		return nil, err
+	}
+	if isRevoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// IsRejected отличает отказ в доступе от сбоя проверки списка отзыва.
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrInvalidTokenClaims) || errors.Is(err, ErrTokenRevoked)
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"avito-pvz-service/internal/rbac"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func newEdKey(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func writePEM(t *testing.T, typ string, der []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600))
+	return path
+}
+
+func TestKeyring_IssueAndParse(t *testing.T) {
+	rsaKey, err := NewSigningKey(newRSAKey(t))
+	require.NoError(t, err)
+	edKey, err := NewSigningKey(newEdKey(t))
+	require.NoError(t, err)
+
+	for _, key := range []*Key{rsaKey, edKey, NewHMACKey([]byte("secret"))} {
+		t.Run(key.Method.Alg(), func(t *testing.T) {
+			keys := NewKeyring(key)
+			token, err := keys.Issue("user@example.com", rbac.RoleStaff)
+			require.NoError(t, err)
+
+			claims, err := keys.Parse(token)
+			require.NoError(t, err)
+			assert.Equal(t, "user@example.com", claims["sub"])
+			assert.Equal(t, "staff", claims["role"])
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, key.Method.Alg(), parsed.Method.Alg())
+			if key.ID != "" {
+				assert.Equal(t, key.ID, parsed.Header["kid"])
+			}
+		})
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	oldKey, err := NewSigningKey(newRSAKey(t))
+	require.NoError(t, err)
+	newKey, err := NewSigningKey(newEdKey(t))
+	require.NoError(t, err)
+
+	oldToken, err := NewKeyring(oldKey).Issue("a", rbac.RoleModerator)
+	require.NoError(t, err)
+
+	// Старый ключ остаётся в проверке после ротации
+	oldPublic, err := NewVerifyKey(oldKey.public)
+	require.NoError(t, err)
+	rotated := NewKeyring(newKey, oldPublic)
+	_, err = rotated.Parse(oldToken)
+	assert.NoError(t, err)
+
+	// ...и перестаёт приниматься, когда его убрали
+	_, err = NewKeyring(newKey).Parse(oldToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	jwks := rotated.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	for _, k := range jwks.Keys {
+		assert.Equal(t, "sig", k.Use)
+		switch k.Kid {
+		case oldKey.ID:
+			assert.Equal(t, "RSA", k.Kty)
+			assert.Equal(t, "AQAB", k.E)
+		case newKey.ID:
+			assert.Equal(t, "OKP", k.Kty)
+			assert.Equal(t, "Ed25519", k.Crv)
+		default:
+			t.Fatalf("неожиданный kid %q", k.Kid)
+		}
+	}
+}
+
+func TestKeyring_RejectsForeignTokens(t *testing.T) {
+	rsaKey, err := NewSigningKey(newRSAKey(t))
+	require.NoError(t, err)
+	keys := NewKeyring(rsaKey)
+
+	// HS256-токен без kid не принимается связкой с асимметричным ключом
+	hs, err := NewKeyring(NewHMACKey([]byte("secret"))).Issue("a", rbac.RoleModerator)
+	require.NoError(t, err)
+	_, err = keys.Parse(hs)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Подмена алгоритма при правильном kid
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()})
+	forged.Header["kid"] = rsaKey.ID
+	signed, err := forged.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = keys.Parse(signed)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	expired := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})
+	expired.Header["kid"] = rsaKey.ID
+	signed, err = expired.SignedString(rsaKey.signer)
+	require.NoError(t, err)
+	_, err = keys.Parse(signed)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("JWT_SIGNING_KEY", "")
+	t.Setenv("JWT_VERIFY_KEYS", "")
+	t.Setenv("JWT_SECRET", "")
+
+	_, err := FromEnv()
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	t.Setenv("JWT_SECRET", "secret")
+	keys, err := FromEnv()
+	require.NoError(t, err)
+	assert.Empty(t, keys.JWKS().Keys)
+
+	rsaKey := newRSAKey(t)
+	t.Setenv("JWT_SIGNING_KEY", writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)))
+	edPublic, err := x509.MarshalPKIXPublicKey(newEdKey(t).Public())
+	require.NoError(t, err)
+	t.Setenv("JWT_VERIFY_KEYS", writePEM(t, "PUBLIC KEY", edPublic))
+
+	keys, err = FromEnv()
+	require.NoError(t, err)
+	assert.Len(t, keys.JWKS().Keys, 2)
+	token, err := keys.Issue("a", rbac.RoleStaff)
+	require.NoError(t, err)
+	_, err = keys.Parse(token)
+	assert.NoError(t, err)
+
+	edPKCS8, err := x509.MarshalPKCS8PrivateKey(newEdKey(t))
+	require.NoError(t, err)
+	t.Setenv("JWT_SIGNING_KEY", writePEM(t, "PRIVATE KEY", edPKCS8))
+	t.Setenv("JWT_VERIFY_KEYS", "")
+	keys, err = FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", keys.JWKS().Keys[0].Alg)
+
+	t.Setenv("JWT_SIGNING_KEY", filepath.Join(t.TempDir(), "missing.pem"))
+	_, err = FromEnv()
+	assert.Error(t, err)
+}
+
+type revocations map[string]bool
+
+func (r revocations) IsAccessTokenRevoked(_ context.Context, jti string) (bool, error) {
+	return r[jti], nil
+}
+
+func TestKeyring_Verify(t *testing.T) {
+	keys := NewKeyring(NewHMACKey([]byte("secret")))
+	token, err := keys.Issue("a", rbac.RoleStaff)
+	require.NoError(t, err)
+	claims, err := keys.Parse(token)
+	require.NoError(t, err)
+	jti := claims["jti"].(string)
+
+	_, err = keys.Verify(context.Background(), token, revocations{})
+	assert.NoError(t, err)
+
+	_, err = keys.Verify(context.Background(), token, revocations{jti: true})
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	assert.True(t, IsRejected(err))
+
+	// Без jti токен нельзя отозвать, поэтому он не принимается
+	noJTI, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = keys.Verify(context.Background(), noJTI, revocations{})
+	assert.ErrorIs(t, err, ErrInvalidTokenClaims)
+}
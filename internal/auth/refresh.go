@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshTokenTTL — срок жизни refresh-токена. Каждый обмен выдаёт новый токен
+// с полным сроком, старый становится одноразово использованным.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// NewRefreshToken возвращает случайный непрозрачный токен для клиента
+// и его хэш для хранения в базе.
+func NewRefreshToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken — SHA-256 в hex. Соль не нужна: токен и так случайный.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
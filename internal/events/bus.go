@@ -0,0 +1,182 @@
+// Package events — внутрипроцессная шина доменных событий о приёмках и товарах.
+//
+// Каждому событию присваивается возрастающий номер Seq. Шина хранит
+// последние события, чтобы подписчик мог продолжить с места обрыва.
+// Публикация никогда не блокируется: если подписчик не успевает читать,
+// его подписка закрывается с ErrSlowConsumer.
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	ReceptionOpened Type = "reception_opened"
+	ReceptionClosed Type = "reception_closed"
+	ProductAdded    Type = "product_added"
+	ProductRemoved  Type = "product_removed"
+
+	ReceptionReopened  Type = "reception_reopened"
+	ReceptionCancelled Type = "reception_cancelled"
+)
+
+var (
+	ErrSlowConsumer = errors.New("подписчик не успевает читать события")
+	ErrSeqExpired   = errors.New("события после указанного seq уже вытеснены из истории")
+	ErrSeqAhead     = errors.New("указанный seq ещё не выдавался")
+)
+
+// Event — изменение приёмки или товара в ПВЗ.
+type Event struct {
+	Seq         uint64
+	Type        Type
+	Time        time.Time
+	PVZId       string
+	City        string
+	ReceptionId string
+	ProductId   string
+	ProductType string
+}
+
+// Filter выбирает события одного ПВЗ или целого города. Пустой фильтр — все события.
+type Filter struct {
+	PVZId string
+	City  string
+}
+
+func (f Filter) match(e Event) bool {
+	if f.PVZId != "" && f.PVZId != e.PVZId {
+		return false
+	}
+	if f.City != "" && f.City != e.City {
+		return false
+	}
+	return true
+}
+
+// Bus раздаёт события подписчикам. Нулевой указатель — валидная шина,
+// которая молча отбрасывает события.
+type Bus struct {
+	mu          sync.Mutex
+	seq         uint64
+	history     []Event
+	historySize int
+	subs        map[*Subscription]struct{}
+}
+
+// NewBus создаёт шину, помнящую последние historySize событий.
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		historySize: historySize,
+		subs:        make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish присваивает событию номер и рассылает его подписчикам.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	e.Seq = b.seq
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for sub := range b.subs {
+		if !sub.filter.match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			b.drop(sub, ErrSlowConsumer)
+		}
+	}
+}
+
+// Subscribe подписывается на события по фильтру. Если afterSeq > 0, сначала
+// будут выданы сохранённые события с номерами больше afterSeq. buffer —
+// сколько событий может накопиться у подписчика, прежде чем он будет отключён.
+// Вторым значением возвращается номер последнего события на момент подписки:
+// всё, что опубликовано позже, придёт в подписку.
+func (b *Bus) Subscribe(filter Filter, afterSeq uint64, buffer int) (*Subscription, uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if afterSeq > 0 {
+		if afterSeq > b.seq {
+			return nil, 0, ErrSeqAhead
+		}
+		// Самое старое событие в истории должно идти сразу за afterSeq
+		if afterSeq < b.seq && (len(b.history) == 0 || b.history[0].Seq > afterSeq+1) {
+			return nil, 0, ErrSeqExpired
+		}
+		for _, e := range b.history {
+			if e.Seq > afterSeq && filter.match(e) {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	sub := &Subscription{
+		bus:    b,
+		filter: filter,
+		ch:     make(chan Event, buffer+len(replay)),
+	}
+	for _, e := range replay {
+		sub.ch <- e
+	}
+	b.subs[sub] = struct{}{}
+	return sub, b.seq, nil
+}
+
+// drop отключает подписчика. Вызывается под блокировкой.
+func (b *Bus) drop(sub *Subscription, reason error) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	sub.err = reason
+	close(sub.ch)
+}
+
+// Subscription — поток событий одного подписчика.
+type Subscription struct {
+	bus    *Bus
+	filter Filter
+	ch     chan Event
+	err    error
+}
+
+// Events закрывается, когда подписка завершена; причину возвращает Err.
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Err возвращает причину отключения или nil, если подписку закрыл сам клиент.
+func (s *Subscription) Err() error {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.err
+}
+
+// Close отписывается от шины.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.drop(s, nil)
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func drain(sub *Subscription) []Event {
+	var got []Event
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return got
+			}
+			got = append(got, e)
+		default:
+			return got
+		}
+	}
+}
+
+func TestBus_Filter(t *testing.T) {
+	bus := NewBus(10)
+	byPVZ, _, err := bus.Subscribe(Filter{PVZId: "a"}, 0, 10)
+	require.NoError(t, err)
+	byCity, _, err := bus.Subscribe(Filter{City: "Казань"}, 0, 10)
+	require.NoError(t, err)
+
+	bus.Publish(Event{Type: ReceptionOpened, PVZId: "a", City: "Москва"})
+	bus.Publish(Event{Type: ReceptionOpened, PVZId: "b", City: "Казань"})
+	bus.Publish(Event{Type: ProductAdded, PVZId: "a", City: "Москва"})
+
+	got := drain(byPVZ)
+	require.Len(t, got, 2)
+	assert.Equal(t, uint64(1), got[0].Seq)
+	assert.Equal(t, uint64(3), got[1].Seq)
+	assert.False(t, got[0].Time.IsZero())
+
+	got = drain(byCity)
+	require.Len(t, got, 1)
+	assert.Equal(t, "b", got[0].PVZId)
+}
+
+func TestBus_Resume(t *testing.T) {
+	bus := NewBus(3)
+	for i := 0; i < 5; i++ {
+		bus.Publish(Event{Type: ProductAdded, PVZId: "a"})
+	}
+
+	// В истории seq 3..5: продолжить можно после 2 и позже
+	sub, lastSeq, err := bus.Subscribe(Filter{PVZId: "a"}, 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), lastSeq)
+	got := drain(sub)
+	require.Len(t, got, 3)
+	assert.Equal(t, uint64(3), got[0].Seq)
+
+	sub, _, err = bus.Subscribe(Filter{}, 5, 1)
+	require.NoError(t, err)
+	assert.Empty(t, drain(sub))
+
+	_, _, err = bus.Subscribe(Filter{}, 1, 1)
+	assert.ErrorIs(t, err, ErrSeqExpired)
+
+	_, _, err = bus.Subscribe(Filter{}, 6, 1)
+	assert.ErrorIs(t, err, ErrSeqAhead)
+}
+
+func TestBus_SlowConsumerIsDropped(t *testing.T) {
+	bus := NewBus(10)
+	slow, _, err := bus.Subscribe(Filter{}, 0, 2)
+	require.NoError(t, err)
+	fast, _, err := bus.Subscribe(Filter{}, 0, 10)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		bus.Publish(Event{Type: ProductAdded})
+	}
+
+	// Публикация не блокируется, медленный подписчик получает то, что успело
+	// попасть в буфер, после чего канал закрывается
+	assert.Len(t, drain(slow), 2)
+	_, ok := <-slow.Events()
+	assert.False(t, ok)
+	assert.ErrorIs(t, slow.Err(), ErrSlowConsumer)
+
+	assert.Len(t, drain(fast), 3)
+	assert.NoError(t, fast.Err())
+}
+
+func TestBus_Close(t *testing.T) {
+	bus := NewBus(10)
+	sub, _, err := bus.Subscribe(Filter{}, 0, 1)
+	require.NoError(t, err)
+
+	sub.Close()
+	sub.Close()
+	bus.Publish(Event{Type: ProductAdded})
+
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.NoError(t, sub.Err())
+
+	var nilBus *Bus
+	nilBus.Publish(Event{Type: ProductAdded})
+}
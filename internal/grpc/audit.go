@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+
+	pvz_v1 "avito-pvz-service/internal/grpc/pvz/v1"
+	"avito-pvz-service/internal/repository"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *server) ListAuditLog(ctx context.Context, req *pvz_v1.ListAuditLogRequest) (*pvz_v1.ListAuditLogResponse, error) {
+	filter := repository.AuditFilter{
+		Actor:      req.GetActor(),
+		Action:     req.GetAction(),
+		EntityType: req.GetEntityType(),
+		EntityID:   req.GetEntityId(),
+	}
+	if req.GetFrom() != nil {
+		t := req.GetFrom().AsTime()
+		filter.From = &t
+	}
+	if req.GetTo() != nil {
+		t := req.GetTo().AsTime()
+		filter.To = &t
+	}
+	page, limit := pageParams(req.GetPage(), req.GetLimit())
+
+	entries, err := s.repos.Audit.ListAudit(ctx, filter, page, limit)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pvz_v1.ListAuditLogResponse{}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, &pvz_v1.AuditEntry{
+			Id:         e.ID,
+			Time:       timestamppb.New(e.Time),
+			Actor:      e.Actor,
+			Role:       e.Role,
+			Action:     e.Action,
+			EntityType: e.EntityType,
+			EntityId:   e.EntityID,
+			Before:     string(e.Before),
+			After:      string(e.After),
+			RequestId:  e.RequestID,
+			ClientIp:   e.ClientIP,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,163 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"avito-pvz-service/internal/audit"
+	"avito-pvz-service/internal/auth"
+	pvz_v1 "avito-pvz-service/internal/grpc/pvz/v1"
+	"avito-pvz-service/internal/rbac"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethods не требуют JWT.
+var publicMethods = map[string]bool{
+	pvz_v1.PVZService_GetPVZList_FullMethodName: true,
+}
+
+type claimsKey struct{}
+
+// authorizer проверяет JWT из метаданных "authorization: Bearer <token>",
+// сверяет роль с политикой доступа и кладёт claims в контекст запроса.
+type authorizer struct {
+	keys    *auth.Keyring
+	revoked auth.RevocationList
+	policy  *rbac.Policy
+}
+
+func (a *authorizer) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// stream — то же для потоковых методов.
+func (a *authorizer) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if publicMethods[info.FullMethod] {
+		return handler(srv, ss)
+	}
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authedStream подменяет контекст потока на контекст с claims.
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (a *authorizer) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	ctx, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := ctx.Value(claimsKey{}).(jwt.MapClaims)
+	name, _ := claims["role"].(string)
+	role, err := rbac.ParseRole(name)
+	if err != nil || !a.policy.AllowRPC(fullMethod, role) {
+		return nil, status.Errorf(codes.PermissionDenied, "Доступ запрещен для роли %q", name)
+	}
+	return withActor(ctx, claims), nil
+}
+
+// withActor кладёт в контекст инициатора для журнала аудита. id запроса
+// берётся из метаданных "x-request-id" или генерируется.
+func withActor(ctx context.Context, claims jwt.MapClaims) context.Context {
+	actor := audit.Actor{}
+	actor.Subject, _ = claims["sub"].(string)
+	actor.Role, _ = claims["role"].(string)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get("x-request-id"); len(ids) > 0 && ids[0] != "" && len(ids[0]) <= 128 {
+		actor.RequestID = ids[0]
+	} else {
+		actor.RequestID = uuid.New().String()
+	}
+	actor.ClientIP = peerIP(ctx)
+	return audit.WithActor(ctx, actor)
+}
+
+// peerIP — адрес клиента без порта.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+func (a *authorizer) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Missing authorization metadata")
+	}
+	parts := strings.SplitN(values[0], " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, status.Error(codes.Unauthenticated, "Invalid authorization metadata format")
+	}
+
+	claims, err := a.keys.Verify(ctx, parts[1], a.revoked)
+	if auth.IsRejected(err) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return context.WithValue(ctx, claimsKey{}, claims), nil
+}
+
+// subjectOf возвращает sub из проверенного токена.
+func subjectOf(ctx context.Context) string {
+	claims, _ := ctx.Value(claimsKey{}).(jwt.MapClaims)
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// roleOf возвращает роль из проверенного токена.
+func roleOf(ctx context.Context) rbac.Role {
+	claims, _ := ctx.Value(claimsKey{}).(jwt.MapClaims)
+	name, _ := claims["role"].(string)
+	role, _ := rbac.ParseRole(name)
+	return role
+}
+
+// requireAssignment пропускает сотрудника только в назначенные ему ПВЗ.
+func (s *server) requireAssignment(ctx context.Context, pvzId string) error {
+	if roleOf(ctx) != rbac.RoleStaff {
+		return nil
+	}
+	ok, err := s.repos.Staff.IsStaffAssigned(ctx, subjectOf(ctx), pvzId)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if !ok {
+		return status.Error(codes.PermissionDenied, "Сотрудник не назначен в этот ПВЗ")
+	}
+	return nil
+}
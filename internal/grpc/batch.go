@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"io"
+
+	pvz_v1 "avito-pvz-service/internal/grpc/pvz/v1"
+	"avito-pvz-service/internal/metrics"
+	"avito-pvz-service/internal/repository"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var batchModes = map[pvz_v1.BatchMode]repository.BatchMode{
+	pvz_v1.BatchMode_BATCH_MODE_UNSPECIFIED:    repository.BatchAllOrNothing,
+	pvz_v1.BatchMode_BATCH_MODE_ALL_OR_NOTHING: repository.BatchAllOrNothing,
+	pvz_v1.BatchMode_BATCH_MODE_BEST_EFFORT:    repository.BatchBestEffort,
+}
+
+// AddProducts принимает поток позиций и добавляет их одним пакетом после
+// закрытия потока клиентом. Позиции копятся в памяти, поэтому их число
+// ограничено repository.MaxBatchSize.
+func (s *server) AddProducts(stream pvz_v1.PVZService_AddProductsServer) error {
+	ctx := stream.Context()
+
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "Empty stream")
+	}
+	if err != nil {
+		return err
+	}
+	header := first.GetHeader()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "First message must be a header")
+	}
+	if err := validPVZId(header.GetPvzId()); err != nil {
+		return err
+	}
+	mode, ok := batchModes[header.GetMode()]
+	if !ok {
+		return status.Error(codes.InvalidArgument, "Unknown batch mode")
+	}
+	if err := s.requireAssignment(ctx, header.GetPvzId()); err != nil {
+		return err
+	}
+
+	var items []repository.NewProduct
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		item := msg.GetItem()
+		if item == nil {
+			return status.Error(codes.InvalidArgument, "Header must be sent once")
+		}
+		if len(items) == repository.MaxBatchSize {
+			return status.Errorf(codes.InvalidArgument, "Batch is limited to %d items", repository.MaxBatchSize)
+		}
+		if len(item.GetBarcode()) > 64 {
+			return status.Error(codes.InvalidArgument, "Barcode is too long")
+		}
+		items = append(items, repository.NewProduct{
+			Type:       item.GetType(),
+			Attributes: item.GetAttributes().AsMap(),
+			Barcode:    item.GetBarcode(),
+		})
+	}
+	if len(items) == 0 {
+		return status.Error(codes.InvalidArgument, "No items")
+	}
+
+	results, err := s.repos.Product.AddProducts(ctx, header.GetPvzId(), items, mode)
+	if err != nil {
+		return toStatus(err)
+	}
+
+	resp := &pvz_v1.AddProductsResponse{}
+	for i, res := range results {
+		out := &pvz_v1.ProductResult{Index: int32(i)}
+		if res.Err != nil {
+			out.Error = res.Err.Error()
+		} else {
+			out.Product = productToProto(*res.Product)
+			resp.Created++
+		}
+		resp.Results = append(resp.Results, out)
+	}
+	metrics.ProductsCreatedTotal.Add(float64(resp.Created))
+	return stream.SendAndClose(resp)
+}
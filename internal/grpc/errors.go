@@ -0,0 +1,45 @@
+package grpc
+
+import (
+	"errors"
+
+	"avito-pvz-service/internal/repository"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus переводит ошибки репозитория в коды gRPC.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrCityNotAllowed),
+		errors.Is(err, repository.ErrInvalidProductType),
+		errors.Is(err, repository.ErrInvalidCursor),
+		errors.Is(err, repository.ErrInvalidProductAttributes),
+		errors.Is(err, repository.ErrInvalidProductTypeSpec),
+		errors.Is(err, repository.ErrInvalidBatchMode),
+		errors.Is(err, repository.ErrInvalidDeletionReason):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repository.ErrPVZNotFound),
+		errors.Is(err, repository.ErrProductTypeNotFound),
+		errors.Is(err, repository.ErrProductNotFound),
+		errors.Is(err, repository.ErrReceptionNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrProductTypeExists),
+		errors.Is(err, repository.ErrDuplicateBarcode):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, repository.ErrReceptionInProgress),
+		errors.Is(err, repository.ErrNoReceptionToClose),
+		errors.Is(err, repository.ErrReceptionClosed),
+		errors.Is(err, repository.ErrNoActiveReception),
+		errors.Is(err, repository.ErrNoProducts),
+		errors.Is(err, repository.ErrPVZInactive),
+		errors.Is(err, repository.ErrPVZHasOpenReception),
+		errors.Is(err, repository.ErrProductTypeInUse),
+		errors.Is(err, repository.ErrInvalidTransition),
+		errors.Is(err, repository.ErrReceptionNotLatest):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
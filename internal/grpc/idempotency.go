@@ -0,0 +1,139 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"avito-pvz-service/internal/audit"
+	pvz_v1 "avito-pvz-service/internal/grpc/pvz/v1"
+	"avito-pvz-service/internal/repository"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// idempotentMethods — изменяющие unary-методы, которые принимают
+// метаданные "idempotency-key". AddProducts — поток, повтор которого
+// ключом не покрыть: дубли в нём отсекает уникальность штрихкода.
+var idempotentMethods = map[string]bool{
+	pvz_v1.PVZService_CreatePVZ_FullMethodName:          true,
+	pvz_v1.PVZService_CreateReception_FullMethodName:    true,
+	pvz_v1.PVZService_CloseLastReception_FullMethodName: true,
+	pvz_v1.PVZService_ReopenReception_FullMethodName:    true,
+	pvz_v1.PVZService_CancelReception_FullMethodName:    true,
+	pvz_v1.PVZService_AddProduct_FullMethodName:         true,
+	pvz_v1.PVZService_DeleteLastProduct_FullMethodName:  true,
+	pvz_v1.PVZService_DeleteProduct_FullMethodName:      true,
+	pvz_v1.PVZService_CreateProductType_FullMethodName:  true,
+	pvz_v1.PVZService_UpdateProductType_FullMethodName:  true,
+	pvz_v1.PVZService_DeleteProductType_FullMethodName:  true,
+}
+
+// maxIdempotencyKeyLen совпадает с размером колонки idempotency_keys.key.
+const maxIdempotencyKeyLen = 255
+
+// idempotency — аналог HTTP-заголовка Idempotency-Key: ответ на первый
+// вызов с ключом хранится ttl и возвращается на повторы с тем же запросом.
+// Ставится после authorizer, ключи разделены по sub.
+type idempotency struct {
+	store repository.IdempotencyRepository
+	ttl   time.Duration
+}
+
+func (i *idempotency) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !idempotentMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get("idempotency-key")
+	if len(keys) == 0 || keys[0] == "" {
+		return handler(ctx, req)
+	}
+	key := keys[0]
+	if len(key) > maxIdempotencyKeyLen {
+		return nil, status.Error(codes.InvalidArgument, "idempotency-key is too long")
+	}
+
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req.(proto.Message))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	scope := audit.ActorFrom(ctx).Subject
+	hash := repository.HashRequest(info.FullMethod, body)
+	prev, err := i.store.ReserveIdempotencyKey(ctx, scope, key, hash, i.ttl)
+	if err != nil {
+		log.Printf("Ошибка резервирования ключа идемпотентности: %v\n", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	switch {
+	case prev == nil:
+	case prev.RequestHash != hash:
+		return nil, status.Error(codes.FailedPrecondition, "idempotency-key is already used with a different request")
+	case !prev.Completed():
+		return nil, status.Error(codes.Aborted, "a request with this idempotency-key is still in progress")
+	default:
+		_ = grpc.SetHeader(ctx, metadata.Pairs("idempotent-replayed", "true"))
+		return replay(prev)
+	}
+
+	resp, err := handler(ctx, req)
+
+	// Клиент мог отменить вызов, а ключ всё равно нужно завершить
+	ctx = context.WithoutCancel(ctx)
+	if serr := i.complete(ctx, scope, key, resp, err); serr != nil {
+		log.Printf("Ошибка сохранения ответа для ключа идемпотентности: %v\n", serr)
+	}
+	return resp, err
+}
+
+// complete сохраняет ответ или ошибку клиента; после сбоя сервера ключ
+// освобождается, чтобы повтор выполнился заново.
+func (i *idempotency) complete(ctx context.Context, scope, key string, resp interface{}, err error) error {
+	if err != nil {
+		st := status.Convert(err)
+		if serverFault(st.Code()) {
+			return i.store.ReleaseIdempotencyKey(ctx, scope, key)
+		}
+		return i.store.CompleteIdempotencyKey(ctx, scope, key, int(st.Code()), "text/plain", []byte(st.Message()))
+	}
+	msg, merr := anypb.New(resp.(proto.Message))
+	if merr != nil {
+		return i.store.ReleaseIdempotencyKey(ctx, scope, key)
+	}
+	data, merr := proto.Marshal(msg)
+	if merr != nil {
+		return i.store.ReleaseIdempotencyKey(ctx, scope, key)
+	}
+	return i.store.CompleteIdempotencyKey(ctx, scope, key, int(codes.OK), "application/protobuf", data)
+}
+
+// replay восстанавливает сохранённый ответ или ошибку.
+func replay(rec *repository.IdempotencyRecord) (interface{}, error) {
+	if code := codes.Code(rec.StatusCode); code != codes.OK {
+		return nil, status.Error(code, string(rec.Response))
+	}
+	var msg anypb.Any
+	if err := proto.Unmarshal(rec.Response, &msg); err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	resp, err := anypb.UnmarshalNew(&msg, proto.UnmarshalOptions{})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return resp, nil
+}
+
+// serverFault — коды сбоев, после которых ответ не сохраняется.
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DeadlineExceeded,
+		codes.Canceled, codes.DataLoss, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
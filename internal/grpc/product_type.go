@@ -0,0 +1,113 @@
+package grpc
+
+import (
+	"context"
+
+	pvz_v1 "avito-pvz-service/internal/grpc/pvz/v1"
+	"avito-pvz-service/internal/repository"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *server) ListProductTypes(ctx context.Context, _ *pvz_v1.ListProductTypesRequest) (*pvz_v1.ListProductTypesResponse, error) {
+	types, err := s.repos.ProductType.ListProductTypes(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pvz_v1.ListProductTypesResponse{}
+	for _, pt := range types {
+		resp.ProductTypes = append(resp.ProductTypes, productTypeToProto(pt))
+	}
+	return resp, nil
+}
+
+func (s *server) CreateProductType(ctx context.Context, req *pvz_v1.CreateProductTypeRequest) (*pvz_v1.CreateProductTypeResponse, error) {
+	pt, err := productTypeFromProto(req.GetProductType())
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.repos.ProductType.CreateProductType(ctx, pt)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pvz_v1.CreateProductTypeResponse{ProductType: productTypeToProto(*created)}, nil
+}
+
+func (s *server) UpdateProductType(ctx context.Context, req *pvz_v1.UpdateProductTypeRequest) (*pvz_v1.UpdateProductTypeResponse, error) {
+	pt, err := productTypeFromProto(req.GetProductType())
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repos.ProductType.UpdateProductType(ctx, pt)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pvz_v1.UpdateProductTypeResponse{ProductType: productTypeToProto(*updated)}, nil
+}
+
+func (s *server) DeleteProductType(ctx context.Context, req *pvz_v1.DeleteProductTypeRequest) (*pvz_v1.DeleteProductTypeResponse, error) {
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Missing code")
+	}
+	if err := s.repos.ProductType.DeleteProductType(ctx, req.GetCode()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pvz_v1.DeleteProductTypeResponse{}, nil
+}
+
+// productTypeFromProto проверяет описание типа так же, как HTTP-ручки.
+func productTypeFromProto(p *pvz_v1.ProductType) (repository.ProductType, error) {
+	if p.GetCode() == "" {
+		return repository.ProductType{}, status.Error(codes.InvalidArgument, "Missing code")
+	}
+	pt := repository.ProductType{
+		Code:    p.GetCode(),
+		Names:   p.GetNames(),
+		Fragile: p.GetFragile(),
+	}
+	for _, a := range p.GetAttributes() {
+		pt.Attributes = append(pt.Attributes, repository.AttributeSpec{
+			Name:     a.GetName(),
+			Kind:     a.GetKind(),
+			Unit:     a.GetUnit(),
+			Required: a.GetRequired(),
+		})
+	}
+	if err := pt.ValidateSpec(); err != nil {
+		return repository.ProductType{}, toStatus(err)
+	}
+	return pt, nil
+}
+
+func productTypeToProto(pt repository.ProductType) *pvz_v1.ProductType {
+	out := &pvz_v1.ProductType{
+		Code:      pt.Code,
+		Names:     pt.Names,
+		Fragile:   pt.Fragile,
+		CreatedAt: timestamppb.New(pt.CreatedAt),
+	}
+	for _, a := range pt.Attributes {
+		out.Attributes = append(out.Attributes, &pvz_v1.AttributeSpec{
+			Name:     a.Name,
+			Kind:     a.Kind,
+			Unit:     a.Unit,
+			Required: a.Required,
+		})
+	}
+	return out
+}
+
+// attributesToProto — атрибуты приходят из JSON, поэтому NewStruct с ними не падает.
+func attributesToProto(attrs map[string]interface{}) *structpb.Struct {
+	if len(attrs) == 0 {
+		return nil
+	}
+	out, err := structpb.NewStruct(attrs)
+	if err != nil {
+		return nil
+	}
+	return out
+}
@@ -21,7 +21,26 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	PVZService_GetPVZList_FullMethodName = "/pvz.v1.PVZService/GetPVZList"
+	PVZService_GetPVZList_FullMethodName          = "/pvz.v1.PVZService/GetPVZList"
+	PVZService_CreatePVZ_FullMethodName           = "/pvz.v1.PVZService/CreatePVZ"
+	PVZService_CreateReception_FullMethodName     = "/pvz.v1.PVZService/CreateReception"
+	PVZService_CloseLastReception_FullMethodName  = "/pvz.v1.PVZService/CloseLastReception"
+	PVZService_ReopenReception_FullMethodName     = "/pvz.v1.PVZService/ReopenReception"
+	PVZService_CancelReception_FullMethodName     = "/pvz.v1.PVZService/CancelReception"
+	PVZService_GetReception_FullMethodName        = "/pvz.v1.PVZService/GetReception"
+	PVZService_GetCurrentReception_FullMethodName = "/pvz.v1.PVZService/GetCurrentReception"
+	PVZService_ListReceptions_FullMethodName      = "/pvz.v1.PVZService/ListReceptions"
+	PVZService_AddProduct_FullMethodName          = "/pvz.v1.PVZService/AddProduct"
+	PVZService_AddProducts_FullMethodName         = "/pvz.v1.PVZService/AddProducts"
+	PVZService_DeleteLastProduct_FullMethodName   = "/pvz.v1.PVZService/DeleteLastProduct"
+	PVZService_DeleteProduct_FullMethodName       = "/pvz.v1.PVZService/DeleteProduct"
+	PVZService_ListPVZRecords_FullMethodName      = "/pvz.v1.PVZService/ListPVZRecords"
+	PVZService_WatchPVZ_FullMethodName            = "/pvz.v1.PVZService/WatchPVZ"
+	PVZService_ListProductTypes_FullMethodName    = "/pvz.v1.PVZService/ListProductTypes"
+	PVZService_CreateProductType_FullMethodName   = "/pvz.v1.PVZService/CreateProductType"
+	PVZService_UpdateProductType_FullMethodName   = "/pvz.v1.PVZService/UpdateProductType"
+	PVZService_DeleteProductType_FullMethodName   = "/pvz.v1.PVZService/DeleteProductType"
+	PVZService_ListAuditLog_FullMethodName        = "/pvz.v1.PVZService/ListAuditLog"
 )
 
 // PVZServiceClient is the client API for PVZService service.
@@ -30,6 +49,45 @@ const (
 type PVZServiceClient interface {
 	// Возвращает все ПВЗ без авторизации
 	GetPVZList(ctx context.Context, in *GetPVZListRequest, opts ...grpc.CallOption) (*GetPVZListResponse, error)
+	// Создание ПВЗ (moderator)
+	CreatePVZ(ctx context.Context, in *CreatePVZRequest, opts ...grpc.CallOption) (*CreatePVZResponse, error)
+	// Открытие приёмки (staff)
+	CreateReception(ctx context.Context, in *CreateReceptionRequest, opts ...grpc.CallOption) (*CreateReceptionResponse, error)
+	// Закрытие последней приёмки (staff)
+	CloseLastReception(ctx context.Context, in *CloseLastReceptionRequest, opts ...grpc.CallOption) (*CloseLastReceptionResponse, error)
+	// Переоткрытие закрытой приёмки, если она последняя в ПВЗ (moderator)
+	ReopenReception(ctx context.Context, in *ReopenReceptionRequest, opts ...grpc.CallOption) (*ReopenReceptionResponse, error)
+	// Отмена приёмки: она пропадает из отчётов (moderator)
+	CancelReception(ctx context.Context, in *CancelReceptionRequest, opts ...grpc.CallOption) (*CancelReceptionResponse, error)
+	// Приёмка в любом статусе вместе с товарами (staff, moderator)
+	GetReception(ctx context.Context, in *GetReceptionRequest, opts ...grpc.CallOption) (*GetReceptionResponse, error)
+	// Открытая приёмка ПВЗ с товарами, NotFound если её нет (staff, moderator)
+	GetCurrentReception(ctx context.Context, in *GetCurrentReceptionRequest, opts ...grpc.CallOption) (*GetCurrentReceptionResponse, error)
+	// История приёмок ПВЗ с фильтрами, новые первыми (staff, moderator)
+	ListReceptions(ctx context.Context, in *ListReceptionsRequest, opts ...grpc.CallOption) (*ListReceptionsResponse, error)
+	// Добавление товара в открытую приёмку (staff)
+	AddProduct(ctx context.Context, in *AddProductRequest, opts ...grpc.CallOption) (*AddProductResponse, error)
+	// Пакетное добавление товаров одной транзакцией (staff).
+	// Первое сообщение — header, за ним позиции.
+	AddProducts(ctx context.Context, opts ...grpc.CallOption) (PVZService_AddProductsClient, error)
+	// Удаление последнего товара по LIFO (staff)
+	DeleteLastProduct(ctx context.Context, in *DeleteLastProductRequest, opts ...grpc.CallOption) (*DeleteLastProductResponse, error)
+	// Удаление конкретного товара из открытой приёмки с причиной (staff)
+	DeleteProduct(ctx context.Context, in *DeleteProductRequest, opts ...grpc.CallOption) (*DeleteProductResponse, error)
+	// ПВЗ с приёмками и товарами за период, курсорная пагинация (staff, moderator)
+	ListPVZRecords(ctx context.Context, in *ListPVZRecordsRequest, opts ...grpc.CallOption) (*ListPVZRecordsResponse, error)
+	// Поток событий о приёмках и товарах одного ПВЗ или города (staff, moderator)
+	WatchPVZ(ctx context.Context, in *WatchPVZRequest, opts ...grpc.CallOption) (PVZService_WatchPVZClient, error)
+	// Справочник типов товаров (все роли)
+	ListProductTypes(ctx context.Context, in *ListProductTypesRequest, opts ...grpc.CallOption) (*ListProductTypesResponse, error)
+	// Добавление типа товара (moderator)
+	CreateProductType(ctx context.Context, in *CreateProductTypeRequest, opts ...grpc.CallOption) (*CreateProductTypeResponse, error)
+	// Полная замена описания типа товара (moderator)
+	UpdateProductType(ctx context.Context, in *UpdateProductTypeRequest, opts ...grpc.CallOption) (*UpdateProductTypeResponse, error)
+	// Удаление типа, по которому ещё нет товаров (moderator)
+	DeleteProductType(ctx context.Context, in *DeleteProductTypeRequest, opts ...grpc.CallOption) (*DeleteProductTypeResponse, error)
+	// Журнал аудита с фильтрами, новые записи первыми (moderator)
+	ListAuditLog(ctx context.Context, in *ListAuditLogRequest, opts ...grpc.CallOption) (*ListAuditLogResponse, error)
 }
 
 type pVZServiceClient struct {
@@ -49,12 +107,270 @@ func (c *pVZServiceClient) GetPVZList(ctx context.Context, in *GetPVZListRequest
 	return out, nil
 }
 
+func (c *pVZServiceClient) CreatePVZ(ctx context.Context, in *CreatePVZRequest, opts ...grpc.CallOption) (*CreatePVZResponse, error) {
+	out := new(CreatePVZResponse)
+	err := c.cc.Invoke(ctx, PVZService_CreatePVZ_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) CreateReception(ctx context.Context, in *CreateReceptionRequest, opts ...grpc.CallOption) (*CreateReceptionResponse, error) {
+	out := new(CreateReceptionResponse)
+	err := c.cc.Invoke(ctx, PVZService_CreateReception_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) CloseLastReception(ctx context.Context, in *CloseLastReceptionRequest, opts ...grpc.CallOption) (*CloseLastReceptionResponse, error) {
+	out := new(CloseLastReceptionResponse)
+	err := c.cc.Invoke(ctx, PVZService_CloseLastReception_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) ReopenReception(ctx context.Context, in *ReopenReceptionRequest, opts ...grpc.CallOption) (*ReopenReceptionResponse, error) {
+	out := new(ReopenReceptionResponse)
+	err := c.cc.Invoke(ctx, PVZService_ReopenReception_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) CancelReception(ctx context.Context, in *CancelReceptionRequest, opts ...grpc.CallOption) (*CancelReceptionResponse, error) {
+	out := new(CancelReceptionResponse)
+	err := c.cc.Invoke(ctx, PVZService_CancelReception_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) GetReception(ctx context.Context, in *GetReceptionRequest, opts ...grpc.CallOption) (*GetReceptionResponse, error) {
+	out := new(GetReceptionResponse)
+	err := c.cc.Invoke(ctx, PVZService_GetReception_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) GetCurrentReception(ctx context.Context, in *GetCurrentReceptionRequest, opts ...grpc.CallOption) (*GetCurrentReceptionResponse, error) {
+	out := new(GetCurrentReceptionResponse)
+	err := c.cc.Invoke(ctx, PVZService_GetCurrentReception_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) ListReceptions(ctx context.Context, in *ListReceptionsRequest, opts ...grpc.CallOption) (*ListReceptionsResponse, error) {
+	out := new(ListReceptionsResponse)
+	err := c.cc.Invoke(ctx, PVZService_ListReceptions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) AddProduct(ctx context.Context, in *AddProductRequest, opts ...grpc.CallOption) (*AddProductResponse, error) {
+	out := new(AddProductResponse)
+	err := c.cc.Invoke(ctx, PVZService_AddProduct_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) AddProducts(ctx context.Context, opts ...grpc.CallOption) (PVZService_AddProductsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PVZService_ServiceDesc.Streams[0], PVZService_AddProducts_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pVZServiceAddProductsClient{stream}
+	return x, nil
+}
+
+type PVZService_AddProductsClient interface {
+	Send(*AddProductsRequest) error
+	CloseAndRecv() (*AddProductsResponse, error)
+	grpc.ClientStream
+}
+
+type pVZServiceAddProductsClient struct {
+	grpc.ClientStream
+}
+
+func (x *pVZServiceAddProductsClient) Send(m *AddProductsRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *pVZServiceAddProductsClient) CloseAndRecv() (*AddProductsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(AddProductsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *pVZServiceClient) DeleteLastProduct(ctx context.Context, in *DeleteLastProductRequest, opts ...grpc.CallOption) (*DeleteLastProductResponse, error) {
+	out := new(DeleteLastProductResponse)
+	err := c.cc.Invoke(ctx, PVZService_DeleteLastProduct_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) DeleteProduct(ctx context.Context, in *DeleteProductRequest, opts ...grpc.CallOption) (*DeleteProductResponse, error) {
+	out := new(DeleteProductResponse)
+	err := c.cc.Invoke(ctx, PVZService_DeleteProduct_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) ListPVZRecords(ctx context.Context, in *ListPVZRecordsRequest, opts ...grpc.CallOption) (*ListPVZRecordsResponse, error) {
+	out := new(ListPVZRecordsResponse)
+	err := c.cc.Invoke(ctx, PVZService_ListPVZRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) WatchPVZ(ctx context.Context, in *WatchPVZRequest, opts ...grpc.CallOption) (PVZService_WatchPVZClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PVZService_ServiceDesc.Streams[1], PVZService_WatchPVZ_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pVZServiceWatchPVZClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PVZService_WatchPVZClient interface {
+	Recv() (*PVZEvent, error)
+	grpc.ClientStream
+}
+
+type pVZServiceWatchPVZClient struct {
+	grpc.ClientStream
+}
+
+func (x *pVZServiceWatchPVZClient) Recv() (*PVZEvent, error) {
+	m := new(PVZEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *pVZServiceClient) ListProductTypes(ctx context.Context, in *ListProductTypesRequest, opts ...grpc.CallOption) (*ListProductTypesResponse, error) {
+	out := new(ListProductTypesResponse)
+	err := c.cc.Invoke(ctx, PVZService_ListProductTypes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) CreateProductType(ctx context.Context, in *CreateProductTypeRequest, opts ...grpc.CallOption) (*CreateProductTypeResponse, error) {
+	out := new(CreateProductTypeResponse)
+	err := c.cc.Invoke(ctx, PVZService_CreateProductType_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) UpdateProductType(ctx context.Context, in *UpdateProductTypeRequest, opts ...grpc.CallOption) (*UpdateProductTypeResponse, error) {
+	out := new(UpdateProductTypeResponse)
+	err := c.cc.Invoke(ctx, PVZService_UpdateProductType_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) DeleteProductType(ctx context.Context, in *DeleteProductTypeRequest, opts ...grpc.CallOption) (*DeleteProductTypeResponse, error) {
+	out := new(DeleteProductTypeResponse)
+	err := c.cc.Invoke(ctx, PVZService_DeleteProductType_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pVZServiceClient) ListAuditLog(ctx context.Context, in *ListAuditLogRequest, opts ...grpc.CallOption) (*ListAuditLogResponse, error) {
+	out := new(ListAuditLogResponse)
+	err := c.cc.Invoke(ctx, PVZService_ListAuditLog_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PVZServiceServer is the server API for PVZService service.
 // All implementations must embed UnimplementedPVZServiceServer
 // for forward compatibility
 type PVZServiceServer interface {
 	// Возвращает все ПВЗ без авторизации
 	GetPVZList(context.Context, *GetPVZListRequest) (*GetPVZListResponse, error)
+	// Создание ПВЗ (moderator)
+	CreatePVZ(context.Context, *CreatePVZRequest) (*CreatePVZResponse, error)
+	// Открытие приёмки (staff)
+	CreateReception(context.Context, *CreateReceptionRequest) (*CreateReceptionResponse, error)
+	// Закрытие последней приёмки (staff)
+	CloseLastReception(context.Context, *CloseLastReceptionRequest) (*CloseLastReceptionResponse, error)
+	// Переоткрытие закрытой приёмки, если она последняя в ПВЗ (moderator)
+	ReopenReception(context.Context, *ReopenReceptionRequest) (*ReopenReceptionResponse, error)
+	// Отмена приёмки: она пропадает из отчётов (moderator)
+	CancelReception(context.Context, *CancelReceptionRequest) (*CancelReceptionResponse, error)
+	// Приёмка в любом статусе вместе с товарами (staff, moderator)
+	GetReception(context.Context, *GetReceptionRequest) (*GetReceptionResponse, error)
+	// Открытая приёмка ПВЗ с товарами, NotFound если её нет (staff, moderator)
+	GetCurrentReception(context.Context, *GetCurrentReceptionRequest) (*GetCurrentReceptionResponse, error)
+	// История приёмок ПВЗ с фильтрами, новые первыми (staff, moderator)
+	ListReceptions(context.Context, *ListReceptionsRequest) (*ListReceptionsResponse, error)
+	// Добавление товара в открытую приёмку (staff)
+	AddProduct(context.Context, *AddProductRequest) (*AddProductResponse, error)
+	// Пакетное добавление товаров одной транзакцией (staff).
+	// Первое сообщение — header, за ним позиции.
+	AddProducts(PVZService_AddProductsServer) error
+	// Удаление последнего товара по LIFO (staff)
+	DeleteLastProduct(context.Context, *DeleteLastProductRequest) (*DeleteLastProductResponse, error)
+	// Удаление конкретного товара из открытой приёмки с причиной (staff)
+	DeleteProduct(context.Context, *DeleteProductRequest) (*DeleteProductResponse, error)
+	// ПВЗ с приёмками и товарами за период, курсорная пагинация (staff, moderator)
+	ListPVZRecords(context.Context, *ListPVZRecordsRequest) (*ListPVZRecordsResponse, error)
+	// Поток событий о приёмках и товарах одного ПВЗ или города (staff, moderator)
+	WatchPVZ(*WatchPVZRequest, PVZService_WatchPVZServer) error
+	// Справочник типов товаров (все роли)
+	ListProductTypes(context.Context, *ListProductTypesRequest) (*ListProductTypesResponse, error)
+	// Добавление типа товара (moderator)
+	CreateProductType(context.Context, *CreateProductTypeRequest) (*CreateProductTypeResponse, error)
+	// Полная замена описания типа товара (moderator)
+	UpdateProductType(context.Context, *UpdateProductTypeRequest) (*UpdateProductTypeResponse, error)
+	// Удаление типа, по которому ещё нет товаров (moderator)
+	DeleteProductType(context.Context, *DeleteProductTypeRequest) (*DeleteProductTypeResponse, error)
+	// Журнал аудита с фильтрами, новые записи первыми (moderator)
+	ListAuditLog(context.Context, *ListAuditLogRequest) (*ListAuditLogResponse, error)
 	mustEmbedUnimplementedPVZServiceServer()
 }
 
@@ -65,6 +381,63 @@ type UnimplementedPVZServiceServer struct {
 func (UnimplementedPVZServiceServer) GetPVZList(context.Context, *GetPVZListRequest) (*GetPVZListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPVZList not implemented")
 }
+func (UnimplementedPVZServiceServer) CreatePVZ(context.Context, *CreatePVZRequest) (*CreatePVZResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePVZ not implemented")
+}
+func (UnimplementedPVZServiceServer) CreateReception(context.Context, *CreateReceptionRequest) (*CreateReceptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateReception not implemented")
+}
+func (UnimplementedPVZServiceServer) CloseLastReception(context.Context, *CloseLastReceptionRequest) (*CloseLastReceptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseLastReception not implemented")
+}
+func (UnimplementedPVZServiceServer) ReopenReception(context.Context, *ReopenReceptionRequest) (*ReopenReceptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReopenReception not implemented")
+}
+func (UnimplementedPVZServiceServer) CancelReception(context.Context, *CancelReceptionRequest) (*CancelReceptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelReception not implemented")
+}
+func (UnimplementedPVZServiceServer) GetReception(context.Context, *GetReceptionRequest) (*GetReceptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReception not implemented")
+}
+func (UnimplementedPVZServiceServer) GetCurrentReception(context.Context, *GetCurrentReceptionRequest) (*GetCurrentReceptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCurrentReception not implemented")
+}
+func (UnimplementedPVZServiceServer) ListReceptions(context.Context, *ListReceptionsRequest) (*ListReceptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReceptions not implemented")
+}
+func (UnimplementedPVZServiceServer) AddProduct(context.Context, *AddProductRequest) (*AddProductResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddProduct not implemented")
+}
+func (UnimplementedPVZServiceServer) AddProducts(PVZService_AddProductsServer) error {
+	return status.Errorf(codes.Unimplemented, "method AddProducts not implemented")
+}
+func (UnimplementedPVZServiceServer) DeleteLastProduct(context.Context, *DeleteLastProductRequest) (*DeleteLastProductResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteLastProduct not implemented")
+}
+func (UnimplementedPVZServiceServer) DeleteProduct(context.Context, *DeleteProductRequest) (*DeleteProductResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteProduct not implemented")
+}
+func (UnimplementedPVZServiceServer) ListPVZRecords(context.Context, *ListPVZRecordsRequest) (*ListPVZRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPVZRecords not implemented")
+}
+func (UnimplementedPVZServiceServer) WatchPVZ(*WatchPVZRequest, PVZService_WatchPVZServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPVZ not implemented")
+}
+func (UnimplementedPVZServiceServer) ListProductTypes(context.Context, *ListProductTypesRequest) (*ListProductTypesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProductTypes not implemented")
+}
+func (UnimplementedPVZServiceServer) CreateProductType(context.Context, *CreateProductTypeRequest) (*CreateProductTypeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateProductType not implemented")
+}
+func (UnimplementedPVZServiceServer) UpdateProductType(context.Context, *UpdateProductTypeRequest) (*UpdateProductTypeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProductType not implemented")
+}
+func (UnimplementedPVZServiceServer) DeleteProductType(context.Context, *DeleteProductTypeRequest) (*DeleteProductTypeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteProductType not implemented")
+}
+func (UnimplementedPVZServiceServer) ListAuditLog(context.Context, *ListAuditLogRequest) (*ListAuditLogResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditLog not implemented")
+}
 func (UnimplementedPVZServiceServer) mustEmbedUnimplementedPVZServiceServer() {}
 
 // UnsafePVZServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -96,6 +469,359 @@ func _PVZService_GetPVZList_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PVZService_CreatePVZ_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePVZRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).CreatePVZ(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_CreatePVZ_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).CreatePVZ(ctx, req.(*CreatePVZRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_CreateReception_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateReceptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).CreateReception(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_CreateReception_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).CreateReception(ctx, req.(*CreateReceptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_CloseLastReception_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseLastReceptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).CloseLastReception(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_CloseLastReception_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).CloseLastReception(ctx, req.(*CloseLastReceptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_ReopenReception_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReopenReceptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).ReopenReception(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_ReopenReception_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).ReopenReception(ctx, req.(*ReopenReceptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_CancelReception_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelReceptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).CancelReception(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_CancelReception_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).CancelReception(ctx, req.(*CancelReceptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_GetReception_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReceptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).GetReception(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_GetReception_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).GetReception(ctx, req.(*GetReceptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_GetCurrentReception_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCurrentReceptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).GetCurrentReception(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_GetCurrentReception_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).GetCurrentReception(ctx, req.(*GetCurrentReceptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_ListReceptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReceptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).ListReceptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_ListReceptions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).ListReceptions(ctx, req.(*ListReceptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_AddProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddProductRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).AddProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_AddProduct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).AddProduct(ctx, req.(*AddProductRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_AddProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PVZServiceServer).AddProducts(&pVZServiceAddProductsServer{stream})
+}
+
+type PVZService_AddProductsServer interface {
+	SendAndClose(*AddProductsResponse) error
+	Recv() (*AddProductsRequest, error)
+	grpc.ServerStream
+}
+
+type pVZServiceAddProductsServer struct {
+	grpc.ServerStream
+}
+
+func (x *pVZServiceAddProductsServer) SendAndClose(m *AddProductsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *pVZServiceAddProductsServer) Recv() (*AddProductsRequest, error) {
+	m := new(AddProductsRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _PVZService_DeleteLastProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteLastProductRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).DeleteLastProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_DeleteLastProduct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).DeleteLastProduct(ctx, req.(*DeleteLastProductRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_DeleteProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteProductRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).DeleteProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_DeleteProduct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).DeleteProduct(ctx, req.(*DeleteProductRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_ListPVZRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPVZRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).ListPVZRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_ListPVZRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).ListPVZRecords(ctx, req.(*ListPVZRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_WatchPVZ_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPVZRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PVZServiceServer).WatchPVZ(m, &pVZServiceWatchPVZServer{stream})
+}
+
+type PVZService_WatchPVZServer interface {
+	Send(*PVZEvent) error
+	grpc.ServerStream
+}
+
+type pVZServiceWatchPVZServer struct {
+	grpc.ServerStream
+}
+
+func (x *pVZServiceWatchPVZServer) Send(m *PVZEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PVZService_ListProductTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProductTypesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).ListProductTypes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_ListProductTypes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).ListProductTypes(ctx, req.(*ListProductTypesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_CreateProductType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateProductTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).CreateProductType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_CreateProductType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).CreateProductType(ctx, req.(*CreateProductTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_UpdateProductType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProductTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).UpdateProductType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_UpdateProductType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).UpdateProductType(ctx, req.(*UpdateProductTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_DeleteProductType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteProductTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).DeleteProductType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_DeleteProductType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).DeleteProductType(ctx, req.(*DeleteProductTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PVZService_ListAuditLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).ListAuditLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_ListAuditLog_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).ListAuditLog(ctx, req.(*ListAuditLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PVZService_ServiceDesc is the grpc.ServiceDesc for PVZService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -107,7 +833,86 @@ var PVZService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPVZList",
 			Handler:    _PVZService_GetPVZList_Handler,
 		},
+		{
+			MethodName: "CreatePVZ",
+			Handler:    _PVZService_CreatePVZ_Handler,
+		},
+		{
+			MethodName: "CreateReception",
+			Handler:    _PVZService_CreateReception_Handler,
+		},
+		{
+			MethodName: "CloseLastReception",
+			Handler:    _PVZService_CloseLastReception_Handler,
+		},
+		{
+			MethodName: "ReopenReception",
+			Handler:    _PVZService_ReopenReception_Handler,
+		},
+		{
+			MethodName: "CancelReception",
+			Handler:    _PVZService_CancelReception_Handler,
+		},
+		{
+			MethodName: "GetReception",
+			Handler:    _PVZService_GetReception_Handler,
+		},
+		{
+			MethodName: "GetCurrentReception",
+			Handler:    _PVZService_GetCurrentReception_Handler,
+		},
+		{
+			MethodName: "ListReceptions",
+			Handler:    _PVZService_ListReceptions_Handler,
+		},
+		{
+			MethodName: "AddProduct",
+			Handler:    _PVZService_AddProduct_Handler,
+		},
+		{
+			MethodName: "DeleteLastProduct",
+			Handler:    _PVZService_DeleteLastProduct_Handler,
+		},
+		{
+			MethodName: "DeleteProduct",
+			Handler:    _PVZService_DeleteProduct_Handler,
+		},
+		{
+			MethodName: "ListPVZRecords",
+			Handler:    _PVZService_ListPVZRecords_Handler,
+		},
+		{
+			MethodName: "ListProductTypes",
+			Handler:    _PVZService_ListProductTypes_Handler,
+		},
+		{
+			MethodName: "CreateProductType",
+			Handler:    _PVZService_CreateProductType_Handler,
+		},
+		{
+			MethodName: "UpdateProductType",
+			Handler:    _PVZService_UpdateProductType_Handler,
+		},
+		{
+			MethodName: "DeleteProductType",
+			Handler:    _PVZService_DeleteProductType_Handler,
+		},
+		{
+			MethodName: "ListAuditLog",
+			Handler:    _PVZService_ListAuditLog_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AddProducts",
+			Handler:       _PVZService_AddProducts_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchPVZ",
+			Handler:       _PVZService_WatchPVZ_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal/grpc/pvz/v1/pvz.proto",
 }
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+
+	"avito-pvz-service/internal/audit"
+	"avito-pvz-service/internal/ratelimit"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimiter ограничивает частоту вызовов: публичные методы считаются по
+// IP клиента, остальные — по sub из JWT, поэтому ставится после authorizer.
+// Сверх лимита отвечает ResourceExhausted с метаданными "retry-after".
+type rateLimiter struct {
+	limiter *ratelimit.Limiter
+}
+
+func (r *rateLimiter) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := r.allow(ctx, info.FullMethod, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (r *rateLimiter) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := r.allow(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (r *rateLimiter) allow(ctx context.Context, fullMethod string, setHeader func(metadata.MD) error) error {
+	ok, wait := r.limiter.AllowRPC(ctx, fullMethod, rpcClient(ctx, fullMethod))
+	if ok {
+		return nil
+	}
+	_ = setHeader(metadata.Pairs("retry-after", ratelimit.RetryAfter(wait)))
+	return status.Error(codes.ResourceExhausted, "Too many requests")
+}
+
+// rpcClient — кем считать вызов: "sub:<sub>" или для публичных методов "ip:<адрес>".
+func rpcClient(ctx context.Context, fullMethod string) string {
+	if publicMethods[fullMethod] {
+		return "ip:" + peerIP(ctx)
+	}
+	return "sub:" + audit.ActorFrom(ctx).Subject
+}
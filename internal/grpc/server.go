@@ -4,47 +4,75 @@ import (
     "context"
     "log"
     "net"
+    "time"
 
+    "avito-pvz-service/internal/auth"
+    "avito-pvz-service/internal/ratelimit"
+    "avito-pvz-service/internal/rbac"
     "avito-pvz-service/internal/repository"
     pvz_v1 "avito-pvz-service/internal/grpc/pvz/v1"
 
     "google.golang.org/grpc"
     "google.golang.org/grpc/reflection"
-    "google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type server struct {
     pvz_v1.UnimplementedPVZServiceServer
+    repos repository.Repositories
+}
+
+func newServer(repos repository.Repositories) *server {
+    return &server{repos: repos}
 }
 
 func (s *server) GetPVZList(ctx context.Context, _ *pvz_v1.GetPVZListRequest) (*pvz_v1.GetPVZListResponse, error) {
-    pvzs, err := repository.GetAllPVZ()
+    pvzs, err := s.repos.PVZ.GetAllPVZ(ctx)
     if err != nil {
         return nil, err
     }
     resp := &pvz_v1.GetPVZListResponse{}
     for _, p := range pvzs {
-        resp.Pvzs = append(resp.Pvzs, &pvz_v1.PVZ{
-            Id:               p.ID,
-            RegistrationDate: timestamppb.New(p.RegistrationDate),
-            City:             p.City,
-        })
+        resp.Pvzs = append(resp.Pvzs, pvzToProto(p))
     }
     return resp, nil
 }
 
-func RunGRPCServer() {
-    lis, err := net.Listen("tcp", ":3000")
-    if err != nil {
-        log.Fatalf("failed to listen: %v", err)
+// NewGRPCServer собирает gRPC-сервер с сервисом ПВЗ и интерсепторами,
+// которые проверяют JWT и роль по политике доступа, ограничивают частоту
+// вызовов и обрабатывают ключи идемпотентности; idempotencyTTL=0 отключает
+// ключи, limiter=nil — ограничение частоты.
+func NewGRPCServer(repos repository.Repositories, keys *auth.Keyring, policy *rbac.Policy, idempotencyTTL time.Duration, limiter *ratelimit.Limiter) *grpc.Server {
+    authz := &authorizer{keys: keys, revoked: repos.Token, policy: policy}
+    unary := []grpc.UnaryServerInterceptor{authz.unary}
+    stream := []grpc.StreamServerInterceptor{authz.stream}
+    if limiter != nil {
+        rl := &rateLimiter{limiter: limiter}
+        unary = append(unary, rl.unary)
+        stream = append(stream, rl.stream)
+    }
+    if idempotencyTTL > 0 {
+        idem := &idempotency{store: repos.Idempotency, ttl: idempotencyTTL}
+        unary = append(unary, idem.unary)
     }
-    s := grpc.NewServer()
+    s := grpc.NewServer(
+        grpc.ChainUnaryInterceptor(unary...),
+        grpc.ChainStreamInterceptor(stream...),
+    )
 
-    pvz_v1.RegisterPVZServiceServer(s, &server{})
+    pvz_v1.RegisterPVZServiceServer(s, newServer(repos))
 
     // reflection, чтобы grpcurl и другие инструменты
     // могли автоматически узнать о сервисах и методах
     reflection.Register(s)
+    return s
+}
+
+func RunGRPCServer(repos repository.Repositories, keys *auth.Keyring, policy *rbac.Policy, idempotencyTTL time.Duration, limiter *ratelimit.Limiter) {
+    lis, err := net.Listen("tcp", ":3000")
+    if err != nil {
+        log.Fatalf("failed to listen: %v", err)
+    }
+    s := NewGRPCServer(repos, keys, policy, idempotencyTTL, limiter)
 
     log.Println("gRPC server is running on port 3000")
     if err := s.Serve(lis); err != nil {
@@ -0,0 +1,568 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"avito-pvz-service/internal/auth"
+	pvz_v1 "avito-pvz-service/internal/grpc/pvz/v1"
+	"avito-pvz-service/internal/ratelimit"
+	"avito-pvz-service/internal/rbac"
+	"avito-pvz-service/internal/repository"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const testSecret = "grpc-test-secret"
+
+// newTestClient поднимает сервер на bufconn поверх хранилища в памяти.
+func newTestClient(t *testing.T) (pvz_v1.PVZServiceClient, repository.Repositories) {
+	return newTestClientWithLimits(t, ratelimit.Default())
+}
+
+func newTestClientWithLimits(t *testing.T, limits *ratelimit.Limits) (pvz_v1.PVZServiceClient, repository.Repositories) {
+	lis := bufconn.Listen(1 << 20)
+	keys := auth.NewKeyring(auth.NewHMACKey([]byte(testSecret)))
+	repos := repository.NewMemoryRepositories()
+	srv := NewGRPCServer(repos, keys, rbac.Default(), time.Hour, ratelimit.NewLimiter(limits, ratelimit.NewMemoryStore()))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pvz_v1.NewPVZServiceClient(conn), repos
+}
+
+func withRole(t *testing.T, role string) context.Context {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  role,
+		"role": role,
+		"jti":  uuid.New().String(),
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestGRPC_ReceptionFlow(t *testing.T) {
+	client, repos := newTestClient(t)
+	mod := withRole(t, "moderator")
+	staff := withRole(t, "staff")
+
+	pvzResp, err := client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Москва"})
+	require.NoError(t, err)
+	pvzId := pvzResp.GetPvz().GetId()
+
+	// Сотрудник работает только в назначенных ПВЗ
+	_, err = client.CreateReception(staff, &pvz_v1.CreateReceptionRequest{PvzId: pvzId})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = repos.Staff.AssignStaff(context.Background(), "staff", pvzId, "moderator")
+	require.NoError(t, err)
+
+	// Товар без открытой приёмки
+	_, err = client.AddProduct(staff, &pvz_v1.AddProductRequest{PvzId: pvzId, Type: "обувь"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	rec, err := client.CreateReception(staff, &pvz_v1.CreateReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+	assert.Equal(t, "in_progress", rec.GetReception().GetStatus())
+
+	for _, typ := range []string{"электроника", "одежда"} {
+		_, err = client.AddProduct(staff, &pvz_v1.AddProductRequest{PvzId: pvzId, Type: typ})
+		require.NoError(t, err)
+	}
+	_, err = client.AddProduct(staff, &pvz_v1.AddProductRequest{PvzId: pvzId, Type: "мебель"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Повторный скан той же посылки
+	_, err = client.AddProduct(staff, &pvz_v1.AddProductRequest{PvzId: pvzId, Type: "обувь", Barcode: "ORDER-7"})
+	require.NoError(t, err)
+	_, err = client.AddProduct(staff, &pvz_v1.AddProductRequest{PvzId: pvzId, Type: "обувь", Barcode: "ORDER-7"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.DeleteLastProduct(staff, &pvz_v1.DeleteLastProductRequest{PvzId: pvzId})
+	require.NoError(t, err)
+	_, err = client.DeleteLastProduct(staff, &pvz_v1.DeleteLastProductRequest{PvzId: pvzId})
+	require.NoError(t, err)
+
+	closed, err := client.CloseLastReception(staff, &pvz_v1.CloseLastReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+	assert.Equal(t, "close", closed.GetReception().GetStatus())
+
+	_, err = client.CloseLastReception(staff, &pvz_v1.CloseLastReceptionRequest{PvzId: pvzId})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	list, err := client.ListPVZRecords(mod, &pvz_v1.ListPVZRecordsRequest{
+		StartDate: timestamppb.New(time.Now().Add(-time.Hour)),
+		EndDate:   timestamppb.New(time.Now().Add(time.Hour)),
+	})
+	require.NoError(t, err)
+	require.Len(t, list.GetRecords(), 1)
+	require.Len(t, list.GetRecords()[0].GetReceptions(), 1)
+	assert.Len(t, list.GetRecords()[0].GetReceptions()[0].GetProducts(), 1)
+	assert.Empty(t, list.GetNextCursor())
+
+	// Удалённые по LIFO товары видны модератору с отметкой, кто их удалил
+	list, err = client.ListPVZRecords(mod, &pvz_v1.ListPVZRecordsRequest{
+		StartDate:      timestamppb.New(time.Now().Add(-time.Hour)),
+		EndDate:        timestamppb.New(time.Now().Add(time.Hour)),
+		IncludeDeleted: true,
+	})
+	require.NoError(t, err)
+	products := list.GetRecords()[0].GetReceptions()[0].GetProducts()
+	require.Len(t, products, 3)
+	assert.Nil(t, products[0].GetDeletedAt())
+	assert.NotNil(t, products[2].GetDeletedAt())
+	assert.Equal(t, "staff", products[2].GetDeletedBy())
+	_, err = client.ListPVZRecords(staff, &pvz_v1.ListPVZRecordsRequest{
+		StartDate:      timestamppb.New(time.Now().Add(-time.Hour)),
+		EndDate:        timestamppb.New(time.Now().Add(time.Hour)),
+		IncludeDeleted: true,
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestGRPC_Auth(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	// GetPVZList публичный
+	_, err := client.GetPVZList(context.Background(), &pvz_v1.GetPVZListRequest{})
+	assert.NoError(t, err)
+
+	_, err = client.CreatePVZ(context.Background(), &pvz_v1.CreatePVZRequest{City: "Москва"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	bad := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer garbage")
+	_, err = client.CreatePVZ(bad, &pvz_v1.CreatePVZRequest{City: "Москва"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CreatePVZ(withRole(t, "staff"), &pvz_v1.CreatePVZRequest{City: "Москва"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Роль не из перечня не проходит политику
+	_, err = client.ListPVZRecords(withRole(t, "admin"), &pvz_v1.ListPVZRecordsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.CreateReception(withRole(t, "moderator"), &pvz_v1.CreateReceptionRequest{PvzId: "not-a-uuid"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.CreateReception(withRole(t, "staff"), &pvz_v1.CreateReceptionRequest{PvzId: "not-a-uuid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPC_WatchPVZ(t *testing.T) {
+	client, repos := newTestClient(t)
+	mod := withRole(t, "moderator")
+	staff := withRole(t, "staff")
+
+	pvzResp, err := client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Казань"})
+	require.NoError(t, err)
+	pvzId := pvzResp.GetPvz().GetId()
+	other, err := client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Москва"})
+	require.NoError(t, err)
+	for _, id := range []string{pvzId, other.GetPvz().GetId()} {
+		_, err = repos.Staff.AssignStaff(context.Background(), "staff", id, "moderator")
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(mod)
+	defer cancel()
+	stream, err := client.WatchPVZ(ctx, &pvz_v1.WatchPVZRequest{Target: &pvz_v1.WatchPVZRequest_City{City: "Казань"}})
+	require.NoError(t, err)
+	// Заголовок приходит после оформления подписки
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	_, err = client.CreateReception(staff, &pvz_v1.CreateReceptionRequest{PvzId: other.GetPvz().GetId()})
+	require.NoError(t, err)
+	_, err = client.CreateReception(staff, &pvz_v1.CreateReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+	_, err = client.AddProduct(staff, &pvz_v1.AddProductRequest{PvzId: pvzId, Type: "обувь"})
+	require.NoError(t, err)
+	_, err = client.DeleteLastProduct(staff, &pvz_v1.DeleteLastProductRequest{PvzId: pvzId})
+	require.NoError(t, err)
+	_, err = client.CloseLastReception(staff, &pvz_v1.CloseLastReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+
+	want := []pvz_v1.PVZEventType{
+		pvz_v1.PVZEventType_PVZ_EVENT_TYPE_RECEPTION_OPENED,
+		pvz_v1.PVZEventType_PVZ_EVENT_TYPE_PRODUCT_ADDED,
+		pvz_v1.PVZEventType_PVZ_EVENT_TYPE_PRODUCT_REMOVED,
+		pvz_v1.PVZEventType_PVZ_EVENT_TYPE_RECEPTION_CLOSED,
+	}
+	var seqs []uint64
+	for _, typ := range want {
+		e, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, typ, e.GetType())
+		assert.Equal(t, pvzId, e.GetPvzId())
+		seqs = append(seqs, e.GetSeq())
+	}
+	cancel()
+
+	// Переподключение после первого события отдаёт оставшиеся из истории
+	resumed, err := client.WatchPVZ(staff, &pvz_v1.WatchPVZRequest{
+		Target:   &pvz_v1.WatchPVZRequest_PvzId{PvzId: pvzId},
+		AfterSeq: seqs[0],
+	})
+	require.NoError(t, err)
+	for _, seq := range seqs[1:] {
+		e, err := resumed.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, seq, e.GetSeq())
+	}
+
+	ahead, err := client.WatchPVZ(staff, &pvz_v1.WatchPVZRequest{
+		Target:   &pvz_v1.WatchPVZRequest_PvzId{PvzId: pvzId},
+		AfterSeq: 1000,
+	})
+	require.NoError(t, err)
+	_, err = ahead.Recv()
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+
+	noTarget, err := client.WatchPVZ(staff, &pvz_v1.WatchPVZRequest{})
+	require.NoError(t, err)
+	_, err = noTarget.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	anon, err := client.WatchPVZ(context.Background(), &pvz_v1.WatchPVZRequest{Target: &pvz_v1.WatchPVZRequest_City{City: "Казань"}})
+	require.NoError(t, err)
+	_, err = anon.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGRPC_ProductTypes(t *testing.T) {
+	client, repos := newTestClient(t)
+	mod := withRole(t, "moderator")
+	staff := withRole(t, "staff")
+
+	furniture := &pvz_v1.ProductType{
+		Code:       "мебель",
+		Names:      map[string]string{"ru": "Мебель"},
+		Attributes: []*pvz_v1.AttributeSpec{{Name: "weight", Kind: "number", Unit: "kg", Required: true}},
+	}
+	_, err := client.CreateProductType(staff, &pvz_v1.CreateProductTypeRequest{ProductType: furniture})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.CreateProductType(mod, &pvz_v1.CreateProductTypeRequest{ProductType: furniture})
+	require.NoError(t, err)
+	_, err = client.CreateProductType(mod, &pvz_v1.CreateProductTypeRequest{ProductType: furniture})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	list, err := client.ListProductTypes(staff, &pvz_v1.ListProductTypesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.GetProductTypes(), 4)
+
+	pvzResp, err := client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Москва"})
+	require.NoError(t, err)
+	pvzId := pvzResp.GetPvz().GetId()
+	_, err = repos.Staff.AssignStaff(context.Background(), "staff", pvzId, "moderator")
+	require.NoError(t, err)
+	_, err = client.CreateReception(staff, &pvz_v1.CreateReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+
+	_, err = client.AddProduct(staff, &pvz_v1.AddProductRequest{PvzId: pvzId, Type: "мебель"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	attrs, err := structpb.NewStruct(map[string]interface{}{"weight": 30})
+	require.NoError(t, err)
+	added, err := client.AddProduct(staff, &pvz_v1.AddProductRequest{PvzId: pvzId, Type: "мебель", Attributes: attrs})
+	require.NoError(t, err)
+	assert.Equal(t, float64(30), added.GetProduct().GetAttributes().AsMap()["weight"])
+
+	_, err = client.DeleteProductType(mod, &pvz_v1.DeleteProductTypeRequest{Code: "мебель"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = client.UpdateProductType(mod, &pvz_v1.UpdateProductTypeRequest{ProductType: &pvz_v1.ProductType{Code: "посуда"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPC_AddProducts(t *testing.T) {
+	client, repos := newTestClient(t)
+	mod := withRole(t, "moderator")
+	staff := withRole(t, "staff")
+
+	pvzResp, err := client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Казань"})
+	require.NoError(t, err)
+	pvzId := pvzResp.GetPvz().GetId()
+	_, err = repos.Staff.AssignStaff(context.Background(), "staff", pvzId, "moderator")
+	require.NoError(t, err)
+	_, err = client.CreateReception(staff, &pvz_v1.CreateReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+
+	send := func(mode pvz_v1.BatchMode, types ...string) (*pvz_v1.AddProductsResponse, error) {
+		stream, err := client.AddProducts(staff)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&pvz_v1.AddProductsRequest{Payload: &pvz_v1.AddProductsRequest_Header{
+			Header: &pvz_v1.AddProductsHeader{PvzId: pvzId, Mode: mode},
+		}}))
+		for _, typ := range types {
+			require.NoError(t, stream.Send(&pvz_v1.AddProductsRequest{Payload: &pvz_v1.AddProductsRequest_Item{
+				Item: &pvz_v1.ProductItem{Type: typ},
+			}}))
+		}
+		return stream.CloseAndRecv()
+	}
+
+	resp, err := send(pvz_v1.BatchMode_BATCH_MODE_UNSPECIFIED, "обувь", "мебель")
+	require.NoError(t, err)
+	assert.Zero(t, resp.GetCreated())
+
+	resp, err = send(pvz_v1.BatchMode_BATCH_MODE_BEST_EFFORT, "обувь", "мебель", "одежда")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, resp.GetCreated())
+	require.Len(t, resp.GetResults(), 3)
+	assert.Equal(t, "одежда", resp.GetResults()[2].GetProduct().GetType())
+	assert.NotEmpty(t, resp.GetResults()[1].GetError())
+
+	// Удаление конкретного товара из пакета
+	productId := resp.GetResults()[0].GetProduct().GetId()
+	_, err = client.DeleteProduct(staff, &pvz_v1.DeleteProductRequest{ProductId: productId})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	deleted, err := client.DeleteProduct(staff, &pvz_v1.DeleteProductRequest{ProductId: productId, Reason: "duplicate"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, deleted.GetDeletionId())
+	_, err = client.DeleteProduct(staff, &pvz_v1.DeleteProductRequest{ProductId: productId, Reason: "duplicate"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = send(pvz_v1.BatchMode_BATCH_MODE_BEST_EFFORT)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Без заголовка
+	stream, err := client.AddProducts(staff)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pvz_v1.AddProductsRequest{Payload: &pvz_v1.AddProductsRequest_Item{Item: &pvz_v1.ProductItem{Type: "обувь"}}}))
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPC_ReopenCancelReception(t *testing.T) {
+	client, repos := newTestClient(t)
+	mod := withRole(t, "moderator")
+	staff := withRole(t, "staff")
+
+	pvzResp, err := client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Москва"})
+	require.NoError(t, err)
+	pvzId := pvzResp.GetPvz().GetId()
+	_, err = repos.Staff.AssignStaff(context.Background(), "staff", pvzId, "moderator")
+	require.NoError(t, err)
+
+	rec, err := client.CreateReception(staff, &pvz_v1.CreateReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+	recId := rec.GetReception().GetId()
+
+	_, err = client.ReopenReception(mod, &pvz_v1.ReopenReceptionRequest{ReceptionId: recId})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = client.CloseLastReception(staff, &pvz_v1.CloseLastReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+
+	_, err = client.ReopenReception(staff, &pvz_v1.ReopenReceptionRequest{ReceptionId: recId})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.ReopenReception(mod, &pvz_v1.ReopenReceptionRequest{ReceptionId: "bad"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ReopenReception(mod, &pvz_v1.ReopenReceptionRequest{ReceptionId: uuid.New().String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	reopened, err := client.ReopenReception(mod, &pvz_v1.ReopenReceptionRequest{ReceptionId: recId})
+	require.NoError(t, err)
+	assert.Equal(t, "reopened", reopened.GetReception().GetStatus())
+
+	cancelled, err := client.CancelReception(mod, &pvz_v1.CancelReceptionRequest{ReceptionId: recId})
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.GetReception().GetStatus())
+	_, err = client.CancelReception(mod, &pvz_v1.CancelReceptionRequest{ReceptionId: recId})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestGRPC_ReceptionHistory(t *testing.T) {
+	client, repos := newTestClient(t)
+	mod := withRole(t, "moderator")
+	staff := withRole(t, "staff")
+
+	pvzResp, err := client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Москва"})
+	require.NoError(t, err)
+	pvzId := pvzResp.GetPvz().GetId()
+	_, err = repos.Staff.AssignStaff(context.Background(), "staff", pvzId, "moderator")
+	require.NoError(t, err)
+
+	_, err = client.GetCurrentReception(staff, &pvz_v1.GetCurrentReceptionRequest{PvzId: pvzId})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	rec, err := client.CreateReception(staff, &pvz_v1.CreateReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+	_, err = client.AddProduct(staff, &pvz_v1.AddProductRequest{PvzId: pvzId, Type: "обувь"})
+	require.NoError(t, err)
+
+	current, err := client.GetCurrentReception(staff, &pvz_v1.GetCurrentReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+	assert.Equal(t, rec.GetReception().GetId(), current.GetReception().GetReception().GetId())
+	assert.Len(t, current.GetReception().GetProducts(), 1)
+
+	got, err := client.GetReception(mod, &pvz_v1.GetReceptionRequest{ReceptionId: rec.GetReception().GetId()})
+	require.NoError(t, err)
+	assert.Len(t, got.GetReception().GetProducts(), 1)
+	_, err = client.GetReception(mod, &pvz_v1.GetReceptionRequest{ReceptionId: uuid.New().String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	list, err := client.ListReceptions(mod, &pvz_v1.ListReceptionsRequest{PvzId: pvzId, Status: "in_progress"})
+	require.NoError(t, err)
+	assert.Len(t, list.GetReceptions(), 1)
+	list, err = client.ListReceptions(mod, &pvz_v1.ListReceptionsRequest{PvzId: pvzId, Status: "close"})
+	require.NoError(t, err)
+	assert.Empty(t, list.GetReceptions())
+	_, err = client.ListReceptions(mod, &pvz_v1.ListReceptionsRequest{PvzId: pvzId, Status: "open"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ListReceptions(mod, &pvz_v1.ListReceptionsRequest{PvzId: uuid.New().String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Продолжение истории по курсору
+	_, err = client.CloseLastReception(staff, &pvz_v1.CloseLastReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+	rec2, err := client.CreateReception(staff, &pvz_v1.CreateReceptionRequest{PvzId: pvzId})
+	require.NoError(t, err)
+	list, err = client.ListReceptions(mod, &pvz_v1.ListReceptionsRequest{PvzId: pvzId, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, list.GetReceptions(), 1)
+	assert.Equal(t, rec2.GetReception().GetId(), list.GetReceptions()[0].GetId())
+	require.NotEmpty(t, list.GetNextCursor())
+	list, err = client.ListReceptions(mod, &pvz_v1.ListReceptionsRequest{PvzId: pvzId, Limit: 1, Cursor: list.GetNextCursor()})
+	require.NoError(t, err)
+	require.Len(t, list.GetReceptions(), 1)
+	assert.Equal(t, rec.GetReception().GetId(), list.GetReceptions()[0].GetId())
+	assert.Empty(t, list.GetNextCursor())
+	_, err = client.ListReceptions(mod, &pvz_v1.ListReceptionsRequest{PvzId: pvzId, Page: 2, Cursor: "garbage"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPC_AuditLog(t *testing.T) {
+	client, _ := newTestClient(t)
+	mod := metadata.AppendToOutgoingContext(withRole(t, "moderator"), "x-request-id", "req-7")
+
+	pvzResp, err := client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Москва"})
+	require.NoError(t, err)
+	pvzId := pvzResp.GetPvz().GetId()
+
+	_, err = client.ListAuditLog(withRole(t, "staff"), &pvz_v1.ListAuditLogRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	resp, err := client.ListAuditLog(mod, &pvz_v1.ListAuditLogRequest{EntityType: "pvz", EntityId: pvzId})
+	require.NoError(t, err)
+	require.Len(t, resp.GetEntries(), 1)
+	e := resp.GetEntries()[0]
+	assert.Equal(t, "pvz.create", e.GetAction())
+	assert.Equal(t, "moderator", e.GetActor())
+	assert.Equal(t, "req-7", e.GetRequestId())
+	assert.NotEmpty(t, e.GetClientIp())
+	assert.Empty(t, e.GetBefore())
+	assert.Contains(t, e.GetAfter(), pvzId)
+
+	resp, err = client.ListAuditLog(mod, &pvz_v1.ListAuditLogRequest{From: timestamppb.New(time.Now().Add(time.Hour))})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetEntries())
+}
+
+func TestGRPC_IdempotencyKey(t *testing.T) {
+	client, _ := newTestClient(t)
+	mod := metadata.AppendToOutgoingContext(withRole(t, "moderator"), "idempotency-key", "pvz-1")
+
+	first, err := client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Москва"})
+	require.NoError(t, err)
+	var header metadata.MD
+	retry, err := client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Москва"}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, first.GetPvz().GetId(), retry.GetPvz().GetId())
+	assert.Equal(t, []string{"true"}, header.Get("idempotent-replayed"))
+
+	_, err = client.CreatePVZ(mod, &pvz_v1.CreatePVZRequest{City: "Казань"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Ошибка клиента сохраняется и возвращается на повтор
+	bad := metadata.AppendToOutgoingContext(withRole(t, "moderator"), "idempotency-key", "pvz-2")
+	_, err = client.CreatePVZ(bad, &pvz_v1.CreatePVZRequest{City: "Тверь"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err2 := client.CreatePVZ(bad, &pvz_v1.CreatePVZRequest{City: "Тверь"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err2))
+	assert.Equal(t, status.Convert(err).Message(), status.Convert(err2).Message())
+
+	// Без ключа каждый вызов выполняется заново
+	a, err := client.CreatePVZ(withRole(t, "moderator"), &pvz_v1.CreatePVZRequest{City: "Москва"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.GetPvz().GetId(), a.GetPvz().GetId())
+}
+
+func TestGRPC_RateLimit(t *testing.T) {
+	limits, err := ratelimit.Parse([]byte(`
+default: {rate: "0"}
+grpc:
+  "/pvz.v1.PVZService/GetPVZList": {rate: 1/m, burst: 1}
+  "/pvz.v1.PVZService/CreatePVZ": {rate: 1/m, burst: 1}
+  "/pvz.v1.PVZService/WatchPVZ": {rate: 1/m, burst: 1}
+`))
+	require.NoError(t, err)
+	client, _ := newTestClientWithLimits(t, limits)
+
+	// Публичный метод считается по IP
+	_, err = client.GetPVZList(context.Background(), &pvz_v1.GetPVZListRequest{})
+	require.NoError(t, err)
+	var header metadata.MD
+	_, err = client.GetPVZList(context.Background(), &pvz_v1.GetPVZListRequest{}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"60"}, header.Get("retry-after"))
+
+	// Остальные — по sub
+	_, err = client.CreatePVZ(withRole(t, "moderator"), &pvz_v1.CreatePVZRequest{City: "Москва"})
+	require.NoError(t, err)
+	_, err = client.CreatePVZ(withRole(t, "moderator"), &pvz_v1.CreatePVZRequest{City: "Москва"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Потоки ограничиваются так же
+	ctx, cancel := context.WithCancel(withRole(t, "staff"))
+	defer cancel()
+	req := &pvz_v1.WatchPVZRequest{Target: &pvz_v1.WatchPVZRequest_City{City: "Москва"}}
+	first, err := client.WatchPVZ(ctx, req)
+	require.NoError(t, err)
+	_, err = first.Header()
+	require.NoError(t, err)
+	second, err := client.WatchPVZ(ctx, req)
+	require.NoError(t, err)
+	_, err = second.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestGRPC_PVZLifecycleFields(t *testing.T) {
+	client, repos := newTestClient(t)
+
+	created, err := client.CreatePVZ(withRole(t, "moderator"), &pvz_v1.CreatePVZRequest{City: "Москва"})
+	require.NoError(t, err)
+	assert.True(t, created.GetPvz().GetActive())
+	assert.Nil(t, created.GetPvz().GetClosedAt())
+
+	name, address, inactive := "ПВЗ на Тверской", "Тверская, 1", false
+	_, err = repos.PVZ.UpdatePVZ(context.Background(), created.GetPvz().GetId(),
+		repository.PVZUpdate{Name: &name, Address: &address, Active: &inactive})
+	require.NoError(t, err)
+
+	// Деактивация видна gRPC-клиентам так же, как в HTTP
+	list, err := client.GetPVZList(context.Background(), &pvz_v1.GetPVZListRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetPvzs(), 1)
+	p := list.GetPvzs()[0]
+	assert.Equal(t, name, p.GetName())
+	assert.Equal(t, address, p.GetAddress())
+	assert.False(t, p.GetActive())
+	assert.NotNil(t, p.GetClosedAt())
+}
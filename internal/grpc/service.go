@@ -0,0 +1,333 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pvz_v1 "avito-pvz-service/internal/grpc/pvz/v1"
+	"avito-pvz-service/internal/metrics"
+	"avito-pvz-service/internal/rbac"
+	"avito-pvz-service/internal/repository"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func validPVZId(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return status.Error(codes.InvalidArgument, "Invalid pvz_id")
+	}
+	return nil
+}
+
+func (s *server) CreatePVZ(ctx context.Context, req *pvz_v1.CreatePVZRequest) (*pvz_v1.CreatePVZResponse, error) {
+	if req.GetCity() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Missing city")
+	}
+
+	pvz, err := s.repos.PVZ.CreatePVZ(ctx, req.GetCity())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	metrics.PVZCreatedTotal.Inc()
+	return &pvz_v1.CreatePVZResponse{Pvz: pvzToProto(*pvz)}, nil
+}
+
+func (s *server) CreateReception(ctx context.Context, req *pvz_v1.CreateReceptionRequest) (*pvz_v1.CreateReceptionResponse, error) {
+	if err := validPVZId(req.GetPvzId()); err != nil {
+		return nil, err
+	}
+	if err := s.requireAssignment(ctx, req.GetPvzId()); err != nil {
+		return nil, err
+	}
+
+	reception, err := s.repos.Reception.CreateReception(ctx, req.GetPvzId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	metrics.ReceptionsCreatedTotal.Inc()
+	return &pvz_v1.CreateReceptionResponse{Reception: receptionToProto(*reception)}, nil
+}
+
+func (s *server) CloseLastReception(ctx context.Context, req *pvz_v1.CloseLastReceptionRequest) (*pvz_v1.CloseLastReceptionResponse, error) {
+	if err := validPVZId(req.GetPvzId()); err != nil {
+		return nil, err
+	}
+	if err := s.requireAssignment(ctx, req.GetPvzId()); err != nil {
+		return nil, err
+	}
+
+	reception, err := s.repos.Reception.CloseReception(ctx, req.GetPvzId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pvz_v1.CloseLastReceptionResponse{Reception: receptionToProto(*reception)}, nil
+}
+
+func (s *server) ReopenReception(ctx context.Context, req *pvz_v1.ReopenReceptionRequest) (*pvz_v1.ReopenReceptionResponse, error) {
+	if _, err := uuid.Parse(req.GetReceptionId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid reception_id")
+	}
+	reception, err := s.repos.Reception.ReopenReception(ctx, req.GetReceptionId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pvz_v1.ReopenReceptionResponse{Reception: receptionToProto(*reception)}, nil
+}
+
+func (s *server) CancelReception(ctx context.Context, req *pvz_v1.CancelReceptionRequest) (*pvz_v1.CancelReceptionResponse, error) {
+	if _, err := uuid.Parse(req.GetReceptionId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid reception_id")
+	}
+	reception, err := s.repos.Reception.CancelReception(ctx, req.GetReceptionId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pvz_v1.CancelReceptionResponse{Reception: receptionToProto(*reception)}, nil
+}
+
+func (s *server) GetReception(ctx context.Context, req *pvz_v1.GetReceptionRequest) (*pvz_v1.GetReceptionResponse, error) {
+	if _, err := uuid.Parse(req.GetReceptionId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid reception_id")
+	}
+	record, err := s.repos.Reception.GetReception(ctx, req.GetReceptionId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pvz_v1.GetReceptionResponse{Reception: receptionRecordToProto(*record)}, nil
+}
+
+func (s *server) GetCurrentReception(ctx context.Context, req *pvz_v1.GetCurrentReceptionRequest) (*pvz_v1.GetCurrentReceptionResponse, error) {
+	if err := validPVZId(req.GetPvzId()); err != nil {
+		return nil, err
+	}
+	if _, err := s.repos.PVZ.GetPVZ(ctx, req.GetPvzId()); err != nil {
+		return nil, toStatus(err)
+	}
+	record, err := s.repos.Reception.CurrentReception(ctx, req.GetPvzId())
+	if errors.Is(err, repository.ErrNoActiveReception) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pvz_v1.GetCurrentReceptionResponse{Reception: receptionRecordToProto(*record)}, nil
+}
+
+func (s *server) ListReceptions(ctx context.Context, req *pvz_v1.ListReceptionsRequest) (*pvz_v1.ListReceptionsResponse, error) {
+	if err := validPVZId(req.GetPvzId()); err != nil {
+		return nil, err
+	}
+	filter := repository.ReceptionFilter{Status: req.GetStatus()}
+	if filter.Status != "" && !repository.ValidReceptionStatus(filter.Status) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid status")
+	}
+	if req.GetStartDate() != nil {
+		t := req.GetStartDate().AsTime()
+		filter.StartDate = &t
+	}
+	if req.GetEndDate() != nil {
+		t := req.GetEndDate().AsTime()
+		filter.EndDate = &t
+	}
+	page, limit := pageParams(req.GetPage(), req.GetLimit())
+	var after *repository.ReceptionCursor
+	if req.GetCursor() != "" {
+		if page > 1 {
+			return nil, status.Error(codes.InvalidArgument, "page and cursor are mutually exclusive")
+		}
+		cursor, err := repository.DecodeReceptionCursor(req.GetCursor())
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		after = cursor
+	}
+
+	if _, err := s.repos.PVZ.GetPVZ(ctx, req.GetPvzId()); err != nil {
+		return nil, toStatus(err)
+	}
+	// Первая страница и продолжение по курсору идут по (date_time, id),
+	// page больше 1 — старая выборка со смещением
+	var receptions []repository.Reception
+	var next *repository.ReceptionCursor
+	var err error
+	if page > 1 {
+		receptions, err = s.repos.Reception.ListReceptions(ctx, req.GetPvzId(), filter, page, limit)
+	} else {
+		receptions, next, err = s.repos.Reception.ListReceptionsPage(ctx, req.GetPvzId(), filter, after, limit)
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pvz_v1.ListReceptionsResponse{NextCursor: next.Encode()}
+	for _, r := range receptions {
+		resp.Receptions = append(resp.Receptions, receptionToProto(r))
+	}
+	return resp, nil
+}
+
+// pageParams применяет к page и limit те же умолчания, что и HTTP API.
+func pageParams(page, limit int32) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > repository.MaxPageLimit {
+		limit = repository.MaxPageLimit
+	}
+	return int(page), int(limit)
+}
+
+func (s *server) AddProduct(ctx context.Context, req *pvz_v1.AddProductRequest) (*pvz_v1.AddProductResponse, error) {
+	if err := validPVZId(req.GetPvzId()); err != nil {
+		return nil, err
+	}
+	if len(req.GetBarcode()) > 64 {
+		return nil, status.Error(codes.InvalidArgument, "Barcode is too long")
+	}
+	if err := s.requireAssignment(ctx, req.GetPvzId()); err != nil {
+		return nil, err
+	}
+
+	product, err := s.repos.Product.AddProduct(ctx, req.GetPvzId(), repository.NewProduct{
+		Type:       req.GetType(),
+		Attributes: req.GetAttributes().AsMap(),
+		Barcode:    req.GetBarcode(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	metrics.ProductsCreatedTotal.Inc()
+	return &pvz_v1.AddProductResponse{Product: productToProto(*product)}, nil
+}
+
+func (s *server) DeleteLastProduct(ctx context.Context, req *pvz_v1.DeleteLastProductRequest) (*pvz_v1.DeleteLastProductResponse, error) {
+	if err := validPVZId(req.GetPvzId()); err != nil {
+		return nil, err
+	}
+	if err := s.requireAssignment(ctx, req.GetPvzId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Product.DeleteLastProduct(ctx, req.GetPvzId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pvz_v1.DeleteLastProductResponse{}, nil
+}
+
+func (s *server) DeleteProduct(ctx context.Context, req *pvz_v1.DeleteProductRequest) (*pvz_v1.DeleteProductResponse, error) {
+	if _, err := uuid.Parse(req.GetProductId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid product_id")
+	}
+	if !repository.ValidDeletionReason(req.GetReason()) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid or missing reason")
+	}
+	product, err := s.repos.Product.GetProduct(ctx, req.GetProductId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.requireAssignment(ctx, product.PVZId); err != nil {
+		return nil, err
+	}
+
+	deletion, err := s.repos.Product.DeleteProduct(ctx, req.GetProductId(), repository.ProductDeletion{
+		Reason:    req.GetReason(),
+		Comment:   req.GetComment(),
+		DeletedBy: subjectOf(ctx),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pvz_v1.DeleteProductResponse{DeletionId: deletion.ID}, nil
+}
+
+func (s *server) ListPVZRecords(ctx context.Context, req *pvz_v1.ListPVZRecordsRequest) (*pvz_v1.ListPVZRecordsResponse, error) {
+	if req.GetStartDate() == nil || req.GetEndDate() == nil {
+		return nil, status.Error(codes.InvalidArgument, "start_date and end_date are required")
+	}
+	startDate := req.GetStartDate().AsTime()
+	endDate := req.GetEndDate().AsTime()
+
+	var after *repository.PVZCursor
+	if req.GetCursor() != "" {
+		cursor, err := repository.DecodePVZCursor(req.GetCursor())
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		after = cursor
+	}
+
+	if req.GetIncludeDeleted() && roleOf(ctx) != rbac.RoleModerator {
+		return nil, status.Error(codes.PermissionDenied, "include_deleted is allowed only for moderator")
+	}
+
+	records, next, err := s.repos.PVZ.GetPVZRecordsPage(ctx, &startDate, &endDate, req.GetIncludeDeleted(), after, int(req.GetLimit()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pvz_v1.ListPVZRecordsResponse{NextCursor: next.Encode()}
+	for _, rec := range records {
+		out := &pvz_v1.PVZRecord{Pvz: pvzToProto(rec.PVZ)}
+		for _, r := range rec.Receptions {
+			out.Receptions = append(out.Receptions, receptionRecordToProto(r))
+		}
+		resp.Records = append(resp.Records, out)
+	}
+	return resp, nil
+}
+
+func pvzToProto(p repository.PVZ) *pvz_v1.PVZ {
+	return &pvz_v1.PVZ{
+		Id:               p.ID,
+		RegistrationDate: timestamppb.New(p.RegistrationDate),
+		City:             p.City,
+		Name:             p.Name,
+		Address:          p.Address,
+		Active:           p.Active,
+		ClosedAt:         optionalTimeToProto(p.ClosedAt),
+	}
+}
+
+func receptionToProto(r repository.Reception) *pvz_v1.Reception {
+	return &pvz_v1.Reception{
+		Id:       r.ID,
+		DateTime: timestamppb.New(r.DateTime),
+		PvzId:    r.PVZId,
+		Status:   r.Status,
+	}
+}
+
+func receptionRecordToProto(r repository.ReceptionRecord) *pvz_v1.ReceptionRecord {
+	out := &pvz_v1.ReceptionRecord{Reception: receptionToProto(r.Reception)}
+	for _, p := range r.Products {
+		out.Products = append(out.Products, productToProto(p))
+	}
+	return out
+}
+
+func productToProto(p repository.Product) *pvz_v1.Product {
+	return &pvz_v1.Product{
+		Id:          p.ID,
+		DateTime:    timestamppb.New(p.DateTime),
+		Type:        p.Type,
+		ReceptionId: p.ReceptionId,
+		PvzId:       p.PVZId,
+		Attributes:  attributesToProto(p.Attributes),
+		Barcode:     p.Barcode,
+		DeletedAt:   optionalTimeToProto(p.DeletedAt),
+		DeletedBy:   p.DeletedBy,
+	}
+}
+
+func optionalTimeToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
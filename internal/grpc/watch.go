@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"avito-pvz-service/internal/events"
+	pvz_v1 "avito-pvz-service/internal/grpc/pvz/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// watchBuffer — сколько событий может ждать отправки одному подписчику.
+// Если клиент читает медленнее, поток завершается с ResourceExhausted,
+// и клиент переподключается с after_seq последнего полученного события.
+const watchBuffer = 256
+
+// lastSeqHeader — заголовок ответа с номером последнего события на момент подписки.
+const lastSeqHeader = "last-seq"
+
+var eventTypes = map[events.Type]pvz_v1.PVZEventType{
+	events.ReceptionOpened:    pvz_v1.PVZEventType_PVZ_EVENT_TYPE_RECEPTION_OPENED,
+	events.ReceptionClosed:    pvz_v1.PVZEventType_PVZ_EVENT_TYPE_RECEPTION_CLOSED,
+	events.ProductAdded:       pvz_v1.PVZEventType_PVZ_EVENT_TYPE_PRODUCT_ADDED,
+	events.ProductRemoved:     pvz_v1.PVZEventType_PVZ_EVENT_TYPE_PRODUCT_REMOVED,
+	events.ReceptionReopened:  pvz_v1.PVZEventType_PVZ_EVENT_TYPE_RECEPTION_REOPENED,
+	events.ReceptionCancelled: pvz_v1.PVZEventType_PVZ_EVENT_TYPE_RECEPTION_CANCELLED,
+}
+
+func (s *server) WatchPVZ(req *pvz_v1.WatchPVZRequest, stream pvz_v1.PVZService_WatchPVZServer) error {
+	ctx := stream.Context()
+
+	var filter events.Filter
+	switch target := req.GetTarget().(type) {
+	case *pvz_v1.WatchPVZRequest_PvzId:
+		if err := validPVZId(target.PvzId); err != nil {
+			return err
+		}
+		filter.PVZId = target.PvzId
+	case *pvz_v1.WatchPVZRequest_City:
+		if target.City == "" {
+			return status.Error(codes.InvalidArgument, "Missing city")
+		}
+		filter.City = target.City
+	default:
+		return status.Error(codes.InvalidArgument, "Specify pvz_id or city")
+	}
+
+	if s.repos.Events == nil {
+		return status.Error(codes.Unavailable, "Поток событий недоступен")
+	}
+	sub, lastSeq, err := s.repos.Events.Subscribe(filter, req.GetAfterSeq(), watchBuffer)
+	if errors.Is(err, events.ErrSeqExpired) || errors.Is(err, events.ErrSeqAhead) {
+		return status.Error(codes.OutOfRange, err.Error())
+	}
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer sub.Close()
+
+	// Заголовок подтверждает клиенту, что подписка оформлена
+	header := metadata.Pairs(lastSeqHeader, strconv.FormatUint(lastSeq, 10))
+	if err := stream.SendHeader(header); err != nil {
+		return err
+	}
+
+	lastSent := req.GetAfterSeq()
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case e, ok := <-sub.Events():
+			if !ok {
+				if errors.Is(sub.Err(), events.ErrSlowConsumer) {
+					return status.Error(codes.ResourceExhausted,
+						fmt.Sprintf("%v, переподключитесь с after_seq=%d", sub.Err(), lastSent))
+				}
+				return status.Error(codes.Unavailable, "Подписка закрыта")
+			}
+			if err := stream.Send(eventToProto(e)); err != nil {
+				return err
+			}
+			lastSent = e.Seq
+		}
+	}
+}
+
+func eventToProto(e events.Event) *pvz_v1.PVZEvent {
+	return &pvz_v1.PVZEvent{
+		Seq:         e.Seq,
+		Type:        eventTypes[e.Type],
+		Time:        timestamppb.New(e.Time),
+		PvzId:       e.PVZId,
+		City:        e.City,
+		ReceptionId: e.ReceptionId,
+		ProductId:   e.ProductId,
+		ProductType: e.ProductType,
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"avito-pvz-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAuditHandler отдаёт журнал аудита, новые записи первыми. Фильтры:
+// actor, action, entityType, entityId и интервал from/to в RFC3339.
+func (h *Handler) ListAuditHandler(c *gin.Context) {
+	filter := repository.AuditFilter{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		EntityType: c.Query("entityType"),
+		EntityID:   c.Query("entityId"),
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		s := c.Query(p.name)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid " + p.name + " format"})
+			return
+		}
+		*p.dst = &t
+	}
+	page, limit := pageParams(c)
+
+	entries, err := h.repos.Audit.ListAudit(c.Request.Context(), filter, page, limit)
+	if err != nil {
+		log.Println("Журнал аудита: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	if entries == nil {
+		entries = []repository.AuditEntry{}
+	}
+	c.JSON(http.StatusOK, entries)
+}
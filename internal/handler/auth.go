@@ -3,47 +3,19 @@ package handler
 import (
 	"log"
 	"net/http"
-	"os"
-	"time"
 
-	"avito-pvz-service/internal/repository"
+	"avito-pvz-service/internal/rbac"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func getJWTSecret() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "default654пв6а45п46ва5п6ва5п46в5а1п6а5816а16в"
-		log.Println("Используется секрет по умолчанию")
-	}
-	return []byte(secret)
-}
-
-func generateJWT(email, role string) (string, error) {
-	log.Printf("Генерация токена для %s с ролью %s", email, role)
-	claims := jwt.MapClaims{
-		"sub":  email,
-		"role": role,
-		"exp":  time.Now().Add(72 * time.Hour).Unix(),
-		"iat":  time.Now().Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString(getJWTSecret())
-	if err != nil {
-		log.Println("Ошибка при создании токена:", err)
-	}
-	return signed, err
-}
-
 type DummyLoginRequest struct {
 	Role string `json:"role" binding:"required"`
 }
-type DummyLoginResponse struct{ Token string }
+type DummyLoginResponse = TokenResponse
 
-func DummyLoginHandler(c *gin.Context) {
+func (h *Handler) DummyLoginHandler(c *gin.Context) {
 	log.Println("Вызов dummyLogin")
 	var req DummyLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -52,24 +24,32 @@ func DummyLoginHandler(c *gin.Context) {
 		return
 	}
 
-	token, err := generateJWT(req.Role, req.Role)
+	role, err := rbac.ParseRole(req.Role)
+	if err != nil {
+		log.Println("Некорректная роль dummyLogin:", req.Role)
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	tokens, err := h.issueTokens(c.Request.Context(), string(role), role)
 	if err != nil {
 		log.Println("Не удалось сгенерировать токен:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка генерации токена"})
 		return
 	}
 
-	log.Println("dummyLogin успешно, роль:", req.Role)
-	c.JSON(http.StatusOK, DummyLoginResponse{Token: token})
+	log.Println("dummyLogin успешно, роль:", role)
+	c.JSON(http.StatusOK, tokens)
 }
 
+// RegisterRequest — самостоятельная регистрация: роль staff так получить нельзя.
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 	Role     string `json:"role" binding:"required,oneof=client moderator"`
 }
 
-func RegisterHandler(c *gin.Context) {
+func (h *Handler) RegisterHandler(c *gin.Context) {
 	log.Println("Вызов регистрации")
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -78,7 +58,14 @@ func RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	user, err := repository.CreateUser(req.Email, req.Password, req.Role)
+	role, err := rbac.ParseRole(req.Role)
+	if err != nil {
+		log.Println("Некорректная роль при регистрации:", req.Role)
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	user, err := h.repos.User.CreateUser(c.Request.Context(), req.Email, req.Password, string(role))
 	if err != nil {
 		log.Println("Ошибка при создании пользователя:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
@@ -98,9 +85,9 @@ type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
-type LoginResponse struct{ Token string }
+type LoginResponse = TokenResponse
 
-func LoginHandler(c *gin.Context) {
+func (h *Handler) LoginHandler(c *gin.Context) {
 	log.Println("Вызов авторизации")
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -109,7 +96,7 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	user, err := repository.GetUserByEmail(req.Email)
+	user, err := h.repos.User.GetUserByEmail(c.Request.Context(), req.Email)
 	if err != nil {
 		log.Println("Пользователь не найден:", req.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{"message": "Неверные учетные данные"})
@@ -122,7 +109,14 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	token, err := generateJWT(user.Email, user.Role)
+	role, err := rbac.ParseRole(user.Role)
+	if err != nil {
+		log.Printf("Неизвестная роль %q у пользователя %s\n", user.Role, user.Email)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка генерации токена"})
+		return
+	}
+
+	tokens, err := h.issueTokens(c.Request.Context(), user.Email, role)
 	if err != nil {
 		log.Println("Ошибка при генерации токена:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка генерации токена"})
@@ -130,5 +124,10 @@ func LoginHandler(c *gin.Context) {
 	}
 
 	log.Println("Авторизация успешна:", req.Email)
-	c.JSON(http.StatusOK, LoginResponse{Token: token})
+	c.JSON(http.StatusOK, tokens)
+}
+
+// JWKSHandler отдаёт открытые ключи проверки токенов для других сервисов.
+func (h *Handler) JWKSHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, h.keys.JWKS())
 }
@@ -7,15 +7,19 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"avito-pvz-service/internal/auth"
+	"avito-pvz-service/internal/repository"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestDummyLoginHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
+	h := NewHandler(repository.NewMemoryRepositories(), auth.NewKeyring(auth.NewHMACKey([]byte("test-secret"))))
 
 	t.Run("ValidRoles", func(t *testing.T) {
-		for _, role := range []string{"client", "staff", "moderator", "anything"} {
+		for _, role := range []string{"client", "staff", "moderator", "employee"} {
 			// Формируем запрос
 			body, _ := json.Marshal(gin.H{"role": role})
 			req := httptest.NewRequest(http.MethodPost, "/dummyLogin", bytes.NewBuffer(body))
@@ -25,7 +29,7 @@ func TestDummyLoginHandler(t *testing.T) {
 			ctx.Request = req
 
 			// Вызываем хендлер
-			DummyLoginHandler(ctx)
+			h.DummyLoginHandler(ctx)
 
 			// Проверяем код и наличие токена
 			assert.Equal(t, http.StatusOK, w.Code, "для роли %q должен быть 200", role)
@@ -36,6 +40,22 @@ func TestDummyLoginHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("UnknownRole", func(t *testing.T) {
+		body, _ := json.Marshal(gin.H{"role": "anything"})
+		req := httptest.NewRequest(http.MethodPost, "/dummyLogin", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = req
+
+		h.DummyLoginHandler(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var resp map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, "Неизвестная роль", resp["message"])
+	})
+
 	t.Run("MissingRole", func(t *testing.T) {
 		// Пустой JSON => нет поля role
 		req := httptest.NewRequest(http.MethodPost, "/dummyLogin", bytes.NewBuffer([]byte(`{}`)))
@@ -44,7 +64,7 @@ func TestDummyLoginHandler(t *testing.T) {
 		ctx, _ := gin.CreateTestContext(w)
 		ctx.Request = req
 
-		DummyLoginHandler(ctx)
+		h.DummyLoginHandler(ctx)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		var resp map[string]string
@@ -60,7 +80,7 @@ func TestDummyLoginHandler(t *testing.T) {
 		ctx, _ := gin.CreateTestContext(w)
 		ctx.Request = req
 
-		DummyLoginHandler(ctx)
+		h.DummyLoginHandler(ctx)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		var resp map[string]string
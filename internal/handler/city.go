@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"avito-pvz-service/internal/rbac"
+	"avito-pvz-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListCitiesHandler отдаёт справочник городов. Модератор видит и отключённые.
+func (h *Handler) ListCitiesHandler(c *gin.Context) {
+	role, _ := c.Get("role")
+	cities, err := h.repos.City.ListCities(c.Request.Context(), role == rbac.RoleModerator)
+	if err != nil {
+		log.Println("Список городов: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, cities)
+}
+
+type CreateCityRequest struct {
+	Name       string `json:"name" binding:"required,max=255"`
+	RegionCode string `json:"regionCode" binding:"required,max=16"`
+	Timezone   string `json:"timezone" binding:"required"`
+	Enabled    *bool  `json:"enabled"`
+}
+
+func (h *Handler) CreateCityHandler(c *gin.Context) {
+	log.Println("Добавление города: начало")
+	var req CreateCityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Добавление города: неверный запрос:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON or missing fields"})
+		return
+	}
+	if !validTimezone(req.Timezone) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown timezone"})
+		return
+	}
+
+	city := repository.City{Name: req.Name, RegionCode: req.RegionCode, Timezone: req.Timezone, Enabled: true}
+	if req.Enabled != nil {
+		city.Enabled = *req.Enabled
+	}
+	created, err := h.repos.City.CreateCity(c.Request.Context(), city)
+	if errors.Is(err, repository.ErrCityExists) {
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Добавление города: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Println("Добавление города: успешно,", created.Name)
+	c.JSON(http.StatusCreated, created)
+}
+
+type UpdateCityRequest struct {
+	RegionCode *string `json:"regionCode" binding:"omitempty,max=16"`
+	Timezone   *string `json:"timezone"`
+	Enabled    *bool   `json:"enabled"`
+}
+
+func (h *Handler) UpdateCityHandler(c *gin.Context) {
+	log.Println("Изменение города: начало")
+	var req UpdateCityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Изменение города: неверный запрос:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON"})
+		return
+	}
+	if req.RegionCode == nil && req.Timezone == nil && req.Enabled == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Nothing to update"})
+		return
+	}
+	if req.Timezone != nil && !validTimezone(*req.Timezone) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown timezone"})
+		return
+	}
+
+	name := c.Param("name")
+	city, err := h.repos.City.UpdateCity(c.Request.Context(), name, repository.CityUpdate{
+		RegionCode: req.RegionCode,
+		Timezone:   req.Timezone,
+		Enabled:    req.Enabled,
+	})
+	if errors.Is(err, repository.ErrCityNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Изменение города: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Printf("Изменение города: успешно, %s, включён=%t\n", city.Name, city.Enabled)
+	c.JSON(http.StatusOK, city)
+}
+
+func (h *Handler) DeleteCityHandler(c *gin.Context) {
+	log.Println("Удаление города: начало")
+	name := c.Param("name")
+
+	err := h.repos.City.DeleteCity(c.Request.Context(), name)
+	if errors.Is(err, repository.ErrCityNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrCityInUse) {
+		// Город с ПВЗ можно только отключить
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Удаление города: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Println("Удаление города: успешно,", name)
+	c.Status(http.StatusNoContent)
+}
+
+// validTimezone проверяет имя часового пояса из базы IANA, например Europe/Moscow.
+func validTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
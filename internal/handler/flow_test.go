@@ -0,0 +1,1021 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"avito-pvz-service/internal/auth"
+	"avito-pvz-service/internal/middleware"
+	"avito-pvz-service/internal/ratelimit"
+	"avito-pvz-service/internal/rbac"
+	"avito-pvz-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRouter собирает роутер теми же RegisterRoutes, что и cmd/server, но поверх хранилища в памяти.
+func newTestRouter(t *testing.T) *gin.Engine {
+	return newTestRouterWithLimits(t, ratelimit.Default())
+}
+
+func newTestRouterWithLimits(t *testing.T, limits *ratelimit.Limits) *gin.Engine {
+	return newTestRouterWithRepos(t, repository.NewMemoryRepositories(), limits)
+}
+
+func newTestRouterWithRepos(t *testing.T, repos repository.Repositories, limits *ratelimit.Limits) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	keys := auth.NewKeyring(auth.NewHMACKey([]byte("test-secret")))
+	router := gin.New()
+	err := NewHandler(repos, keys).RegisterRoutes(router, RouterConfig{
+		Policy:         rbac.Default(),
+		Limiter:        ratelimit.NewLimiter(limits, ratelimit.NewMemoryStore()),
+		IdempotencyTTL: time.Hour,
+	})
+	require.NoError(t, err)
+	return router
+}
+
+func doJSON(t *testing.T, router *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func loginAs(t *testing.T, router *gin.Engine, role string) string {
+	w := doJSON(t, router, http.MethodPost, "/dummyLogin", "", gin.H{"role": role})
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp DummyLoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Token
+}
+
+// assignStaff назначает сотрудника с токеном /dummyLogin (sub "staff") в ПВЗ.
+func assignStaff(t *testing.T, router *gin.Engine, modToken, pvzId string) {
+	w := doJSON(t, router, http.MethodPost, "/pvz/"+pvzId+"/staff", modToken, gin.H{"subject": "staff"})
+	require.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestReceptionFlow_InMemory(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	// Создаём ПВЗ
+	w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Москва"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+
+	// Сотрудник не может создать ПВЗ, а employee из swagger — это тот же сотрудник
+	w = doJSON(t, router, http.MethodPost, "/pvz", staffToken, gin.H{"city": "Москва"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/pvz", loginAs(t, router, "employee"), gin.H{"city": "Москва"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Клиенту список ПВЗ недоступен
+	w = doJSON(t, router, http.MethodGet, "/pvz?startDate=2020-01-01T00:00:00Z&endDate=2100-01-01T00:00:00Z", loginAs(t, router, "client"), nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Открываем приёмку и добавляем товары
+	assignStaff(t, router, modToken, pvz.ID)
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	for _, typ := range []string{"электроника", "одежда", "обувь"} {
+		w = doJSON(t, router, http.MethodPost, "/products", staffToken, gin.H{"pvzId": pvz.ID, "type": typ})
+		require.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/delete_last_product", staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/close_last_reception", staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Список ПВЗ содержит приёмку с двумя товарами
+	w = doJSON(t, router, http.MethodGet, "/pvz?startDate=2020-01-01T00:00:00Z&endDate=2100-01-01T00:00:00Z", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var records []repository.PVZRecord
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &records))
+	require.Len(t, records, 1)
+	require.Len(t, records[0].Receptions, 1)
+	assert.Equal(t, "close", records[0].Receptions[0].Reception.Status)
+	assert.Len(t, records[0].Receptions[0].Products, 2)
+
+	// Удалённый товар виден модератору по includeDeleted=true, сотруднику — нет
+	w = doJSON(t, router, http.MethodGet, "/pvz?startDate=2020-01-01T00:00:00Z&endDate=2100-01-01T00:00:00Z&includeDeleted=true", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &records))
+	products := records[0].Receptions[0].Products
+	require.Len(t, products, 3)
+	require.NotNil(t, products[2].DeletedAt)
+	assert.Equal(t, "обувь", products[2].Type)
+	assert.Equal(t, "staff", products[2].DeletedBy)
+
+	w = doJSON(t, router, http.MethodGet, "/pvz?startDate=2020-01-01T00:00:00Z&endDate=2100-01-01T00:00:00Z&includeDeleted=true", staffToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(t, router, http.MethodGet, "/pvz?startDate=2020-01-01T00:00:00Z&endDate=2100-01-01T00:00:00Z&includeDeleted=maybe", modToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPVZList_Cursor(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	for i := 0; i < 3; i++ {
+		w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Казань"})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var pvz repository.PVZ
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+		assignStaff(t, router, modToken, pvz.ID)
+		w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+		require.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	const base = "/pvz?startDate=2020-01-01T00:00:00Z&endDate=2100-01-01T00:00:00Z&limit=2"
+
+	// Первая страница: пустой cursor включает курсорный режим
+	w := doJSON(t, router, http.MethodGet, base+"&cursor=", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var first PVZListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	assert.Len(t, first.Items, 2)
+	require.NotEmpty(t, first.NextCursor)
+
+	w = doJSON(t, router, http.MethodGet, base+"&cursor="+first.NextCursor, modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var second PVZListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+	assert.Len(t, second.Items, 1)
+	assert.Empty(t, second.NextCursor)
+
+	w = doJSON(t, router, http.MethodGet, base+"&cursor=garbage", modToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Без cursor ответ остаётся массивом, как раньше
+	w = doJSON(t, router, http.MethodGet, base+"&page=2", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var legacy []repository.PVZRecord
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &legacy))
+	assert.Len(t, legacy, 1)
+}
+
+func TestStaffAssignment(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Москва"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+
+	// Без назначения сотрудник не работает с ПВЗ
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/close_last_reception", staffToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Назначать может только модератор, и только в существующий ПВЗ
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/staff", staffToken, gin.H{"subject": "staff"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/pvz/00000000-0000-0000-0000-000000000000/staff", modToken, gin.H{"subject": "staff"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assignStaff(t, router, modToken, pvz.ID)
+	assignStaff(t, router, modToken, pvz.ID)
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/staff", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var staff []repository.StaffAssignment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &staff))
+	require.Len(t, staff, 1)
+	assert.Equal(t, "staff", staff[0].Subject)
+	assert.Equal(t, "moderator", staff[0].AssignedBy)
+
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/products", staffToken, gin.H{"pvzId": pvz.ID, "type": "обувь"})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// Снятие действует сразу, без перевыпуска токена
+	w = doJSON(t, router, http.MethodDelete, "/pvz/"+pvz.ID+"/staff/staff", modToken, nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/delete_last_product", staffToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(t, router, http.MethodDelete, "/pvz/"+pvz.ID+"/staff/staff", modToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	router := newTestRouter(t)
+	refresh := func(token string) *httptest.ResponseRecorder {
+		return doJSON(t, router, http.MethodPost, "/token/refresh", "", gin.H{"refreshToken": token})
+	}
+	var first TokenResponse
+	w := doJSON(t, router, http.MethodPost, "/dummyLogin", "", gin.H{"role": "staff"})
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	require.NotEmpty(t, first.RefreshToken)
+
+	// Обмен выдаёт новую пару
+	w = refresh(first.RefreshToken)
+	require.Equal(t, http.StatusOK, w.Code)
+	var second TokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	w = doJSON(t, router, http.MethodGet, "/pvz?startDate=2020-01-01T00:00:00Z&endDate=2100-01-01T00:00:00Z", second.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Повторное использование старого токена отзывает всё семейство
+	w = refresh(first.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = refresh(second.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = refresh("garbage")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Повтор обмена с тем же Idempotency-Key получает ту же пару, а не отзыв семейства
+	var fourth TokenResponse
+	w = doJSON(t, router, http.MethodPost, "/dummyLogin", "", gin.H{"role": "staff"})
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fourth))
+	refreshWithKey := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/token/refresh", strings.NewReader(`{"refreshToken":"`+fourth.RefreshToken+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.HeaderIdempotencyKey, "refresh-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	exchanged := refreshWithKey()
+	require.Equal(t, http.StatusOK, exchanged.Code)
+	retry := refreshWithKey()
+	require.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(middleware.HeaderIdempotentReplayed))
+	assert.Equal(t, exchanged.Body.String(), retry.Body.String())
+	var fifth TokenResponse
+	require.NoError(t, json.Unmarshal(retry.Body.Bytes(), &fifth))
+	w = refresh(fifth.RefreshToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Выход отзывает access-токен и семейство refresh-токена
+	var third TokenResponse
+	w = doJSON(t, router, http.MethodPost, "/dummyLogin", "", gin.H{"role": "moderator"})
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &third))
+	w = doJSON(t, router, http.MethodPost, "/logout", third.Token, gin.H{"refreshToken": third.RefreshToken})
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = doJSON(t, router, http.MethodPost, "/pvz", third.Token, gin.H{"city": "Москва"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = refresh(third.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestPVZLifecycle(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Казань"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+	assert.True(t, pvz.Active)
+	assignStaff(t, router, modToken, pvz.ID)
+
+	w = doJSON(t, router, http.MethodPatch, "/pvz/"+pvz.ID, modToken, gin.H{"name": "На Баумана", "address": "ул. Баумана, 1"})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(t, router, http.MethodPatch, "/pvz/"+pvz.ID, staffToken, gin.H{"name": "Другое"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(t, router, http.MethodPatch, "/pvz/"+pvz.ID, modToken, gin.H{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID, staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var got repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "На Баумана", got.Name)
+	assert.Equal(t, "ул. Баумана, 1", got.Address)
+	w = doJSON(t, router, http.MethodGet, "/pvz/00000000-0000-0000-0000-000000000000", staffToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Открытая приёмка мешает удалению, но не деактивации
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON(t, router, http.MethodDelete, "/pvz/"+pvz.ID, modToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doJSON(t, router, http.MethodPatch, "/pvz/"+pvz.ID, modToken, gin.H{"active": false})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.False(t, got.Active)
+	assert.NotNil(t, got.ClosedAt)
+
+	// Текущую приёмку можно довести до конца, новую открыть нельзя
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/close_last_reception", staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doJSON(t, router, http.MethodPatch, "/pvz/"+pvz.ID, modToken, gin.H{"active": true})
+	require.Equal(t, http.StatusOK, w.Code)
+	var reopened repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reopened))
+	assert.True(t, reopened.Active)
+	assert.Nil(t, reopened.ClosedAt)
+
+	w = doJSON(t, router, http.MethodDelete, "/pvz/"+pvz.ID, modToken, nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID, modToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCityCatalogue(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	clientToken := loginAs(t, router, "client")
+	novosibirsk := "/cities/" + url.PathEscape("Новосибирск")
+
+	w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Новосибирск"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(t, router, http.MethodPost, "/cities", clientToken, gin.H{"name": "Новосибирск", "regionCode": "54", "timezone": "Asia/Novosibirsk"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/cities", modToken, gin.H{"name": "Новосибирск", "regionCode": "54", "timezone": "Mars/Olympus"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/cities", modToken, gin.H{"name": "Новосибирск", "regionCode": "54", "timezone": "Asia/Novosibirsk"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/cities", modToken, gin.H{"name": "Новосибирск", "regionCode": "54", "timezone": "Asia/Novosibirsk"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Новосибирск"})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// Отключённый город не видят клиенты и в нём нельзя открыть ПВЗ
+	w = doJSON(t, router, http.MethodPatch, novosibirsk, modToken, gin.H{"enabled": false})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Новосибирск"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var cities []repository.City
+	w = doJSON(t, router, http.MethodGet, "/cities", clientToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cities))
+	assert.Len(t, cities, 3)
+	w = doJSON(t, router, http.MethodGet, "/cities", modToken, nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cities))
+	assert.Len(t, cities, 4)
+
+	// Город с ПВЗ удалить нельзя
+	w = doJSON(t, router, http.MethodDelete, novosibirsk, modToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doJSON(t, router, http.MethodDelete, "/cities/"+url.PathEscape("Тверь"), modToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProductTypeCatalogue(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	furniture := gin.H{
+		"code":  "мебель",
+		"names": gin.H{"ru": "Мебель", "en": "Furniture"},
+		"attributes": []gin.H{
+			{"name": "weight", "kind": "number", "unit": "kg", "required": true},
+			{"name": "color", "kind": "string"},
+		},
+	}
+	w := doJSON(t, router, http.MethodPost, "/product-types", staffToken, furniture)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/product-types", modToken, gin.H{"code": "мебель", "attributes": []gin.H{{"name": "weight", "kind": "date"}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/product-types", modToken, furniture)
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/product-types", modToken, furniture)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var types []repository.ProductType
+	w = doJSON(t, router, http.MethodGet, "/product-types", loginAs(t, router, "client"), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &types))
+	assert.Len(t, types, 4)
+
+	w = doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Москва"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+	assignStaff(t, router, modToken, pvz.ID)
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// Атрибуты проверяются по описанию типа
+	for _, attrs := range []gin.H{nil, {"weight": -1}, {"weight": 12, "legs": 4}, {"weight": "12"}} {
+		w = doJSON(t, router, http.MethodPost, "/products", staffToken, gin.H{"pvzId": pvz.ID, "type": "мебель", "attributes": attrs})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "атрибуты %v", attrs)
+	}
+	w = doJSON(t, router, http.MethodPost, "/products", staffToken, gin.H{"pvzId": pvz.ID, "type": "мебель", "attributes": gin.H{"weight": 12.5, "color": "белый"}})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var product repository.Product
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+	assert.Equal(t, 12.5, product.Attributes["weight"])
+
+	// PUT заменяет описание целиком
+	w = doJSON(t, router, http.MethodPut, "/product-types/"+url.PathEscape("мебель"), modToken, gin.H{"names": gin.H{"ru": "Мебель"}, "fragile": true})
+	require.Equal(t, http.StatusOK, w.Code)
+	var updated repository.ProductType
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.True(t, updated.Fragile)
+	assert.Empty(t, updated.Attributes)
+	w = doJSON(t, router, http.MethodPut, "/product-types/"+url.PathEscape("посуда"), modToken, gin.H{})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Тип с товарами удалить нельзя
+	w = doJSON(t, router, http.MethodDelete, "/product-types/"+url.PathEscape("мебель"), modToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doJSON(t, router, http.MethodDelete, "/product-types/"+url.PathEscape("посуда"), modToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProductBarcode(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Казань"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+	assignStaff(t, router, modToken, pvz.ID)
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w = doJSON(t, router, http.MethodPost, "/products", staffToken, gin.H{"pvzId": pvz.ID, "type": "обувь", "barcode": "4600000000017"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var product repository.Product
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+	assert.Equal(t, "4600000000017", product.Barcode)
+
+	// Повторный скан в открытой приёмке
+	w = doJSON(t, router, http.MethodPost, "/products", staffToken, gin.H{"pvzId": pvz.ID, "type": "обувь", "barcode": "4600000000017"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doJSON(t, router, http.MethodGet, "/products/by-barcode/4600000000017", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var loc repository.ProductLocation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loc))
+	assert.Equal(t, product.ID, loc.Product.ID)
+	assert.Equal(t, product.ReceptionId, loc.Reception.ID)
+	assert.Equal(t, "Казань", loc.PVZ.City)
+
+	w = doJSON(t, router, http.MethodGet, "/products/by-barcode/unknown", staffToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(t, router, http.MethodGet, "/products/by-barcode/4600000000017", loginAs(t, router, "client"), nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestProductBatch(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Москва"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+	assignStaff(t, router, modToken, pvz.ID)
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	items := []gin.H{
+		{"type": "электроника", "barcode": "B-1"},
+		{"type": "мебель"},
+		{"type": "одежда", "barcode": "B-1"},
+		{"type": "обувь"},
+	}
+
+	// По умолчанию пакет отклоняется целиком
+	w = doJSON(t, router, http.MethodPost, "/products/batch", staffToken, gin.H{"pvzId": pvz.ID, "items": items})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var resp AddProductsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "all_or_nothing", resp.Mode)
+	assert.Zero(t, resp.Created)
+	require.Len(t, resp.Results, 4)
+	assert.Equal(t, repository.ErrBatchAborted.Error(), resp.Results[0].Error)
+
+	w = doJSON(t, router, http.MethodPost, "/products/batch", staffToken, gin.H{"pvzId": pvz.ID, "mode": "best_effort", "items": items})
+	require.Equal(t, http.StatusCreated, w.Code)
+	resp = AddProductsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Created)
+	assert.NotNil(t, resp.Results[0].Product)
+	assert.Equal(t, repository.ErrInvalidProductType.Error(), resp.Results[1].Error)
+	assert.Equal(t, repository.ErrDuplicateBarcode.Error(), resp.Results[2].Error)
+	assert.Equal(t, 3, resp.Results[3].Index)
+
+	w = doJSON(t, router, http.MethodPost, "/products/batch", staffToken, gin.H{"pvzId": pvz.ID, "mode": "partial", "items": items})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/products/batch", staffToken, gin.H{"pvzId": pvz.ID, "items": []gin.H{}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	tooMany := make([]gin.H, repository.MaxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = gin.H{"type": "обувь"}
+	}
+	w = doJSON(t, router, http.MethodPost, "/products/batch", staffToken, gin.H{"pvzId": pvz.ID, "items": tooMany})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeleteProductById(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Москва"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+	assignStaff(t, router, modToken, pvz.ID)
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var products []repository.Product
+	for _, typ := range []string{"электроника", "одежда", "обувь", "обувь"} {
+		w = doJSON(t, router, http.MethodPost, "/products", staffToken, gin.H{"pvzId": pvz.ID, "type": typ})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var p repository.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+		products = append(products, p)
+	}
+	wrong := "/products/" + products[0].ID
+
+	// Причина обязательна
+	w = doJSON(t, router, http.MethodDelete, wrong, staffToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(t, router, http.MethodDelete, wrong+"?reason=whatever", staffToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(t, router, http.MethodDelete, wrong+"?reason=wrong_scan&comment="+url.QueryEscape("не та коробка"), staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var del repository.ProductDeletion
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &del))
+	assert.Equal(t, "staff", del.DeletedBy)
+	assert.Equal(t, "электроника", del.Type)
+
+	w = doJSON(t, router, http.MethodDelete, wrong+"?reason=wrong_scan", staffToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// LIFO по-прежнему работает и снимает последний товар
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/delete_last_product", staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/close_last_reception", staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(t, router, http.MethodDelete, "/products/"+products[1].ID+"?reason=damaged", staffToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var deletions []repository.ProductDeletion
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/product-deletions", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deletions))
+	require.Len(t, deletions, 1)
+	assert.Equal(t, "не та коробка", deletions[0].Comment)
+}
+
+func TestReopenCancelReception(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Москва"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+	assignStaff(t, router, modToken, pvz.ID)
+
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var rec repository.Reception
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rec))
+	w = doJSON(t, router, http.MethodPost, "/products", staffToken, gin.H{"pvzId": pvz.ID, "type": "обувь"})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// Открытую приёмку переоткрыть нельзя, сотруднику ручка недоступна
+	w = doJSON(t, router, http.MethodPost, "/receptions/"+rec.ID+"/reopen", modToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/close_last_reception", staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/receptions/"+rec.ID+"/reopen", staffToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/receptions/00000000-0000-0000-0000-000000000000/reopen", modToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// После переоткрытия в приёмку снова можно добавлять товары
+	w = doJSON(t, router, http.MethodPost, "/receptions/"+rec.ID+"/reopen", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var reopened repository.Reception
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reopened))
+	assert.Equal(t, "reopened", reopened.Status)
+	w = doJSON(t, router, http.MethodPost, "/products", staffToken, gin.H{"pvzId": pvz.ID, "type": "одежда"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/close_last_reception", staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Отменённая приёмка пропадает из отчёта и больше не меняется
+	w = doJSON(t, router, http.MethodPost, "/receptions/"+rec.ID+"/cancel", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/receptions/"+rec.ID+"/reopen", modToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	// Закрыть отменённую приёмку — тоже недопустимый переход
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/close_last_reception", staffToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doJSON(t, router, http.MethodGet, "/pvz?startDate=2020-01-01T00:00:00Z&endDate=2100-01-01T00:00:00Z", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var records []repository.PVZRecord
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &records))
+	assert.Empty(t, records, "других приёмок у ПВЗ нет")
+}
+
+func TestReceptionHistory(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Москва"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+	assignStaff(t, router, modToken, pvz.ID)
+
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions/current", staffToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(t, router, http.MethodGet, "/pvz/00000000-0000-0000-0000-000000000000/receptions", staffToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var first repository.Reception
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	w = doJSON(t, router, http.MethodPost, "/products", staffToken, gin.H{"pvzId": pvz.ID, "type": "обувь"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/pvz/"+pvz.ID+"/close_last_reception", staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/receptions", staffToken, gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var second repository.Reception
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+
+	// Приёмка по id вместе с товарами
+	w = doJSON(t, router, http.MethodGet, "/receptions/"+first.ID, modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var record repository.ReceptionRecord
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &record))
+	assert.Equal(t, "close", record.Reception.Status)
+	assert.Len(t, record.Products, 1)
+	w = doJSON(t, router, http.MethodGet, "/receptions/00000000-0000-0000-0000-000000000000", modToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(t, router, http.MethodGet, "/receptions/"+first.ID, loginAs(t, router, "client"), nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions/current", staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &record))
+	assert.Equal(t, second.ID, record.Reception.ID)
+
+	// История: фильтр по статусу и пагинация
+	var receptions []repository.Reception
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &receptions))
+	require.Len(t, receptions, 2)
+	assert.Equal(t, second.ID, receptions[0].ID)
+
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions?status=close", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &receptions))
+	require.Len(t, receptions, 1)
+	assert.Equal(t, first.ID, receptions[0].ID)
+
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions?page=2&limit=1", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &receptions))
+	require.Len(t, receptions, 1)
+	assert.Equal(t, first.ID, receptions[0].ID)
+
+	// Курсорная пагинация: обходим историю по одной приёмке
+	var byCursor []string
+	next := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3, "курсор должен закончиться")
+		w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions?limit=1&cursor="+next, modToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var page ReceptionListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		for _, r := range page.Items {
+			byCursor = append(byCursor, r.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		next = page.NextCursor
+	}
+	assert.Equal(t, []string{second.ID, first.ID}, byCursor)
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions?cursor=garbage", modToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions?endDate=2000-01-01T00:00:00Z", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions?status=open", modToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions?startDate=yesterday", modToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWebhookSubscriptions(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+
+	w := doJSON(t, router, http.MethodPost, "/webhooks", loginAs(t, router, "staff"), gin.H{"url": "http://billing.local/hook"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/webhooks", modToken, gin.H{"url": "billing.local/hook"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/webhooks", modToken, gin.H{"url": "http://billing.local/hook", "eventTypes": []string{"pvz_opened"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Секрет сгенерирован и показан только при создании
+	w = doJSON(t, router, http.MethodPost, "/webhooks", modToken, gin.H{
+		"url":        "http://billing.local/hook",
+		"eventTypes": []string{"reception_closed"},
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var sub repository.WebhookSubscription
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+	assert.Len(t, sub.Secret, 64)
+	assert.Equal(t, "moderator", sub.CreatedBy)
+
+	w = doJSON(t, router, http.MethodGet, "/webhooks", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var subs []repository.WebhookSubscription
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &subs))
+	require.Len(t, subs, 1)
+	assert.Empty(t, subs[0].Secret)
+	assert.Equal(t, []string{"reception_closed"}, subs[0].EventTypes)
+
+	w = doJSON(t, router, http.MethodGet, "/webhooks/"+sub.ID+"/deliveries", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+	w = doJSON(t, router, http.MethodGet, "/webhooks/"+sub.ID+"/deliveries?status=lost", modToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(t, router, http.MethodDelete, "/webhooks/"+sub.ID, modToken, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON(t, router, http.MethodDelete, "/webhooks/"+sub.ID, modToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(t, router, http.MethodGet, "/webhooks/"+sub.ID+"/deliveries", modToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAuditLog(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+
+	// id запроса из заголовка попадает в журнал и возвращается в ответе
+	req := httptest.NewRequest(http.MethodPost, "/pvz", strings.NewReader(`{"city":"Москва"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+modToken)
+	req.Header.Set(middleware.HeaderRequestID, "req-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "req-42", w.Header().Get(middleware.HeaderRequestID))
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+
+	// Без заголовка id запроса генерируется
+	assignStaff(t, router, modToken, pvz.ID)
+	w = doJSON(t, router, http.MethodPost, "/receptions", loginAs(t, router, "staff"), gin.H{"pvzId": pvz.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.NotEmpty(t, w.Header().Get(middleware.HeaderRequestID))
+
+	w = doJSON(t, router, http.MethodGet, "/audit?entityType=pvz&entityId="+pvz.ID, modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var entries []repository.AuditEntry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, repository.AuditPVZCreate, entries[0].Action)
+	assert.Equal(t, "moderator", entries[0].Actor)
+	assert.Equal(t, "moderator", entries[0].Role)
+	assert.Equal(t, "req-42", entries[0].RequestID)
+	assert.NotEmpty(t, entries[0].ClientIP)
+	assert.Nil(t, entries[0].Before)
+
+	w = doJSON(t, router, http.MethodGet, "/audit?action=reception.create", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, repository.EntityReception, entries[0].EntityType)
+	assert.Equal(t, "staff", entries[0].Actor)
+
+	w = doJSON(t, router, http.MethodGet, "/audit?from=2100-01-01T00:00:00Z", modToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+	w = doJSON(t, router, http.MethodGet, "/audit?to=tomorrow", modToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(t, router, http.MethodGet, "/audit", loginAs(t, router, "staff"), nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	router := newTestRouter(t)
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	post := func(path, token, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(middleware.HeaderIdempotencyKey, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/pvz", modToken, "pvz-1", `{"city":"Москва"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+	assignStaff(t, router, modToken, pvz.ID)
+
+	// Повтор получает тот же ответ, а не ошибку «приёмка уже открыта»
+	body := `{"pvzId":"` + pvz.ID + `"}`
+	first := post("/receptions", staffToken, "rec-1", body)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(middleware.HeaderIdempotentReplayed))
+	retry := post("/receptions", staffToken, "rec-1", body)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(middleware.HeaderIdempotentReplayed))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", retry.Header().Get("Content-Type"))
+
+	// Повтор добавления товара не создаёт дубль
+	first = post("/products", staffToken, "prod-1", `{"pvzId":"`+pvz.ID+`","type":"обувь"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	retry = post("/products", staffToken, "prod-1", `{"pvzId":"`+pvz.ID+`","type":"обувь"}`)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	w = doJSON(t, router, http.MethodGet, "/pvz/"+pvz.ID+"/receptions/current", staffToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var current struct {
+		Products []repository.Product `json:"products"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &current))
+	assert.Len(t, current.Products, 1)
+
+	// Тот же ключ с другим телом
+	w = post("/products", staffToken, "prod-1", `{"pvzId":"`+pvz.ID+`","type":"одежда"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// Ответ с ошибкой клиента тоже сохраняется
+	first = post("/receptions", staffToken, "rec-2", body)
+	require.Equal(t, http.StatusConflict, first.Code)
+	retry = post("/receptions", staffToken, "rec-2", body)
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(middleware.HeaderIdempotentReplayed))
+
+	// Ключи разных пользователей не пересекаются
+	w = post("/pvz", staffToken, "pvz-1", `{"city":"Москва"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = post("/pvz", modToken, "rec-1", `{"city":"Казань"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = post("/pvz", modToken, strings.Repeat("k", 256), `{"city":"Москва"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// flakyReceptions отказывает в первых fail вызовах CreateReception, как недоступная БД.
+type flakyReceptions struct {
+	repository.ReceptionRepository
+	fail int
+}
+
+func (f *flakyReceptions) CreateReception(ctx context.Context, pvzId string) (*repository.Reception, error) {
+	if f.fail > 0 {
+		f.fail--
+		return nil, errors.New("connection refused")
+	}
+	return f.ReceptionRepository.CreateReception(ctx, pvzId)
+}
+
+func TestIdempotencyKey_RetryAfterServerError(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	repos.Reception = &flakyReceptions{ReceptionRepository: repos.Reception, fail: 1}
+	router := newTestRouterWithRepos(t, repos, ratelimit.Default())
+	modToken := loginAs(t, router, "moderator")
+	staffToken := loginAs(t, router, "staff")
+
+	w := doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Москва"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pvz repository.PVZ
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pvz))
+	assignStaff(t, router, modToken, pvz.ID)
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/receptions", strings.NewReader(`{"pvzId":"`+pvz.ID+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+staffToken)
+		req.Header.Set(middleware.HeaderIdempotencyKey, "rec-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Сбой хранилища — 500, ключ освобождается, и повтор выполняется заново
+	w = post()
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	w = post()
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(middleware.HeaderIdempotentReplayed))
+}
+
+func TestRateLimit(t *testing.T) {
+	limits, err := ratelimit.Parse([]byte(`
+default: {rate: "0"}
+http:
+  "POST /dummyLogin": {rate: 1/m, burst: 3}
+  "POST /pvz": {rate: 1/m, burst: 1}
+`))
+	require.NoError(t, err)
+	router := newTestRouterWithLimits(t, limits)
+
+	// Публичная ручка считается по IP
+	modToken := loginAs(t, router, "moderator")
+	loginAs(t, router, "staff")
+	otherToken := loginAs(t, router, "moderator")
+	w := doJSON(t, router, http.MethodPost, "/dummyLogin", "", gin.H{"role": "moderator"})
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Прокси не настроены: подменённые заголовки не дают новой корзины
+	req := httptest.NewRequest(http.MethodPost, "/dummyLogin", strings.NewReader(`{"role":"moderator"}`))
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	req.Header.Set("X-Real-IP", "203.0.113.10")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/dummyLogin", strings.NewReader(`{"role":"moderator"}`))
+	req.RemoteAddr = "198.51.100.7:5000"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Защищённая — по sub: оба токена модератора делят одну корзину
+	w = doJSON(t, router, http.MethodPost, "/pvz", modToken, gin.H{"city": "Москва"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON(t, router, http.MethodPost, "/pvz", otherToken, gin.H{"city": "Москва"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// Ручки без лимита не ограничены
+	for i := 0; i < 5; i++ {
+		w = doJSON(t, router, http.MethodGet, "/cities", modToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestRegister_RoleAllowList(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := doJSON(t, router, http.MethodPost, "/register", "", gin.H{"email": "client@example.com", "password": "secret", "role": "client"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	// Сотрудник не может зарегистрироваться сам ни под одним из имён роли
+	for _, role := range []string{"staff", "employee"} {
+		w = doJSON(t, router, http.MethodPost, "/register", "", gin.H{"email": role + "@example.com", "password": "secret", "role": role})
+		assert.Equal(t, http.StatusBadRequest, w.Code, role)
+	}
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"avito-pvz-service/internal/auth"
+	"avito-pvz-service/internal/repository"
+)
+
+// Handler содержит зависимости HTTP-хэндлеров.
+type Handler struct {
+	repos repository.Repositories
+	keys  *auth.Keyring
+}
+
+func NewHandler(repos repository.Repositories, keys *auth.Keyring) *Handler {
+	return &Handler{repos: repos, keys: keys}
+}
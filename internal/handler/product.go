@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -8,35 +10,19 @@ import (
 	"avito-pvz-service/internal/repository"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
 type AddProductRequest struct {
-	Type  string `json:"type" binding:"required,oneof=электроника одежда обувь"`
-	PVZId string `json:"pvzId" binding:"required,uuid"`
+	// Type — код из справочника типов товаров
+	Type       string                 `json:"type" binding:"required"`
+	PVZId      string                 `json:"pvzId" binding:"required,uuid"`
+	Attributes map[string]interface{} `json:"attributes"`
+	// Barcode — штрихкод или внешний номер заказа
+	Barcode string `json:"barcode" binding:"omitempty,max=64"`
 }
 
-func AddProductHandler(c *gin.Context) {
+func (h *Handler) AddProductHandler(c *gin.Context) {
 	log.Println("Добавление товара: начало")
-	claims, exists := c.Get("user")
-	if !exists {
-		log.Println("Добавление товара: неавторизован")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
-	}
-	jwtClaims, ok := claims.(jwt.MapClaims)
-	if !ok {
-		log.Println("Добавление товара: неверные данные токена")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token claims"})
-		return
-	}
-	role, ok := jwtClaims["role"].(string)
-	if !ok || role != "staff" {
-		log.Println("Добавление товара: доступ запрещён, роль не сотрудник ПВЗ")
-		c.JSON(http.StatusForbidden, gin.H{"message": "Доступ запрещен: требуется роль сотрудника ПВЗ"})
-		return
-	}
-
 	// привязка JSON
 	var req AddProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -45,13 +31,31 @@ func AddProductHandler(c *gin.Context) {
 		return
 	}
 	log.Printf("Добавление товара: PVZ=%s, тип=%s\n", req.PVZId, req.Type)
+	if !h.requireAssignment(c, req.PVZId) {
+		return
+	}
 
 	// создание записи
-	product, err := repository.AddProduct(req.PVZId, req.Type)
-	if err != nil {
-		log.Println("Добавление товара: ошибка добавления:", err)
+	product, err := h.repos.Product.AddProduct(c.Request.Context(), req.PVZId, repository.NewProduct{
+		Type:       req.Type,
+		Attributes: req.Attributes,
+		Barcode:    req.Barcode,
+	})
+	switch {
+	case errors.Is(err, repository.ErrDuplicateBarcode):
+		log.Println("Добавление товара: повторный скан штрихкода", req.Barcode)
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	case errors.Is(err, repository.ErrNoActiveReception),
+		errors.Is(err, repository.ErrInvalidProductType),
+		errors.Is(err, repository.ErrInvalidProductAttributes):
+		log.Println("Добавление товара: отклонено:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
+	case err != nil:
+		log.Println("Добавление товара: ошибка добавления:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
 	}
 
 	// метрика
@@ -61,27 +65,8 @@ func AddProductHandler(c *gin.Context) {
 	c.JSON(http.StatusCreated, product)
 }
 
-func DeleteLastProductHandler(c *gin.Context) {
+func (h *Handler) DeleteLastProductHandler(c *gin.Context) {
 	log.Println("Удаление товара: начало")
-	claims, exists := c.Get("user")
-	if !exists {
-		log.Println("Удаление товара: неавторизован")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
-	}
-	jwtClaims, ok := claims.(jwt.MapClaims)
-	if !ok {
-		log.Println("Удаление товара: неверные данные токена")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token claims"})
-		return
-	}
-	role, ok := jwtClaims["role"].(string)
-	if !ok || role != "staff" {
-		log.Println("Удаление товара: доступ запрещён, роль не сотрудник ПВЗ")
-		c.JSON(http.StatusForbidden, gin.H{"message": "Доступ запрещен: требуется роль сотрудника ПВЗ"})
-		return
-	}
-
 	pvzId := c.Param("pvzId")
 	if pvzId == "" {
 		log.Println("Удаление товара: отсутствует PVZ id в URL")
@@ -89,13 +74,187 @@ func DeleteLastProductHandler(c *gin.Context) {
 		return
 	}
 	log.Println("Удаление товара: PVZ =", pvzId)
+	if !h.requireAssignment(c, pvzId) {
+		return
+	}
 
-	if err := repository.DeleteLastProduct(pvzId); err != nil {
-		log.Println("Удаление товара: ошибка удаления:", err)
+	err := h.repos.Product.DeleteLastProduct(c.Request.Context(), pvzId)
+	switch {
+	case errors.Is(err, repository.ErrNoActiveReception),
+		errors.Is(err, repository.ErrReceptionClosed),
+		errors.Is(err, repository.ErrNoProducts):
+		log.Println("Удаление товара: отклонено:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
+	case err != nil:
+		log.Println("Удаление товара: ошибка удаления:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
 	}
 
 	log.Println("Удаление товара: успешно удалён последний товар")
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
+
+func (h *Handler) FindProductByBarcodeHandler(c *gin.Context) {
+	code := c.Param("code")
+	loc, err := h.repos.Product.FindProductByBarcode(c.Request.Context(), code)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Поиск товара по штрихкоду: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, loc)
+}
+
+type BatchProductItem struct {
+	Type       string                 `json:"type" binding:"required"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Barcode    string                 `json:"barcode" binding:"omitempty,max=64"`
+}
+
+// AddProductsRequest — пакет товаров одного ПВЗ. По умолчанию режим all_or_nothing.
+// Размер пакета ограничен repository.MaxBatchSize.
+type AddProductsRequest struct {
+	PVZId string             `json:"pvzId" binding:"required,uuid"`
+	Mode  string             `json:"mode" binding:"omitempty,oneof=all_or_nothing best_effort"`
+	Items []BatchProductItem `json:"items" binding:"required,min=1,dive"`
+}
+
+type BatchItemResult struct {
+	Index   int                 `json:"index"`
+	Product *repository.Product `json:"product,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
+type AddProductsResponse struct {
+	Mode    string            `json:"mode"`
+	Created int               `json:"created"`
+	Results []BatchItemResult `json:"results"`
+}
+
+func (h *Handler) AddProductsHandler(c *gin.Context) {
+	log.Println("Пакетное добавление товаров: начало")
+	var req AddProductsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Пакетное добавление товаров: неверный запрос:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON or missing fields"})
+		return
+	}
+	if len(req.Items) > repository.MaxBatchSize {
+		log.Printf("Пакетное добавление товаров: позиций %d, больше лимита\n", len(req.Items))
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Batch is limited to %d items", repository.MaxBatchSize)})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = string(repository.BatchAllOrNothing)
+	}
+	log.Printf("Пакетное добавление товаров: PVZ=%s, позиций=%d, режим=%s\n", req.PVZId, len(req.Items), req.Mode)
+	if !h.requireAssignment(c, req.PVZId) {
+		return
+	}
+
+	items := make([]repository.NewProduct, len(req.Items))
+	for i, it := range req.Items {
+		items[i] = repository.NewProduct{Type: it.Type, Attributes: it.Attributes, Barcode: it.Barcode}
+	}
+	results, err := h.repos.Product.AddProducts(c.Request.Context(), req.PVZId, items, repository.BatchMode(req.Mode))
+	switch {
+	case errors.Is(err, repository.ErrNoActiveReception),
+		errors.Is(err, repository.ErrInvalidBatchMode):
+		log.Println("Пакетное добавление товаров: отклонено:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	case err != nil:
+		log.Println("Пакетное добавление товаров: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	resp := AddProductsResponse{Mode: req.Mode, Results: make([]BatchItemResult, len(results))}
+	for i, res := range results {
+		resp.Results[i] = BatchItemResult{Index: i, Product: res.Product}
+		if res.Err != nil {
+			resp.Results[i].Error = res.Err.Error()
+		} else {
+			resp.Created++
+		}
+	}
+	metrics.ProductsCreatedTotal.Add(float64(resp.Created))
+	log.Printf("Пакетное добавление товаров: добавлено %d из %d\n", resp.Created, len(results))
+
+	// Если не добавилось ничего, ответ — 400 с причинами по позициям
+	status := http.StatusCreated
+	if resp.Created == 0 {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, resp)
+}
+
+// DeleteProductHandler удаляет конкретный товар из открытой приёмки.
+// Причина передаётся в query: ?reason=wrong_scan&comment=...
+func (h *Handler) DeleteProductHandler(c *gin.Context) {
+	log.Println("Удаление товара по id: начало")
+	productId := c.Param("productId")
+	reason := c.Query("reason")
+	if !repository.ValidDeletionReason(reason) {
+		log.Println("Удаление товара по id: неверная причина:", reason)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid or missing reason"})
+		return
+	}
+
+	product, err := h.repos.Product.GetProduct(c.Request.Context(), productId)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Удаление товара по id: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	if !h.requireAssignment(c, product.PVZId) {
+		return
+	}
+
+	deletion, err := h.repos.Product.DeleteProduct(c.Request.Context(), productId, repository.ProductDeletion{
+		Reason:    reason,
+		Comment:   c.Query("comment"),
+		DeletedBy: subjectOf(c),
+	})
+	if errors.Is(err, repository.ErrProductNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrReceptionClosed) {
+		log.Println("Удаление товара по id: приёмка закрыта,", productId)
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Удаление товара по id: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Printf("Удаление товара по id: успешно, id=%s, причина=%s\n", productId, reason)
+	c.JSON(http.StatusOK, deletion)
+}
+
+func (h *Handler) ListProductDeletionsHandler(c *gin.Context) {
+	deletions, err := h.repos.Product.ListProductDeletions(c.Request.Context(), c.Param("pvzId"))
+	if errors.Is(err, repository.ErrPVZNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Журнал удалений товаров: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, deletions)
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"avito-pvz-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) ListProductTypesHandler(c *gin.Context) {
+	types, err := h.repos.ProductType.ListProductTypes(c.Request.Context())
+	if err != nil {
+		log.Println("Список типов товаров: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, types)
+}
+
+// ProductTypeRequest — описание типа товара. Код в PUT берётся из URL.
+type ProductTypeRequest struct {
+	Code       string                     `json:"code" binding:"omitempty,max=50"`
+	Names      map[string]string          `json:"names"`
+	Attributes []repository.AttributeSpec `json:"attributes"`
+	Fragile    bool                       `json:"fragile"`
+}
+
+func (r ProductTypeRequest) productType() repository.ProductType {
+	return repository.ProductType{Code: r.Code, Names: r.Names, Attributes: r.Attributes, Fragile: r.Fragile}
+}
+
+func (h *Handler) CreateProductTypeHandler(c *gin.Context) {
+	log.Println("Добавление типа товара: начало")
+	var req ProductTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		log.Println("Добавление типа товара: неверный запрос:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON or missing code"})
+		return
+	}
+	pt := req.productType()
+	if err := pt.ValidateSpec(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	created, err := h.repos.ProductType.CreateProductType(c.Request.Context(), pt)
+	if errors.Is(err, repository.ErrProductTypeExists) {
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Добавление типа товара: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Println("Добавление типа товара: успешно,", created.Code)
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *Handler) UpdateProductTypeHandler(c *gin.Context) {
+	log.Println("Изменение типа товара: начало")
+	var req ProductTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Изменение типа товара: неверный запрос:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON"})
+		return
+	}
+	req.Code = c.Param("code")
+	pt := req.productType()
+	if err := pt.ValidateSpec(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	updated, err := h.repos.ProductType.UpdateProductType(c.Request.Context(), pt)
+	if errors.Is(err, repository.ErrProductTypeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Изменение типа товара: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Println("Изменение типа товара: успешно,", updated.Code)
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) DeleteProductTypeHandler(c *gin.Context) {
+	log.Println("Удаление типа товара: начало")
+	code := c.Param("code")
+
+	err := h.repos.ProductType.DeleteProductType(c.Request.Context(), code)
+	if errors.Is(err, repository.ErrProductTypeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrProductTypeInUse) {
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Удаление типа товара: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Println("Удаление типа товара: успешно,", code)
+	c.Status(http.StatusNoContent)
+}
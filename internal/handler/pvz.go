@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
@@ -8,35 +9,15 @@ import (
 	"avito-pvz-service/internal/repository"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
 type CreatePVZRequest struct {
 	City string `json:"city" binding:"required"`
 }
 
-func CreatePVZHandler(c *gin.Context) {
+func (h *Handler) CreatePVZHandler(c *gin.Context) {
 	log.Println("Создание ПВЗ: начало")
 
-	claims, exists := c.Get("user")
-	if !exists {
-		log.Println("Создание ПВЗ: неавторизован")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
-	}
-	jwtClaims, ok := claims.(jwt.MapClaims)
-	if !ok {
-		log.Println("Создание ПВЗ: некорректные токен-клеймы")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token claims"})
-		return
-	}
-	role, ok := jwtClaims["role"].(string)
-	if !ok || role != "moderator" {
-		log.Println("Создание ПВЗ: нет прав (нужна роль модератор)")
-		c.JSON(http.StatusForbidden, gin.H{"message": "Доступ запрещен: требуется роль модератора"})
-		return
-	}
-
 	// привязка JSON
 	var req CreatePVZRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -47,7 +28,7 @@ func CreatePVZHandler(c *gin.Context) {
 	log.Printf("Создание ПВЗ: город=%s\n", req.City)
 
 	// создание ПВЗ в БД
-	pvz, err := repository.CreatePVZ(req.City)
+	pvz, err := h.repos.PVZ.CreatePVZ(c.Request.Context(), req.City)
 	if err != nil {
 		log.Println("Создание ПВЗ: ошибка создания:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
@@ -60,3 +41,84 @@ func CreatePVZHandler(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, pvz)
 }
+
+func (h *Handler) GetPVZHandler(c *gin.Context) {
+	pvzId := c.Param("pvzId")
+	pvz, err := h.repos.PVZ.GetPVZ(c.Request.Context(), pvzId)
+	if errors.Is(err, repository.ErrPVZNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Получение ПВЗ: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, pvz)
+}
+
+// UpdatePVZRequest — переданные поля меняются, остальные остаются как есть.
+type UpdatePVZRequest struct {
+	Name    *string `json:"name" binding:"omitempty,max=255"`
+	Address *string `json:"address"`
+	Active  *bool   `json:"active"`
+}
+
+func (h *Handler) UpdatePVZHandler(c *gin.Context) {
+	log.Println("Изменение ПВЗ: начало")
+	var req UpdatePVZRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Изменение ПВЗ: неверный запрос:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON"})
+		return
+	}
+	if req.Name == nil && req.Address == nil && req.Active == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Nothing to update"})
+		return
+	}
+	pvzId := c.Param("pvzId")
+
+	pvz, err := h.repos.PVZ.UpdatePVZ(c.Request.Context(), pvzId, repository.PVZUpdate{
+		Name:    req.Name,
+		Address: req.Address,
+		Active:  req.Active,
+	})
+	if errors.Is(err, repository.ErrPVZNotFound) {
+		log.Println("Изменение ПВЗ: не найден:", pvzId)
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Изменение ПВЗ: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Printf("Изменение ПВЗ: успешно, id=%s, активен=%t\n", pvz.ID, pvz.Active)
+	c.JSON(http.StatusOK, pvz)
+}
+
+func (h *Handler) DeletePVZHandler(c *gin.Context) {
+	log.Println("Удаление ПВЗ: начало")
+	pvzId := c.Param("pvzId")
+
+	err := h.repos.PVZ.DeletePVZ(c.Request.Context(), pvzId)
+	if errors.Is(err, repository.ErrPVZNotFound) {
+		log.Println("Удаление ПВЗ: не найден:", pvzId)
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrPVZHasOpenReception) {
+		log.Println("Удаление ПВЗ: есть незакрытая приёмка:", pvzId)
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Удаление ПВЗ: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Println("Удаление ПВЗ: успешно, id =", pvzId)
+	c.Status(http.StatusNoContent)
+}
@@ -6,13 +6,13 @@ import (
 	"strconv"
 	"time"
 
+	"avito-pvz-service/internal/rbac"
 	"avito-pvz-service/internal/repository"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
-func PVZListHandler(c *gin.Context) {
+func (h *Handler) PVZListHandler(c *gin.Context) {
 	log.Println("Получение списка ПВЗ: начало")
 
 	startDateStr := c.Query("startDate")
@@ -37,6 +37,21 @@ func PVZListHandler(c *gin.Context) {
 		return
 	}
 
+	// Удалённые товары показываются только модератору и только по запросу
+	includeDeleted := false
+	if s := c.Query("includeDeleted"); s != "" {
+		includeDeleted, err = strconv.ParseBool(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid includeDeleted value"})
+			return
+		}
+	}
+	if role, _ := c.Get("role"); includeDeleted && role != rbac.RoleModerator {
+		log.Println("Получение списка ПВЗ: удалённые товары доступны только модератору")
+		c.JSON(http.StatusForbidden, gin.H{"message": "includeDeleted is allowed only for moderator"})
+		return
+	}
+
 	// pagination
 	pageStr  := c.DefaultQuery("page", "1")
 	limitStr := c.DefaultQuery("limit", "10")
@@ -50,30 +65,20 @@ func PVZListHandler(c *gin.Context) {
 		log.Printf("Получение списка ПВЗ: некорректный limit=%s, используем limit=10\n", limitStr)
 		limit = 10
 	}
+	if limit > repository.MaxPageLimit {
+		log.Printf("Получение списка ПВЗ: limit=%d больше максимума, используем limit=%d\n", limit, repository.MaxPageLimit)
+		limit = repository.MaxPageLimit
+	}
 	log.Printf("Получение списка ПВЗ: page=%d, limit=%d\n", page, limit)
 
-	claims, exists := c.Get("user")
-	if !exists {
-		log.Println("Получение списка ПВЗ: неавторизован")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+	// Курсорная пагинация: параметр cursor присутствует (пустой — первая страница)
+	if cursorStr, ok := c.GetQuery("cursor"); ok {
+		h.pvzListByCursor(c, startDate, endDate, includeDeleted, cursorStr, limit)
 		return
 	}
-	jwtClaims, ok := claims.(jwt.MapClaims)
-	if !ok {
-		log.Println("Получение списка ПВЗ: некорректный токен")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token claims"})
-		return
-	}
-	role, ok := jwtClaims["role"].(string)
-	if !ok || (role != "staff" && role != "moderator") {
-		log.Println("Получение списка ПВЗ: доступ запрещён для роли", role)
-		c.JSON(http.StatusForbidden, gin.H{"message": "Доступ запрещен"})
-		return
-	}
-	log.Println("Получение списка ПВЗ: авторизован, роль =", role)
 
 	// Вызов репозитория
-	records, err := repository.GetPVZRecords(&startDate, &endDate, page, limit)
+	records, err := h.repos.PVZ.GetPVZRecords(c.Request.Context(), &startDate, &endDate, includeDeleted, page, limit)
 	if err != nil {
 		log.Println("Получение списка ПВЗ: ошибка репозитория:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
@@ -83,3 +88,35 @@ func PVZListHandler(c *gin.Context) {
 	log.Printf("Получение списка ПВЗ: найдено %d записей\n", len(records))
 	c.JSON(http.StatusOK, records)
 }
+
+// PVZListResponse — ответ в режиме курсорной пагинации.
+type PVZListResponse struct {
+	Items      []repository.PVZRecord `json:"items"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
+}
+
+func (h *Handler) pvzListByCursor(c *gin.Context, startDate, endDate time.Time, includeDeleted bool, cursorStr string, limit int) {
+	var after *repository.PVZCursor
+	if cursorStr != "" {
+		cursor, err := repository.DecodePVZCursor(cursorStr)
+		if err != nil {
+			log.Println("Получение списка ПВЗ: некорректный cursor:", err)
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		after = cursor
+	}
+
+	records, next, err := h.repos.PVZ.GetPVZRecordsPage(c.Request.Context(), &startDate, &endDate, includeDeleted, after, limit)
+	if err != nil {
+		log.Println("Получение списка ПВЗ: ошибка репозитория:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if records == nil {
+		records = []repository.PVZRecord{}
+	}
+
+	log.Printf("Получение списка ПВЗ: найдено %d записей, есть продолжение: %t\n", len(records), next != nil)
+	c.JSON(http.StatusOK, PVZListResponse{Items: records, NextCursor: next.Encode()})
+}
@@ -1,42 +1,26 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"avito-pvz-service/internal/metrics"
 	"avito-pvz-service/internal/repository"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
 type CreateReceptionRequest struct {
 	PVZId string `json:"pvzId" binding:"required,uuid"`
 }
 
-func CreateReceptionHandler(c *gin.Context) {
+func (h *Handler) CreateReceptionHandler(c *gin.Context) {
 	log.Println("Создание приёмки: начало")
 
-	claims, exists := c.Get("user")
-	if !exists {
-		log.Println("Создание приёмки: неавторизован")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
-	}
-	jwtClaims, ok := claims.(jwt.MapClaims)
-	if !ok {
-		log.Println("Создание приёмки: неверные данные токена")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token claims"})
-		return
-	}
-	role, ok := jwtClaims["role"].(string)
-	if !ok || role != "staff" {
-		log.Println("Создание приёмки: нет прав (нужна роль сотрудника ПВЗ)")
-		c.JSON(http.StatusForbidden, gin.H{"message": "Доступ запрещен: требуется роль сотрудника ПВЗ"})
-		return
-	}
-
 	// привязка JSON
 	var req CreateReceptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -45,13 +29,27 @@ func CreateReceptionHandler(c *gin.Context) {
 		return
 	}
 	log.Printf("Создание приёмки: PVZ=%s\n", req.PVZId)
+	if !h.requireAssignment(c, req.PVZId) {
+		return
+	}
 
-	// создание приёмки в репозитории
-	reception, err := repository.CreateReception(req.PVZId)
-	if err != nil {
-		log.Println("Создание приёмки: ошибка создания:", err)
+	// создание приёмки в репозитории; незакрытая приёмка или
+	// деактивированный ПВЗ — конфликт с состоянием, 409
+	reception, err := h.repos.Reception.CreateReception(c.Request.Context(), req.PVZId)
+	switch {
+	case errors.Is(err, repository.ErrPVZNotFound):
+		log.Println("Создание приёмки: ПВЗ не найден")
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
+	case errors.Is(err, repository.ErrReceptionInProgress),
+		errors.Is(err, repository.ErrPVZInactive):
+		log.Println("Создание приёмки: отклонено:", err)
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	case err != nil:
+		log.Println("Создание приёмки: ошибка создания:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
 	}
 
 	// метрика
@@ -61,7 +59,7 @@ func CreateReceptionHandler(c *gin.Context) {
 	c.JSON(http.StatusCreated, reception)
 }
 
-func CloseReceptionHandler(c *gin.Context) {
+func (h *Handler) CloseReceptionHandler(c *gin.Context) {
 	log.Println("Закрытие приёмки: начало")
 
 	pvzId := c.Param("pvzId")
@@ -71,34 +69,210 @@ func CloseReceptionHandler(c *gin.Context) {
 		return
 	}
 	log.Printf("Закрытие приёмки: PVZ=%s\n", pvzId)
+	if !h.requireAssignment(c, pvzId) {
+		return
+	}
+
+	// закрытие через репозиторий; закрыть уже закрытую или отменённую
+	// приёмку — недопустимый переход, 409
+	reception, err := h.repos.Reception.CloseReception(c.Request.Context(), pvzId)
+	switch {
+	case errors.Is(err, repository.ErrNoReceptionToClose):
+		log.Println("Закрытие приёмки: нет приёмки для закрытия")
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	case errors.Is(err, repository.ErrInvalidTransition),
+		errors.Is(err, repository.ErrReceptionClosed):
+		log.Println("Закрытие приёмки: отклонено:", err)
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	case err != nil:
+		log.Println("Закрытие приёмки: ошибка закрытия:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Printf("Закрытие приёмки: успешно, id=%s\n", reception.ID)
+	c.JSON(http.StatusOK, reception)
+}
+
+func (h *Handler) ReopenReceptionHandler(c *gin.Context) {
+	h.transitionReception(c, "Переоткрытие приёмки", h.repos.Reception.ReopenReception)
+}
+
+func (h *Handler) CancelReceptionHandler(c *gin.Context) {
+	h.transitionReception(c, "Отмена приёмки", h.repos.Reception.CancelReception)
+}
+
+// transitionReception переводит приёмку из URL в новый статус. Недопустимый
+// переход и конфликт с другими приёмками ПВЗ — 409.
+func (h *Handler) transitionReception(c *gin.Context, op string, fn func(ctx context.Context, id string) (*repository.Reception, error)) {
+	receptionId := c.Param("receptionId")
+	log.Printf("%s: начало, id=%s\n", op, receptionId)
+
+	reception, err := fn(c.Request.Context(), receptionId)
+	switch {
+	case errors.Is(err, repository.ErrReceptionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	case errors.Is(err, repository.ErrInvalidTransition),
+		errors.Is(err, repository.ErrReceptionNotLatest),
+		errors.Is(err, repository.ErrPVZInactive),
+		errors.Is(err, repository.ErrDuplicateBarcode):
+		log.Printf("%s: отклонено: %v\n", op, err)
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	case err != nil:
+		log.Printf("%s: ошибка: %v\n", op, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Printf("%s: успешно, id=%s, статус=%s\n", op, reception.ID, reception.Status)
+	c.JSON(http.StatusOK, reception)
+}
+
+func (h *Handler) GetReceptionHandler(c *gin.Context) {
+	receptionId := c.Param("receptionId")
+	record, err := h.repos.Reception.GetReception(c.Request.Context(), receptionId)
+	if errors.Is(err, repository.ErrReceptionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Получение приёмки: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
 
-	claims, exists := c.Get("user")
-	if !exists {
-		log.Println("Закрытие приёмки: неавторизован")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+// CurrentReceptionHandler возвращает открытую приёмку ПВЗ с товарами.
+// Нет ПВЗ или открытой приёмки — 404.
+func (h *Handler) CurrentReceptionHandler(c *gin.Context) {
+	pvzId := c.Param("pvzId")
+	if !h.pvzExists(c, pvzId) {
 		return
 	}
-	jwtClaims, ok := claims.(jwt.MapClaims)
-	if !ok {
-		log.Println("Закрытие приёмки: неверные данные токена")
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token claims"})
+	record, err := h.repos.Reception.CurrentReception(c.Request.Context(), pvzId)
+	if errors.Is(err, repository.ErrNoActiveReception) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
 		return
 	}
-	role, ok := jwtClaims["role"].(string)
-	if !ok || role != "staff" {
-		log.Println("Закрытие приёмки: нет прав (нужна роль сотрудника ПВЗ)")
-		c.JSON(http.StatusForbidden, gin.H{"message": "Доступ запрещен: требуется роль сотрудника ПВЗ"})
+	if err != nil {
+		log.Println("Текущая приёмка: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
 		return
 	}
+	c.JSON(http.StatusOK, record)
+}
 
-	// закрытие через репозиторий
-	reception, err := repository.CloseReception(pvzId)
+func (h *Handler) ListReceptionsHandler(c *gin.Context) {
+	pvzId := c.Param("pvzId")
+
+	var filter repository.ReceptionFilter
+	filter.Status = c.Query("status")
+	if filter.Status != "" && !repository.ValidReceptionStatus(filter.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid status"})
+		return
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"startDate", &filter.StartDate}, {"endDate", &filter.EndDate}} {
+		s := c.Query(p.name)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid " + p.name + " format"})
+			return
+		}
+		*p.dst = &t
+	}
+	page, limit := pageParams(c)
+
+	// Курсорная пагинация, как у GET /pvz: параметр cursor присутствует (пустой — первая страница)
+	cursorStr, byCursor := c.GetQuery("cursor")
+	var after *repository.ReceptionCursor
+	if byCursor && cursorStr != "" {
+		cursor, err := repository.DecodeReceptionCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		after = cursor
+	}
+
+	if !h.pvzExists(c, pvzId) {
+		return
+	}
+	if byCursor {
+		h.listReceptionsByCursor(c, pvzId, filter, after, limit)
+		return
+	}
+	receptions, err := h.repos.Reception.ListReceptions(c.Request.Context(), pvzId, filter, page, limit)
 	if err != nil {
-		log.Println("Закрытие приёмки: ошибка закрытия:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		log.Println("История приёмок: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
 		return
 	}
+	if receptions == nil {
+		receptions = []repository.Reception{}
+	}
+	log.Printf("История приёмок: PVZ=%s, page=%d, limit=%d, найдено %d\n", pvzId, page, limit, len(receptions))
+	c.JSON(http.StatusOK, receptions)
+}
 
-	log.Printf("Закрытие приёмки: успешно, id=%s\n", reception.ID)
-	c.JSON(http.StatusOK, reception)
+// ReceptionListResponse — история приёмок в режиме курсорной пагинации.
+type ReceptionListResponse struct {
+	Items      []repository.Reception `json:"items"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
+}
+
+func (h *Handler) listReceptionsByCursor(c *gin.Context, pvzId string, filter repository.ReceptionFilter, after *repository.ReceptionCursor, limit int) {
+	receptions, next, err := h.repos.Reception.ListReceptionsPage(c.Request.Context(), pvzId, filter, after, limit)
+	if err != nil {
+		log.Println("История приёмок: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	if receptions == nil {
+		receptions = []repository.Reception{}
+	}
+	log.Printf("История приёмок: PVZ=%s, limit=%d, найдено %d, есть продолжение: %t\n", pvzId, limit, len(receptions), next != nil)
+	c.JSON(http.StatusOK, ReceptionListResponse{Items: receptions, NextCursor: next.Encode()})
+}
+
+// pvzExists отвечает 404, если ПВЗ из URL нет.
+func (h *Handler) pvzExists(c *gin.Context, pvzId string) bool {
+	_, err := h.repos.PVZ.GetPVZ(c.Request.Context(), pvzId)
+	if errors.Is(err, repository.ErrPVZNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return false
+	}
+	if err != nil {
+		log.Println("Получение ПВЗ: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return false
+	}
+	return true
+}
+
+// pageParams разбирает page и limit так же, как GET /pvz: неверные значения
+// заменяются умолчаниями, limit ограничен MaxPageLimit.
+func pageParams(c *gin.Context) (page, limit int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > repository.MaxPageLimit {
+		limit = repository.MaxPageLimit
+	}
+	return page, limit
 }
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"time"
+
+	"avito-pvz-service/internal/middleware"
+	"avito-pvz-service/internal/ratelimit"
+	"avito-pvz-service/internal/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouterConfig — настройки цепочек middleware.
+type RouterConfig struct {
+	Policy *rbac.Policy
+	// Limiter ограничивает частоту запросов; nil — без ограничения.
+	Limiter *ratelimit.Limiter
+	// IdempotencyTTL — сколько хранятся ответы для повторов с Idempotency-Key; 0 отключает ключи.
+	IdempotencyTTL time.Duration
+	// TrustedProxies — адреса и подсети прокси, которым разрешено передавать
+	// IP клиента в X-Forwarded-For; пустой список — IP берётся из соединения.
+	TrustedProxies []string
+}
+
+// RegisterRoutes регистрирует все ручки сервиса с их middleware. Одна и та
+// же функция собирает роутер в cmd/server и в тестах.
+func (h *Handler) RegisterRoutes(router *gin.Engine, cfg RouterConfig) error {
+	// Иначе gin доверяет заголовкам от любого клиента, и подменённый
+	// X-Forwarded-For давал бы новую корзину лимита и чужой IP в журнале аудита
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return err
+	}
+
+	// Публичные ручки, лимиты частоты считаются по IP
+	public := router.Group("/")
+	if cfg.Limiter != nil {
+		public.Use(middleware.RateLimitMiddleware(cfg.Limiter))
+	}
+	// Повтор /token/refresh без ключа отозвал бы всё семейство как повторное использование
+	if cfg.IdempotencyTTL > 0 {
+		public.Use(middleware.IdempotencyMiddleware(h.repos.Idempotency, cfg.IdempotencyTTL))
+	}
+	{
+		public.POST("/dummyLogin", h.DummyLoginHandler)
+		public.POST("/register", h.RegisterHandler)
+		public.POST("/login", h.LoginHandler)
+		public.POST("/token/refresh", h.RefreshHandler)
+		public.GET("/.well-known/jwks.json", h.JWKSHandler)
+	}
+
+	// Защищённые через JWT, роли проверяются по политике доступа
+	protected := router.Group("/")
+	protected.Use(middleware.JWTMiddleware(h.keys, h.repos.Token))
+	if cfg.Limiter != nil {
+		protected.Use(middleware.RateLimitMiddleware(cfg.Limiter))
+	}
+	protected.Use(middleware.RBACMiddleware(cfg.Policy), middleware.AuditMiddleware())
+	if cfg.IdempotencyTTL > 0 {
+		protected.Use(middleware.IdempotencyMiddleware(h.repos.Idempotency, cfg.IdempotencyTTL))
+	}
+	{
+		protected.POST("/pvz", h.CreatePVZHandler)
+		protected.GET("/pvz", h.PVZListHandler)
+		protected.GET("/pvz/:pvzId", h.GetPVZHandler)
+		protected.PATCH("/pvz/:pvzId", h.UpdatePVZHandler)
+		protected.DELETE("/pvz/:pvzId", h.DeletePVZHandler)
+		protected.POST("/pvz/:pvzId/close_last_reception", h.CloseReceptionHandler)
+		protected.POST("/pvz/:pvzId/delete_last_product", h.DeleteLastProductHandler)
+		protected.GET("/pvz/:pvzId/receptions", h.ListReceptionsHandler)
+		protected.GET("/pvz/:pvzId/receptions/current", h.CurrentReceptionHandler)
+		protected.GET("/pvz/:pvzId/product-deletions", h.ListProductDeletionsHandler)
+		protected.GET("/pvz/:pvzId/staff", h.ListStaffHandler)
+		protected.POST("/pvz/:pvzId/staff", h.AssignStaffHandler)
+		protected.DELETE("/pvz/:pvzId/staff/:subject", h.UnassignStaffHandler)
+		protected.GET("/cities", h.ListCitiesHandler)
+		protected.POST("/cities", h.CreateCityHandler)
+		protected.PATCH("/cities/:name", h.UpdateCityHandler)
+		protected.DELETE("/cities/:name", h.DeleteCityHandler)
+		protected.GET("/product-types", h.ListProductTypesHandler)
+		protected.POST("/product-types", h.CreateProductTypeHandler)
+		protected.PUT("/product-types/:code", h.UpdateProductTypeHandler)
+		protected.DELETE("/product-types/:code", h.DeleteProductTypeHandler)
+		protected.POST("/receptions", h.CreateReceptionHandler)
+		protected.GET("/receptions/:receptionId", h.GetReceptionHandler)
+		protected.POST("/receptions/:receptionId/reopen", h.ReopenReceptionHandler)
+		protected.POST("/receptions/:receptionId/cancel", h.CancelReceptionHandler)
+		protected.POST("/products", h.AddProductHandler)
+		protected.POST("/products/batch", h.AddProductsHandler)
+		protected.DELETE("/products/:productId", h.DeleteProductHandler)
+		protected.GET("/products/by-barcode/:code", h.FindProductByBarcodeHandler)
+		protected.GET("/webhooks", h.ListWebhooksHandler)
+		protected.POST("/webhooks", h.CreateWebhookHandler)
+		protected.DELETE("/webhooks/:webhookId", h.DeleteWebhookHandler)
+		protected.GET("/webhooks/:webhookId/deliveries", h.ListWebhookDeliveriesHandler)
+		protected.GET("/audit", h.ListAuditHandler)
+		protected.POST("/logout", h.LogoutHandler)
+	}
+	return nil
+}
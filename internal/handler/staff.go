@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"avito-pvz-service/internal/rbac"
+	"avito-pvz-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type AssignStaffRequest struct {
+	// Subject — sub сотрудника в JWT: email либо имя роли для /dummyLogin
+	Subject string `json:"subject" binding:"required"`
+}
+
+func (h *Handler) AssignStaffHandler(c *gin.Context) {
+	log.Println("Назначение сотрудника: начало")
+	var req AssignStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Назначение сотрудника: неверный запрос:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON or missing subject"})
+		return
+	}
+	pvzId := c.Param("pvzId")
+
+	// Зарегистрированный пользователь должен быть сотрудником ПВЗ
+	user, err := h.repos.User.GetUserByEmail(c.Request.Context(), req.Subject)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		log.Println("Назначение сотрудника: ошибка поиска пользователя:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	if user != nil {
+		if role, _ := rbac.ParseRole(user.Role); role != rbac.RoleStaff {
+			log.Printf("Назначение сотрудника: %s имеет роль %s\n", req.Subject, user.Role)
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Пользователь не является сотрудником ПВЗ"})
+			return
+		}
+	}
+
+	assignment, err := h.repos.Staff.AssignStaff(c.Request.Context(), req.Subject, pvzId, subjectOf(c))
+	if errors.Is(err, repository.ErrPVZNotFound) {
+		log.Println("Назначение сотрудника: ПВЗ не найден:", pvzId)
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Назначение сотрудника: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Printf("Назначение сотрудника: %s назначен в ПВЗ %s\n", req.Subject, pvzId)
+	c.JSON(http.StatusCreated, assignment)
+}
+
+func (h *Handler) UnassignStaffHandler(c *gin.Context) {
+	log.Println("Снятие сотрудника: начало")
+	pvzId, subject := c.Param("pvzId"), c.Param("subject")
+
+	err := h.repos.Staff.UnassignStaff(c.Request.Context(), subject, pvzId)
+	if errors.Is(err, repository.ErrAssignmentNotFound) {
+		log.Printf("Снятие сотрудника: %s не назначен в ПВЗ %s\n", subject, pvzId)
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Снятие сотрудника: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Printf("Снятие сотрудника: %s снят с ПВЗ %s\n", subject, pvzId)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ListStaffHandler(c *gin.Context) {
+	pvzId := c.Param("pvzId")
+	staff, err := h.repos.Staff.ListStaff(c.Request.Context(), pvzId)
+	if err != nil {
+		log.Println("Список сотрудников: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, staff)
+}
+
+// subjectOf возвращает sub из проверенного JWT.
+func subjectOf(c *gin.Context) string {
+	claims, _ := c.MustGet("user").(jwt.MapClaims)
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// requireAssignment пропускает сотрудника только в назначенные ему ПВЗ.
+// Назначения читаются при каждом запросе, поэтому снятие действует сразу,
+// не дожидаясь истечения токена. Пишет ответ и возвращает false при отказе.
+func (h *Handler) requireAssignment(c *gin.Context, pvzId string) bool {
+	if role, _ := c.Get("role"); role != rbac.RoleStaff {
+		return true
+	}
+	sub := subjectOf(c)
+	ok, err := h.repos.Staff.IsStaffAssigned(c.Request.Context(), sub, pvzId)
+	if err != nil {
+		log.Println("Проверка назначения: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return false
+	}
+	if !ok {
+		log.Printf("Проверка назначения: %s не назначен в ПВЗ %s\n", sub, pvzId)
+		c.JSON(http.StatusForbidden, gin.H{"message": "Сотрудник не назначен в этот ПВЗ"})
+		return false
+	}
+	return true
+}
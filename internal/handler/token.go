@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"avito-pvz-service/internal/auth"
+	"avito-pvz-service/internal/rbac"
+	"avito-pvz-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// TokenResponse — пара токенов: короткий access-токен для запросов
+// и одноразовый refresh-токен для получения следующей пары.
+type TokenResponse struct {
+	Token        string
+	RefreshToken string
+}
+
+// issueTokens выпускает access-токен и refresh-токен нового семейства.
+// Роль проверяется через rbac.ParseRole до вызова.
+func (h *Handler) issueTokens(ctx context.Context, sub string, role rbac.Role) (TokenResponse, error) {
+	log.Printf("Генерация токена для %s с ролью %s", sub, role)
+	access, err := h.keys.Issue(sub, role)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	refresh, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	err = h.repos.Token.CreateRefreshToken(ctx, repository.RefreshToken{
+		ID:        uuid.New().String(),
+		FamilyID:  uuid.New().String(),
+		Subject:   sub,
+		Role:      string(role),
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	})
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	return TokenResponse{Token: access, RefreshToken: refresh}, nil
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+func (h *Handler) RefreshHandler(c *gin.Context) {
+	log.Println("Обновление токена: начало")
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Обновление токена: неверный запрос:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON or missing refreshToken"})
+		return
+	}
+
+	refresh, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		log.Println("Обновление токена: ошибка генерации:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка генерации токена"})
+		return
+	}
+
+	next, err := h.repos.Token.RotateRefreshToken(c.Request.Context(), auth.HashRefreshToken(req.RefreshToken), repository.RefreshToken{
+		ID:        uuid.New().String(),
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	})
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		log.Println("Обновление токена: повторное использование, семейство отозвано")
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrRefreshTokenInvalid) {
+		log.Println("Обновление токена: токен недействителен")
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Обновление токена: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	role, err := rbac.ParseRole(next.Role)
+	if err != nil {
+		log.Printf("Обновление токена: неизвестная роль %q\n", next.Role)
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	access, err := h.keys.Issue(next.Subject, role)
+	if err != nil {
+		log.Println("Обновление токена: ошибка генерации:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка генерации токена"})
+		return
+	}
+
+	log.Println("Обновление токена: успешно для", next.Subject)
+	c.JSON(http.StatusOK, TokenResponse{Token: access, RefreshToken: refresh})
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// LogoutHandler отзывает текущий access-токен и, если передан refresh-токен,
+// всё его семейство.
+func (h *Handler) LogoutHandler(c *gin.Context) {
+	log.Println("Выход: начало")
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Println("Выход: неверный запрос:", err)
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON"})
+			return
+		}
+	}
+
+	claims := c.MustGet("user").(jwt.MapClaims)
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	if err := h.repos.Token.RevokeAccessToken(c.Request.Context(), jti, time.Unix(int64(exp), 0)); err != nil {
+		log.Println("Выход: ошибка отзыва access-токена:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	if req.RefreshToken != "" {
+		err := h.repos.Token.RevokeRefreshFamily(c.Request.Context(), auth.HashRefreshToken(req.RefreshToken))
+		if err != nil && !errors.Is(err, repository.ErrRefreshTokenInvalid) {
+			log.Println("Выход: ошибка отзыва refresh-токена:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+			return
+		}
+	}
+
+	log.Println("Выход: успешно, jti =", jti)
+	c.Status(http.StatusNoContent)
+}
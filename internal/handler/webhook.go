@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"avito-pvz-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+	// EventTypes — типы событий; пустой список — все события
+	EventTypes []string `json:"eventTypes"`
+	// Secret — ключ подписи; если не задан, сервис сгенерирует его сам
+	Secret string `json:"secret"`
+}
+
+func (h *Handler) CreateWebhookHandler(c *gin.Context) {
+	log.Println("Создание подписки на вебхуки: начало")
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Создание подписки на вебхуки: неверный запрос:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON or missing url"})
+		return
+	}
+
+	sub, err := h.repos.Webhook.CreateWebhook(c.Request.Context(), repository.WebhookSubscription{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+		CreatedBy:  subjectOf(c),
+	})
+	if errors.Is(err, repository.ErrInvalidWebhook) {
+		log.Println("Создание подписки на вебхуки:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Создание подписки на вебхуки: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Printf("Создание подписки на вебхуки: id=%s, url=%s\n", sub.ID, sub.URL)
+	c.JSON(http.StatusCreated, sub)
+}
+
+func (h *Handler) ListWebhooksHandler(c *gin.Context) {
+	subs, err := h.repos.Webhook.ListWebhooks(c.Request.Context())
+	if err != nil {
+		log.Println("Список подписок на вебхуки: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	if subs == nil {
+		subs = []repository.WebhookSubscription{}
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+func (h *Handler) DeleteWebhookHandler(c *gin.Context) {
+	id := c.Param("webhookId")
+	err := h.repos.Webhook.DeleteWebhook(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Удаление подписки на вебхуки: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	log.Printf("Удаление подписки на вебхуки: id=%s\n", id)
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveriesHandler возвращает последние доставки подписки,
+// с необязательным фильтром ?status=pending|delivered|dead.
+func (h *Handler) ListWebhookDeliveriesHandler(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !repository.ValidDeliveryStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid status"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	if limit > repository.MaxPageLimit {
+		limit = repository.MaxPageLimit
+	}
+
+	deliveries, err := h.repos.Webhook.ListDeliveries(c.Request.Context(), c.Param("webhookId"), status, limit)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Доставки вебхуков: ошибка:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		return
+	}
+	if deliveries == nil {
+		deliveries = []repository.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
@@ -45,6 +45,26 @@ var (
             Help: "Количество добавленных товаров",
         },
     )
+    ReceptionsAutoClosedTotal = prometheus.NewCounter(
+        prometheus.CounterOpts{
+            Name: "receptions_auto_closed_total",
+            Help: "Количество приёмок, закрытых автоматически по простою",
+        },
+    )
+    WebhookDeliveriesTotal = prometheus.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "webhook_deliveries_total",
+            Help: "Количество попыток доставки вебхуков",
+        },
+        []string{"result"},
+    )
+    RateLimitedTotal = prometheus.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "rate_limited_total",
+            Help: "Количество запросов, отклонённых лимитом частоты",
+        },
+        []string{"protocol", "route"},
+    )
 )
 
 func init() {
@@ -55,6 +75,9 @@ func init() {
         PVZCreatedTotal,
         ReceptionsCreatedTotal,
         ProductsCreatedTotal,
+        ReceptionsAutoClosedTotal,
+        WebhookDeliveriesTotal,
+        RateLimitedTotal,
     )
 }
 
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"avito-pvz-service/internal/audit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// HeaderRequestID — заголовок с id запроса. Если клиент его не прислал,
+// id генерируется; в ответе заголовок есть всегда.
+const HeaderRequestID = "X-Request-ID"
+
+// AuditMiddleware кладёт в контекст запроса инициатора для журнала аудита:
+// sub и роль из токена, id запроса и IP клиента. Ставится после RBACMiddleware.
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(HeaderRequestID)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.New().String()
+		}
+		c.Header(HeaderRequestID, requestID)
+
+		claims, _ := c.MustGet("user").(jwt.MapClaims)
+		sub, _ := claims["sub"].(string)
+		role, _ := claims["role"].(string)
+		ctx := audit.WithActor(c.Request.Context(), audit.Actor{
+			Subject:   sub,
+			Role:      role,
+			RequestID: requestID,
+			ClientIP:  c.ClientIP(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"avito-pvz-service/internal/audit"
+	"avito-pvz-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderIdempotencyKey — ключ, по которому повтор изменяющего запроса
+	// получает ответ первого вместо повторного выполнения.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed есть в ответе, сохранённом для ключа ранее.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLen совпадает с размером колонки idempotency_keys.key.
+const maxIdempotencyKeyLen = 255
+
+// IdempotencyMiddleware обрабатывает заголовок Idempotency-Key у POST, PUT,
+// PATCH и DELETE. Ответ на первый запрос с ключом хранится ttl и отдаётся
+// на повторы с тем же телом; повтор с другим телом получает 422, а пока
+// первый запрос выполняется — 409. Ответы 5xx не сохраняются, чтобы повтор
+// выполнился заново. Ключи разделены по sub, поэтому на защищённых ручках
+// ставится после AuditMiddleware; на публичных, где sub нет, — по IP клиента.
+// Повтор получает сохранённый ответ, только если тело совпало, так что токены
+// из ответов /login и /token/refresh достаются лишь тому, кто уже знает
+// пароль или refresh-токен.
+func IdempotencyMiddleware(store repository.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if key == "" || !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "Invalid request"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		scope := audit.ActorFrom(ctx).Subject
+		if scope == "" {
+			scope = "ip:" + c.ClientIP()
+		}
+		hash := repository.HashRequest(c.Request.Method+" "+c.Request.URL.RequestURI(), body)
+		prev, err := store.ReserveIdempotencyKey(ctx, scope, key, hash, ttl)
+		if err != nil {
+			log.Printf("Ошибка резервирования ключа идемпотентности: %v\n", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+			return
+		}
+		switch {
+		case prev == nil:
+		case prev.RequestHash != hash:
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"message": "Idempotency-Key is already used with a different request"})
+			return
+		case !prev.Completed():
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": "A request with this Idempotency-Key is still in progress"})
+			return
+		default:
+			c.Header(HeaderIdempotentReplayed, "true")
+			if len(prev.Response) == 0 {
+				c.AbortWithStatus(prev.StatusCode)
+			} else {
+				c.Data(prev.StatusCode, prev.ContentType, prev.Response)
+				c.Abort()
+			}
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+
+		// Клиент мог отключиться, а ключ всё равно нужно завершить
+		ctx = context.WithoutCancel(ctx)
+		if status := rec.Status(); status >= http.StatusInternalServerError {
+			err = store.ReleaseIdempotencyKey(ctx, scope, key)
+		} else {
+			err = store.CompleteIdempotencyKey(ctx, scope, key, status, rec.Header().Get("Content-Type"), rec.body.Bytes())
+		}
+		if err != nil {
+			log.Printf("Ошибка сохранения ответа для ключа идемпотентности: %v\n", err)
+		}
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// responseRecorder копирует тело ответа, чтобы сохранить его для повторов.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -1,23 +1,18 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
-	"os"
 	"strings"
 
+	"avito-pvz-service/internal/auth"
+
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
-func getJWTSecret() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "defaultsecret"
-	}
-	return []byte(secret)
-}
-
-func JWTMiddleware() gin.HandlerFunc {
+// JWTMiddleware проверяет токен из заголовка Authorization, отклоняет
+// отозванные токены и кладёт claims в контекст под ключом "user".
+func JWTMiddleware(keys *auth.Keyring, revoked auth.RevocationList) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -30,22 +25,14 @@ func JWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return getJWTSecret(), nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid token"})
+		claims, err := keys.Verify(c.Request.Context(), parts[1], revoked)
+		if auth.IsRejected(err) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
 			return
 		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid token claims"})
+		if err != nil {
+			log.Println("Ошибка проверки отзыва токена:", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
 			return
 		}
 
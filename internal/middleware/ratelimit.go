@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"avito-pvz-service/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// RateLimitMiddleware ограничивает частоту запросов к ручке по лимитам
+// limiter: защищённые ручки считаются по sub из JWT, публичные — по IP
+// клиента. На защищённых ручках ставится после JWTMiddleware. Сверх лимита
+// отвечает 429 с заголовком Retry-After.
+func RateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := "ip:" + c.ClientIP()
+		if claims, ok := c.Get("user"); ok {
+			jwtClaims, _ := claims.(jwt.MapClaims)
+			if sub, _ := jwtClaims["sub"].(string); sub != "" {
+				client = "sub:" + sub
+			}
+		}
+
+		ok, wait := limiter.AllowHTTP(c.Request.Context(), c.Request.Method, c.FullPath(), client)
+		if !ok {
+			c.Header("Retry-After", ratelimit.RetryAfter(wait))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"message": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"avito-pvz-service/internal/auth"
+	"avito-pvz-service/internal/rbac"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// RBACMiddleware пропускает запрос, только если роль из токена разрешена
+// политикой для этой ручки. Ставится после JWTMiddleware.
+func RBACMiddleware(policy *rbac.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := c.Get("user")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+			return
+		}
+		jwtClaims, ok := claims.(jwt.MapClaims)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": auth.ErrInvalidTokenClaims.Error()})
+			return
+		}
+
+		name, _ := jwtClaims["role"].(string)
+		role, err := rbac.ParseRole(name)
+		if err != nil || !policy.AllowHTTP(c.Request.Method, c.FullPath(), role) {
+			log.Printf("Доступ запрещен: %s %s для роли %q\n", c.Request.Method, c.FullPath(), name)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Доступ запрещен для роли " + name})
+			return
+		}
+
+		c.Set("role", role)
+		c.Next()
+	}
+}
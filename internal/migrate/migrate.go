@@ -0,0 +1,328 @@
+// Package migrate применяет версионированные SQL-миграции к PostgreSQL.
+//
+// Каждый файл NNNN_name.sql содержит секции "-- +migrate Up" и
+// "-- +migrate Down". Применённые версии и контрольные суммы файлов
+// хранятся в таблице schema_migrations. Все операции выполняются под
+// advisory lock, поэтому несколько реплик могут стартовать одновременно.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockKey — ключ pg_advisory_lock, общий для всех реплик сервиса.
+const lockKey int64 = 7_202_504_170
+
+const (
+	upMarker   = "-- +migrate Up"
+	downMarker = "-- +migrate Down"
+)
+
+var (
+	ErrSchemaBehind      = errors.New("схема БД отстаёт: есть непримененные миграции")
+	ErrChecksumMismatch  = errors.New("контрольная сумма применённой миграции не совпадает с файлом")
+	ErrNothingToRollback = errors.New("нет применённых миграций для отката")
+)
+
+// Migration — одна версия схемы.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status — состояние миграции относительно БД.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	// Modified — файл изменился после применения.
+	Modified bool
+	// Missing — версия применена, но файла в бинарнике нет.
+	Missing bool
+}
+
+// Load читает и сортирует по версии миграции из корня fsys.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := make(map[int64]string)
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		m, err := parseFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := seen[m.Version]; ok {
+			return nil, fmt.Errorf("версия %d повторяется в %s и %s", m.Version, prev, e.Name())
+		}
+		seen[m.Version] = e.Name()
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func parseFile(fsys fs.FS, filename string) (Migration, error) {
+	base := strings.TrimSuffix(filename, ".sql")
+	num, name, ok := strings.Cut(base, "_")
+	if !ok {
+		return Migration{}, fmt.Errorf("%s: имя должно иметь вид NNNN_name.sql", filename)
+	}
+	version, err := strconv.ParseInt(num, 10, 64)
+	if err != nil {
+		return Migration{}, fmt.Errorf("%s: некорректная версия: %w", filename, err)
+	}
+
+	content, err := fs.ReadFile(fsys, filename)
+	if err != nil {
+		return Migration{}, err
+	}
+	text := string(content)
+	upIdx := strings.Index(text, upMarker)
+	if upIdx < 0 {
+		return Migration{}, fmt.Errorf("%s: нет секции %q", filename, upMarker)
+	}
+	up := text[upIdx+len(upMarker):]
+	down := ""
+	if downIdx := strings.Index(up, downMarker); downIdx >= 0 {
+		down = up[downIdx+len(downMarker):]
+		up = up[:downIdx]
+	}
+
+	sum := sha256.Sum256(content)
+	return Migration{
+		Version:  version,
+		Name:     name,
+		Up:       strings.TrimSpace(up),
+		Down:     strings.TrimSpace(down),
+		Checksum: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// Migrator применяет миграции к конкретной БД.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+type appliedRow struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// withLock выполняет fn на отдельном соединении под advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("advisory lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	if _, err := conn.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version BIGINT PRIMARY KEY,
+            name TEXT NOT NULL,
+            checksum TEXT NOT NULL,
+            applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+        )`); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedRow, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int64]appliedRow)
+	for rows.Next() {
+		var version int64
+		var row appliedRow
+		if err := rows.Scan(&version, &row.checksum, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		result[version] = row
+	}
+	return result, rows.Err()
+}
+
+// verifyChecksums сверяет применённые миграции с файлами.
+func (m *Migrator) verifyChecksums(done map[int64]appliedRow) error {
+	for _, mig := range m.migrations {
+		row, ok := done[mig.Version]
+		if ok && row.checksum != mig.Checksum {
+			return fmt.Errorf("%w: %04d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
+
+// Up применяет все непримененные миграции по порядку, каждую в своей транзакции.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var result []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verifyChecksums(done); err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := runInTx(ctx, conn, mig.Up,
+				"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+				mig.Version, mig.Name, mig.Checksum); err != nil {
+				return fmt.Errorf("миграция %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+			result = append(result, mig)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Down откатывает последнюю применённую миграцию.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var result *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verifyChecksums(done); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := done[mig.Version]; !ok {
+				continue
+			}
+			if err := runInTx(ctx, conn, mig.Down,
+				"DELETE FROM schema_migrations WHERE version = $1", mig.Version); err != nil {
+				return fmt.Errorf("откат %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+			result = &mig
+			return nil
+		}
+		return ErrNothingToRollback
+	})
+	return result, err
+}
+
+// Status возвращает состояние всех известных и применённых версий.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var result []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		known := make(map[int64]bool)
+		for _, mig := range m.migrations {
+			known[mig.Version] = true
+			st := Status{Version: mig.Version, Name: mig.Name}
+			if row, ok := done[mig.Version]; ok {
+				at := row.appliedAt
+				st.Applied = true
+				st.AppliedAt = &at
+				st.Modified = row.checksum != mig.Checksum
+			}
+			result = append(result, st)
+		}
+		for version, row := range done {
+			if known[version] {
+				continue
+			}
+			at := row.appliedAt
+			result = append(result, Status{Version: version, Applied: true, AppliedAt: &at, Missing: true})
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+		return nil
+	})
+	return result, err
+}
+
+// Verify проверяет, что все миграции применены и не изменялись после применения.
+func (m *Migrator) Verify(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verifyChecksums(done); err != nil {
+			return err
+		}
+		var pending []string
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; !ok {
+				pending = append(pending, fmt.Sprintf("%04d_%s", mig.Version, mig.Name))
+			}
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%w: %s", ErrSchemaBehind, strings.Join(pending, ", "))
+		}
+		return nil
+	})
+}
+
+// runInTx выполняет SQL миграции и запись в schema_migrations атомарно.
+func runInTx(ctx context.Context, conn *sql.Conn, script, bookkeeping string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if script != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,190 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"avito-pvz-service/migrations"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0002_second.sql": {Data: []byte("-- +migrate Up\nCREATE INDEX b;\n-- +migrate Down\nDROP INDEX b;\n")},
+		"0001_first.sql":  {Data: []byte("-- +migrate Up\nCREATE TABLE a();\n\n-- +migrate Down\nDROP TABLE a;\n")},
+		"README.md":       {Data: []byte("не миграция")},
+	}
+}
+
+func expectLock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`SELECT pg_advisory_lock`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestLoad(t *testing.T) {
+	migs, err := Load(testFS())
+	require.NoError(t, err)
+	require.Len(t, migs, 2)
+
+	assert.EqualValues(t, 1, migs[0].Version)
+	assert.Equal(t, "first", migs[0].Name)
+	assert.Equal(t, "CREATE TABLE a();", migs[0].Up)
+	assert.Equal(t, "DROP TABLE a;", migs[0].Down)
+	assert.Len(t, migs[0].Checksum, 64)
+	assert.EqualValues(t, 2, migs[1].Version)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	_, err := Load(fstest.MapFS{"0001_x.sql": {Data: []byte("CREATE TABLE a();")}})
+	assert.Error(t, err, "файл без секции Up")
+
+	_, err = Load(fstest.MapFS{"first.sql": {Data: []byte("-- +migrate Up\n")}})
+	assert.Error(t, err, "имя без версии")
+
+	_, err = Load(fstest.MapFS{
+		"0001_a.sql": {Data: []byte("-- +migrate Up\n")},
+		"0001_b.sql": {Data: []byte("-- +migrate Up\n")},
+	})
+	assert.Error(t, err, "повторяющаяся версия")
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	migs, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, migs)
+	for _, m := range migs {
+		assert.NotEmpty(t, m.Up, "%04d_%s", m.Version, m.Name)
+		assert.NotEmpty(t, m.Down, "%04d_%s", m.Version, m.Name)
+	}
+}
+
+func TestUp_AppliesPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	m, err := New(db, testFS())
+	require.NoError(t, err)
+
+	expectLock(mock)
+	mock.ExpectQuery(`SELECT version, checksum, applied_at FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, m.migrations[0].Checksum, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE INDEX b`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).
+		WithArgs(int64(2), "second", m.migrations[1].Checksum).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	applied, err := m.Up(context.Background())
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.EqualValues(t, 2, applied[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUp_ChecksumMismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	m, err := New(db, testFS())
+	require.NoError(t, err)
+
+	expectLock(mock)
+	mock.ExpectQuery(`SELECT version, checksum, applied_at FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, "изменённый", time.Now()))
+	expectUnlock(mock)
+
+	_, err = m.Up(context.Background())
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestDown_RollsBackLast(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	m, err := New(db, testFS())
+	require.NoError(t, err)
+
+	expectLock(mock)
+	mock.ExpectQuery(`SELECT version, checksum, applied_at FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, m.migrations[0].Checksum, time.Now()).
+			AddRow(2, m.migrations[1].Checksum, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DROP INDEX b`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM schema_migrations`).WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	mig, err := m.Down(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, mig.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerify(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	m, err := New(db, testFS())
+	require.NoError(t, err)
+
+	// Применена только первая миграция — схема отстаёт
+	expectLock(mock)
+	mock.ExpectQuery(`SELECT version, checksum, applied_at FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, m.migrations[0].Checksum, time.Now()))
+	expectUnlock(mock)
+	err = m.Verify(context.Background())
+	assert.ErrorIs(t, err, ErrSchemaBehind)
+	assert.Contains(t, err.Error(), "0002_second")
+
+	// Применены обе
+	expectLock(mock)
+	mock.ExpectQuery(`SELECT version, checksum, applied_at FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, m.migrations[0].Checksum, time.Now()).
+			AddRow(2, m.migrations[1].Checksum, time.Now()))
+	expectUnlock(mock)
+	assert.NoError(t, m.Verify(context.Background()))
+}
+
+func TestStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	m, err := New(db, testFS())
+	require.NoError(t, err)
+
+	expectLock(mock)
+	mock.ExpectQuery(`SELECT version, checksum, applied_at FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, "другая", time.Now()).
+			AddRow(9, "x", time.Now()))
+	expectUnlock(mock)
+
+	statuses, err := m.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.True(t, statuses[0].Applied)
+	assert.True(t, statuses[0].Modified)
+	assert.False(t, statuses[1].Applied)
+	assert.True(t, statuses[2].Missing)
+}
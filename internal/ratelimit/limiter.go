@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"avito-pvz-service/internal/metrics"
+)
+
+// Limiter сверяет запросы с лимитами ручек и методов. Корзина у каждой
+// пары ручка — клиент своя; клиент — это "ip:<адрес>" или "sub:<sub из JWT>".
+type Limiter struct {
+	limits *Limits
+	store  Store
+}
+
+func NewLimiter(limits *Limits, store Store) *Limiter {
+	return &Limiter{limits: limits, store: store}
+}
+
+// AllowHTTP проверяет запрос клиента к ручке; route — шаблон пути из роутера.
+func (l *Limiter) AllowHTTP(ctx context.Context, method, route, client string) (bool, time.Duration) {
+	return l.allow(ctx, "http", method+" "+route, l.limits.HTTP(method, route), client)
+}
+
+// AllowRPC проверяет вызов клиентом gRPC-метода.
+func (l *Limiter) AllowRPC(ctx context.Context, fullMethod, client string) (bool, time.Duration) {
+	return l.allow(ctx, "grpc", fullMethod, l.limits.RPC(fullMethod), client)
+}
+
+func (l *Limiter) allow(ctx context.Context, protocol, route string, limit Limit, client string) (bool, time.Duration) {
+	if limit.Unlimited() {
+		return true, 0
+	}
+	ok, wait, err := l.store.Take(ctx, protocol+"|"+route+"|"+client, limit)
+	if err != nil {
+		// Недоступное хранилище лимитов не должно останавливать сервис
+		log.Printf("Ошибка хранилища лимитов запросов: %v\n", err)
+		return true, 0
+	}
+	if !ok {
+		metrics.RateLimitedTotal.WithLabelValues(protocol, route).Inc()
+	}
+	return ok, wait
+}
+
+// RetryAfter — значение заголовка Retry-After: целые секунды, не меньше одной.
+func RetryAfter(wait time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(wait.Seconds()))))
+}
@@ -0,0 +1,152 @@
+// Package ratelimit ограничивает частоту запросов к HTTP-ручкам и
+// gRPC-методам по алгоритму token bucket.
+package ratelimit
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed limits.yaml
+var defaultLimits []byte
+
+// Limit — параметры корзины токенов: Rate токенов в секунду, не больше Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited сообщает, что лимит не ограничивает запросы.
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Limits сопоставляет HTTP-ручкам и gRPC-методам лимиты.
+type Limits struct {
+	def  Limit
+	http map[string]Limit
+	grpc map[string]Limit
+}
+
+type limitSpec struct {
+	Rate  string `yaml:"rate"`
+	Burst int    `yaml:"burst"`
+}
+
+type limitsFile struct {
+	Default limitSpec            `yaml:"default"`
+	HTTP    map[string]limitSpec `yaml:"http"`
+	GRPC    map[string]limitSpec `yaml:"grpc"`
+}
+
+// Parse разбирает лимиты в формате limits.yaml.
+func Parse(data []byte) (*Limits, error) {
+	var f limitsFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("некорректные лимиты запросов: %w", err)
+	}
+	def, err := f.Default.limit()
+	if err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+	httpLimits, err := parseLimits(f.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	grpcLimits, err := parseLimits(f.GRPC)
+	if err != nil {
+		return nil, err
+	}
+	return &Limits{def: def, http: httpLimits, grpc: grpcLimits}, nil
+}
+
+func parseLimits(raw map[string]limitSpec) (map[string]Limit, error) {
+	limits := make(map[string]Limit, len(raw))
+	for key, spec := range raw {
+		l, err := spec.limit()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		limits[key] = l
+	}
+	return limits, nil
+}
+
+func (s limitSpec) limit() (Limit, error) {
+	if s.Rate == "" {
+		return Limit{}, nil
+	}
+	rate, err := parseRate(s.Rate)
+	if err != nil {
+		return Limit{}, err
+	}
+	if s.Burst < 0 || (rate > 0 && s.Burst == 0) {
+		return Limit{}, fmt.Errorf("burst должен быть больше нуля")
+	}
+	return Limit{Rate: rate, Burst: s.Burst}, nil
+}
+
+// parseRate переводит "N/s", "N/m", "N/h" или число в токены в секунду.
+func parseRate(s string) (float64, error) {
+	num, unit, hasUnit := strings.Cut(s, "/")
+	per := time.Second
+	if hasUnit {
+		switch unit {
+		case "s":
+		case "m":
+			per = time.Minute
+		case "h":
+			per = time.Hour
+		default:
+			return 0, fmt.Errorf("неверная единица в rate %q: ожидается s, m или h", s)
+		}
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("неверное значение rate %q", s)
+	}
+	return n / per.Seconds(), nil
+}
+
+// Default возвращает встроенные лимиты из limits.yaml.
+func Default() *Limits {
+	l, err := Parse(defaultLimits)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// Load читает лимиты из файла; пустой путь — встроенные лимиты.
+func Load(path string) (*Limits, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// HTTP возвращает лимит ручки: route — шаблон пути из роутера, например /pvz/:pvzId.
+func (l *Limits) HTTP(method, route string) Limit {
+	if limit, ok := l.http[method+" "+route]; ok {
+		return limit
+	}
+	return l.def
+}
+
+// RPC возвращает лимит gRPC-метода по его полному имени.
+func (l *Limits) RPC(fullMethod string) Limit {
+	if limit, ok := l.grpc[fullMethod]; ok {
+		return limit
+	}
+	return l.def
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	for in, want := range map[string]float64{
+		"5":    5,
+		"5/s":  5,
+		"30/m": 0.5,
+		"36/h": 0.01,
+		"0":    0,
+	} {
+		got, err := parseRate(in)
+		require.NoError(t, err, in)
+		assert.InDelta(t, want, got, 1e-9, in)
+	}
+
+	for _, in := range []string{"", "fast", "5/d", "-1/s"} {
+		_, err := parseRate(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestDefaultLimits(t *testing.T) {
+	l := Default()
+
+	login := l.HTTP("POST", "/login")
+	assert.InDelta(t, 10.0/60, login.Rate, 1e-9)
+	assert.Equal(t, 5, login.Burst)
+	// Ручки вне файла получают default
+	assert.Equal(t, Limit{Rate: 20, Burst: 40}, l.HTTP("GET", "/pvz"))
+	assert.Equal(t, Limit{Rate: 10, Burst: 20}, l.RPC("/pvz.v1.PVZService/AddProduct"))
+	assert.Equal(t, Limit{Rate: 20, Burst: 40}, l.RPC("/pvz.v1.PVZService/GetPVZList"))
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("default: {rate: \"0\"}\nhttp:\n  \"POST /login\": {rate: 1/m, burst: 1}\n"), 0o600))
+
+	l, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, l.HTTP("GET", "/pvz").Unlimited())
+	assert.False(t, l.HTTP("POST", "/login").Unlimited())
+
+	require.NoError(t, os.WriteFile(path, []byte("http:\n  \"POST /login\": {rate: 1/m}\n"), 0o600))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "POST /login")
+}
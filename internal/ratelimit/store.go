@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store хранит корзины токенов. MemoryStore считает запросы в пределах
+// одной реплики; чтобы лимит был общим для всех реплик, нужна реализация
+// поверх общего хранилища, например Redis.
+type Store interface {
+	// Take забирает токен из корзины key. Если токенов нет, возвращает
+	// false и время, через которое появится следующий.
+	Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// sweepInterval — как часто MemoryStore удаляет полные корзины: они
+// ничем не отличаются от новых.
+const sweepInterval = time.Minute
+
+// MemoryStore хранит корзины токенов в памяти процесса.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+	limit  Limit
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, b := range s.buckets {
+			if b.refill(now) >= float64(b.limit.Burst) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), at: now}
+		s.buckets[key] = b
+	}
+	// Лимит могли поменять, пока корзина жила
+	b.limit = limit
+	b.tokens, b.at = b.refill(now), now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// refill — сколько токенов в корзине к моменту now.
+func (b *bucket) refill(now time.Time) float64 {
+	return min(float64(b.limit.Burst), b.tokens+now.Sub(b.at).Seconds()*b.limit.Rate)
+}
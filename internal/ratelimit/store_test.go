@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+	limit := Limit{Rate: 2, Burst: 3}
+
+	// Полная корзина позволяет burst запросов подряд
+	for i := 0; i < 3; i++ {
+		ok, _, err := store.Take(ctx, "a", limit)
+		require.NoError(t, err)
+		assert.True(t, ok, i)
+	}
+	ok, wait, err := store.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// У другого клиента своя корзина
+	ok, _, _ = store.Take(ctx, "b", limit)
+	assert.True(t, ok)
+
+	// Через полсекунды появляется один токен
+	now = now.Add(500 * time.Millisecond)
+	ok, _, _ = store.Take(ctx, "a", limit)
+	assert.True(t, ok)
+	ok, _, _ = store.Take(ctx, "a", limit)
+	assert.False(t, ok)
+
+	// Полные корзины удаляются при очистке
+	now = now.Add(sweepInterval)
+	_, _, _ = store.Take(ctx, "c", limit)
+	assert.Len(t, store.buckets, 1)
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (bool, time.Duration, error) {
+	return false, 0, errors.New("хранилище недоступно")
+}
+
+func TestLimiter(t *testing.T) {
+	limits, err := Parse([]byte("default: {rate: \"0\"}\nhttp:\n  \"POST /login\": {rate: 1/m, burst: 1}\n"))
+	require.NoError(t, err)
+	l := NewLimiter(limits, NewMemoryStore())
+	ctx := context.Background()
+
+	ok, _ := l.AllowHTTP(ctx, "POST", "/login", "ip:10.0.0.1")
+	assert.True(t, ok)
+	ok, wait := l.AllowHTTP(ctx, "POST", "/login", "ip:10.0.0.1")
+	assert.False(t, ok)
+	assert.Equal(t, "60", RetryAfter(wait))
+	ok, _ = l.AllowHTTP(ctx, "POST", "/login", "ip:10.0.0.2")
+	assert.True(t, ok)
+
+	// Без лимита хранилище не трогается, а при его сбое запросы пропускаются
+	l = NewLimiter(limits, failingStore{})
+	ok, _ = l.AllowRPC(ctx, "/pvz.v1.PVZService/CreatePVZ", "sub:moderator")
+	assert.True(t, ok)
+	ok, _ = l.AllowHTTP(ctx, "POST", "/login", "ip:10.0.0.1")
+	assert.True(t, ok)
+
+	assert.Equal(t, "1", RetryAfter(10*time.Millisecond))
+}
@@ -0,0 +1,95 @@
+package rbac
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed policy.yaml
+var defaultPolicy []byte
+
+// Policy сопоставляет HTTP-ручкам и gRPC-методам разрешённые роли.
+// Всё, чего нет в политике, запрещено.
+type Policy struct {
+	http map[string][]Role
+	grpc map[string][]Role
+}
+
+type policyFile struct {
+	HTTP map[string][]string `yaml:"http"`
+	GRPC map[string][]string `yaml:"grpc"`
+}
+
+// Parse разбирает политику в формате policy.yaml.
+func Parse(data []byte) (*Policy, error) {
+	var f policyFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("некорректная политика доступа: %w", err)
+	}
+	httpRules, err := parseRules(f.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	grpcRules, err := parseRules(f.GRPC)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{http: httpRules, grpc: grpcRules}, nil
+}
+
+func parseRules(raw map[string][]string) (map[string][]Role, error) {
+	rules := make(map[string][]Role, len(raw))
+	for key, names := range raw {
+		for _, name := range names {
+			role, err := ParseRole(name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w %q", key, err, name)
+			}
+			rules[key] = append(rules[key], role)
+		}
+	}
+	return rules, nil
+}
+
+// Default возвращает встроенную политику из policy.yaml.
+func Default() *Policy {
+	p, err := Parse(defaultPolicy)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Load читает политику из файла; пустой путь — встроенная политика.
+func Load(path string) (*Policy, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// AllowHTTP проверяет доступ к ручке: route — шаблон пути из роутера, например /pvz/:pvzId/close_last_reception.
+func (p *Policy) AllowHTTP(method, route string, role Role) bool {
+	return allowed(p.http[method+" "+route], role)
+}
+
+// AllowRPC проверяет доступ к gRPC-методу по его полному имени.
+func (p *Policy) AllowRPC(fullMethod string, role Role) bool {
+	return allowed(p.grpc[fullMethod], role)
+}
+
+func allowed(roles []Role, role Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
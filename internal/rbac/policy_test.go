@@ -0,0 +1,59 @@
+package rbac
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRole(t *testing.T) {
+	for in, want := range map[string]Role{
+		"staff":     RoleStaff,
+		"employee":  RoleStaff,
+		"Moderator": RoleModerator,
+		"client":    RoleClient,
+	} {
+		got, err := ParseRole(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got)
+	}
+
+	for _, in := range []string{"", "admin", "anything"} {
+		_, err := ParseRole(in)
+		assert.ErrorIs(t, err, ErrUnknownRole, in)
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	p := Default()
+
+	assert.True(t, p.AllowHTTP("POST", "/pvz", RoleModerator))
+	assert.False(t, p.AllowHTTP("POST", "/pvz", RoleStaff))
+	assert.True(t, p.AllowHTTP("POST", "/pvz/:pvzId/close_last_reception", RoleStaff))
+	assert.False(t, p.AllowHTTP("GET", "/pvz", RoleClient))
+	// Ручки вне политики запрещены
+	assert.False(t, p.AllowHTTP("DELETE", "/pvz", RoleModerator))
+
+	assert.True(t, p.AllowRPC("/pvz.v1.PVZService/WatchPVZ", RoleStaff))
+	assert.False(t, p.AllowRPC("/pvz.v1.PVZService/CreateReception", RoleModerator))
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("http:\n  \"POST /pvz\": [employee, moderator]\n"), 0o600))
+
+	p, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, p.AllowHTTP("POST", "/pvz", RoleStaff))
+	assert.False(t, p.AllowRPC("/pvz.v1.PVZService/CreatePVZ", RoleModerator))
+
+	require.NoError(t, os.WriteFile(path, []byte("http:\n  \"POST /pvz\": [admin]\n"), 0o600))
+	_, err = Load(path)
+	assert.ErrorIs(t, err, ErrUnknownRole)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
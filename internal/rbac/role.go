@@ -0,0 +1,37 @@
+// Package rbac — роли пользователей и политика доступа к HTTP-ручкам и gRPC-методам.
+package rbac
+
+import (
+	"errors"
+	"strings"
+)
+
+// Role — роль пользователя, которая попадает в JWT.
+type Role string
+
+const (
+	RoleClient    Role = "client"
+	RoleStaff     Role = "staff"
+	RoleModerator Role = "moderator"
+)
+
+var ErrUnknownRole = errors.New("Неизвестная роль")
+
+// aliases — устаревшие имена ролей: в первой версии swagger.yaml сотрудник ПВЗ назывался employee.
+var aliases = map[string]Role{
+	"employee": RoleStaff,
+}
+
+// ParseRole приводит имя роли к каноническому значению. Роли, которых нет
+// в CHECK-ограничении таблицы users, отклоняются.
+func ParseRole(s string) (Role, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch r := Role(s); r {
+	case RoleClient, RoleStaff, RoleModerator:
+		return r, nil
+	}
+	if r, ok := aliases[s]; ok {
+		return r, nil
+	}
+	return "", ErrUnknownRole
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"avito-pvz-service/internal/audit"
+)
+
+// Действия, которые пишутся в журнал аудита.
+const (
+	AuditPVZCreate = "pvz.create"
+	AuditPVZUpdate = "pvz.update"
+	AuditPVZDelete = "pvz.delete"
+
+	AuditReceptionCreate    = "reception.create"
+	AuditReceptionClose     = "reception.close"
+	AuditReceptionAutoClose = "reception.auto_close"
+	AuditReceptionReopen    = "reception.reopen"
+	AuditReceptionCancel    = "reception.cancel"
+
+	AuditProductAdd        = "product.add"
+	AuditProductDeleteLast = "product.delete_last"
+	AuditProductDelete     = "product.delete"
+
+	AuditStaffAssign   = "staff.assign"
+	AuditStaffUnassign = "staff.unassign"
+
+	AuditCityCreate = "city.create"
+	AuditCityUpdate = "city.update"
+	AuditCityDelete = "city.delete"
+
+	AuditProductTypeCreate = "product_type.create"
+	AuditProductTypeUpdate = "product_type.update"
+	AuditProductTypeDelete = "product_type.delete"
+
+	AuditWebhookCreate = "webhook.create"
+	AuditWebhookDelete = "webhook.delete"
+)
+
+// Типы сущностей в журнале аудита.
+const (
+	EntityPVZ             = "pvz"
+	EntityReception       = "reception"
+	EntityProduct         = "product"
+	EntityStaffAssignment = "staff_assignment"
+	EntityCity            = "city"
+	EntityProductType     = "product_type"
+	EntityWebhook         = "webhook"
+)
+
+// AuditEntry — запись журнала аудита. Before и After — состояние сущности
+// до и после действия; у создания нет Before, у удаления — After.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	Time       time.Time       `json:"time"`
+	Actor      string          `json:"actor"`
+	Role       string          `json:"role,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entityType"`
+	EntityID   string          `json:"entityId"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"requestId,omitempty"`
+	ClientIP   string          `json:"clientIp,omitempty"`
+}
+
+// AuditFilter — условия выборки журнала. Пустые поля не ограничивают выборку.
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+}
+
+// auditChange — изменение одной сущности для журнала.
+type auditChange struct {
+	action     string
+	entityType string
+	entityId   string
+	before     interface{}
+	after      interface{}
+}
+
+// auditEntry дополняет изменение сведениями об инициаторе из контекста.
+func auditEntry(ctx context.Context, at time.Time, c auditChange) AuditEntry {
+	actor := audit.ActorFrom(ctx)
+	return AuditEntry{
+		Time:       at,
+		Actor:      actor.Subject,
+		Role:       actor.Role,
+		Action:     c.action,
+		EntityType: c.entityType,
+		EntityID:   c.entityId,
+		Before:     auditJSON(c.before),
+		After:      auditJSON(c.after),
+		RequestID:  actor.RequestID,
+		ClientIP:   actor.ClientIP,
+	}
+}
+
+// auditJSON сериализует состояние сущности; nil и нулевой указатель — без состояния.
+func auditJSON(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+	return data
+}
+
+// nullJSON готовит состояние к записи в колонку JSONB.
+func nullJSON(data json.RawMessage) interface{} {
+	if data == nil {
+		return nil
+	}
+	return []byte(data)
+}
+
+// writeAudit — общий хук журнала аудита: записывает изменения в транзакции
+// самого изменения, чтобы запись появилась тогда и только тогда, когда
+// изменение закоммичено.
+func writeAudit(ctx context.Context, tx *sql.Tx, changes ...auditChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	now := time.Now()
+	values := make([]string, 0, len(changes))
+	args := make([]interface{}, 0, len(changes)*10)
+	for _, c := range changes {
+		e := auditEntry(ctx, now, c)
+		k := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			k+1, k+2, k+3, k+4, k+5, k+6, k+7, k+8, k+9, k+10))
+		args = append(args, e.Time, e.Actor, e.Role, e.Action, e.EntityType, e.EntityID,
+			nullJSON(e.Before), nullJSON(e.After), e.RequestID, e.ClientIP)
+	}
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO audit_log (created_at, actor, role, action, entity_type, entity_id, before, after, request_id, client_ip)
+        VALUES `+strings.Join(values, ", "), args...)
+	return err
+}
+
+// PostgresAuditRepository читает журнал аудита из PostgreSQL. Пишут в
+// журнал остальные хранилища через writeAudit.
+type PostgresAuditRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAuditRepository(db *sql.DB) *PostgresAuditRepository {
+	return &PostgresAuditRepository{db: db}
+}
+
+// ListAudit возвращает записи журнала, новые первыми.
+func (r *PostgresAuditRepository) ListAudit(ctx context.Context, f AuditFilter, page, limit int) ([]AuditEntry, error) {
+	query := `SELECT id, created_at, actor, role, action, entity_type, entity_id, before, after, request_id, client_ip
+        FROM audit_log WHERE TRUE`
+	var args []interface{}
+	for _, cond := range []struct {
+		column, value string
+	}{
+		{"actor", f.Actor},
+		{"action", f.Action},
+		{"entity_type", f.EntityType},
+		{"entity_id", f.EntityID},
+	} {
+		if cond.value != "" {
+			args = append(args, cond.value)
+			query += fmt.Sprintf(" AND %s = $%d", cond.column, len(args))
+		}
+	}
+	if f.From != nil {
+		args = append(args, *f.From)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if f.To != nil {
+		args = append(args, *f.To)
+		query += fmt.Sprintf(" AND created_at <= $%d", len(args))
+	}
+	args = append(args, limit, (page-1)*limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.Time, &e.Actor, &e.Role, &e.Action, &e.EntityType, &e.EntityID,
+			&before, &after, &e.RequestID, &e.ClientIP); err != nil {
+			return nil, err
+		}
+		if before != nil {
+			e.Before = before
+		}
+		if after != nil {
+			e.After = after
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
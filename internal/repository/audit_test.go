@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"avito-pvz-service/internal/audit"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAudit_Actor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := audit.WithActor(context.Background(), audit.Actor{
+		Subject: "moderator@example.com", Role: "moderator", RequestID: "req-1", ClientIP: "10.0.0.1",
+	})
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO audit_log .* VALUES \(\$1, .*\$10\), \(\$11, .*\$20\)`).
+		WithArgs(
+			sqlmock.AnyArg(), "moderator@example.com", "moderator", AuditCityDelete, EntityCity, "Казань",
+			[]byte(`{"name":"Казань"}`), nil, "req-1", "10.0.0.1",
+			sqlmock.AnyArg(), "moderator@example.com", "moderator", AuditCityCreate, EntityCity, "Уфа",
+			nil, []byte(`{"name":"Уфа"}`), "req-1", "10.0.0.1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		return writeAudit(ctx, tx,
+			auditChange{action: AuditCityDelete, entityType: EntityCity, entityId: "Казань", before: map[string]string{"name": "Казань"}},
+			auditChange{action: AuditCityCreate, entityType: EntityCity, entityId: "Уфа", after: map[string]string{"name": "Уфа"}})
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAudit_Filters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	from := time.Now().Add(-time.Hour)
+	mock.ExpectQuery(`FROM audit_log WHERE TRUE AND entity_type = \$1 AND entity_id = \$2 AND created_at >= \$3 ORDER BY created_at DESC, id DESC LIMIT \$4 OFFSET \$5`).
+		WithArgs(EntityPVZ, "pvz-1", from, 10, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "actor", "role", "action", "entity_type", "entity_id",
+			"before", "after", "request_id", "client_ip"}).
+			AddRow(7, time.Now(), "moderator@example.com", "moderator", AuditPVZUpdate, EntityPVZ, "pvz-1",
+				[]byte(`{"active":true}`), []byte(`{"active":false}`), "req-1", "10.0.0.1").
+			AddRow(3, time.Now(), audit.System, "", AuditPVZCreate, EntityPVZ, "pvz-1",
+				nil, []byte(`{"active":true}`), "", ""))
+
+	entries, err := NewPostgresAuditRepository(db).ListAudit(context.Background(),
+		AuditFilter{EntityType: EntityPVZ, EntityID: "pvz-1", From: &from}, 2, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.JSONEq(t, `{"active":false}`, string(entries[0].After))
+	assert.Nil(t, entries[1].Before)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// City — город из справочника, в котором можно открывать ПВЗ.
+type City struct {
+	Name       string    `json:"name"`
+	RegionCode string    `json:"regionCode"`
+	Timezone   string    `json:"timezone"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// CityUpdate — частичное изменение города: nil-поля не меняются.
+type CityUpdate struct {
+	RegionCode *string
+	Timezone   *string
+	Enabled    *bool
+}
+
+// Имена ограничений из миграции 0008.
+const (
+	citiesPKey  = "cities_pkey"
+	pvzCityFKey = "pvz_city_fkey"
+)
+
+// PostgresCityRepository хранит справочник городов в PostgreSQL.
+type PostgresCityRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCityRepository(db *sql.DB) *PostgresCityRepository {
+	return &PostgresCityRepository{db: db}
+}
+
+const cityColumns = "name, region_code, timezone, enabled, created_at"
+
+func scanCity(row rowScanner) (City, error) {
+	var c City
+	err := row.Scan(&c.Name, &c.RegionCode, &c.Timezone, &c.Enabled, &c.CreatedAt)
+	return c, err
+}
+
+func (r *PostgresCityRepository) ListCities(ctx context.Context, includeDisabled bool) ([]City, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+cityColumns+" FROM cities WHERE enabled OR $1 ORDER BY name", includeDisabled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []City{}
+	for rows.Next() {
+		c, err := scanCity(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
+
+func (r *PostgresCityRepository) CreateCity(ctx context.Context, city City) (*City, error) {
+	var c City
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		c, err = scanCity(tx.QueryRowContext(ctx, `
+            INSERT INTO cities (name, region_code, timezone, enabled)
+            VALUES ($1, $2, $3, $4)
+            RETURNING `+cityColumns, city.Name, city.RegionCode, city.Timezone, city.Enabled))
+		if isUniqueViolation(err, citiesPKey) {
+			return ErrCityExists
+		}
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{action: AuditCityCreate, entityType: EntityCity, entityId: c.Name, after: c})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *PostgresCityRepository) UpdateCity(ctx context.Context, name string, upd CityUpdate) (*City, error) {
+	var c City
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		before, err := scanCity(tx.QueryRowContext(ctx, "SELECT "+cityColumns+" FROM cities WHERE name = $1 FOR UPDATE", name))
+		if err == sql.ErrNoRows {
+			return ErrCityNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		c, err = scanCity(tx.QueryRowContext(ctx, `
+            UPDATE cities SET
+                region_code = COALESCE($2, region_code),
+                timezone = COALESCE($3, timezone),
+                enabled = COALESCE($4, enabled)
+            WHERE name = $1
+            RETURNING `+cityColumns, name, upd.RegionCode, upd.Timezone, upd.Enabled))
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{action: AuditCityUpdate, entityType: EntityCity, entityId: name, before: before, after: c})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *PostgresCityRepository) DeleteCity(ctx context.Context, name string) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		before, err := scanCity(tx.QueryRowContext(ctx, "DELETE FROM cities WHERE name = $1 RETURNING "+cityColumns, name))
+		if isForeignKeyViolation(err, pvzCityFKey) {
+			return ErrCityInUse
+		}
+		if err == sql.ErrNoRows {
+			return ErrCityNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{action: AuditCityDelete, entityType: EntityCity, entityId: name, before: before})
+	})
+}
+
+// CityCache кэширует множество включённых городов для проверки в CreatePVZ.
+// Изменения через сам кэш сбрасывают его сразу, изменения с других
+// инстансов становятся видны не позже чем через ttl.
+type CityCache struct {
+	CityRepository
+	ttl time.Duration
+
+	mu       sync.Mutex
+	enabled  map[string]bool
+	loadedAt time.Time
+}
+
+func NewCityCache(repo CityRepository, ttl time.Duration) *CityCache {
+	return &CityCache{CityRepository: repo, ttl: ttl}
+}
+
+// IsCityEnabled сообщает, можно ли открыть ПВЗ в городе.
+func (c *CityCache) IsCityEnabled(ctx context.Context, name string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.enabled == nil || time.Since(c.loadedAt) > c.ttl {
+		cities, err := c.CityRepository.ListCities(ctx, false)
+		if err != nil {
+			return false, err
+		}
+		c.enabled = make(map[string]bool, len(cities))
+		for _, city := range cities {
+			c.enabled[city.Name] = true
+		}
+		c.loadedAt = time.Now()
+	}
+	return c.enabled[name], nil
+}
+
+func (c *CityCache) invalidate() {
+	c.mu.Lock()
+	c.enabled = nil
+	c.mu.Unlock()
+}
+
+func (c *CityCache) CreateCity(ctx context.Context, city City) (*City, error) {
+	defer c.invalidate()
+	return c.CityRepository.CreateCity(ctx, city)
+}
+
+func (c *CityCache) UpdateCity(ctx context.Context, name string, upd CityUpdate) (*City, error) {
+	defer c.invalidate()
+	return c.CityRepository.UpdateCity(ctx, name, upd)
+}
+
+func (c *CityCache) DeleteCity(ctx context.Context, name string) error {
+	defer c.invalidate()
+	return c.CityRepository.DeleteCity(ctx, name)
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCityCache_InvalidatedOnUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	cache := NewCityCache(NewPostgresCityRepository(db), time.Hour)
+	ctx := context.Background()
+
+	expectEnabledCities(mock, "Москва")
+	ok, err := cache.IsCityEnabled(ctx, "Москва")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	enabled := false
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT name, region_code, timezone, enabled, created_at FROM cities WHERE name = \$1 FOR UPDATE`).
+		WithArgs("Москва").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "region_code", "timezone", "enabled", "created_at"}).
+			AddRow("Москва", "77", "Europe/Moscow", true, time.Now()))
+	mock.ExpectQuery(`UPDATE cities SET`).
+		WithArgs("Москва", nil, nil, &enabled).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "region_code", "timezone", "enabled", "created_at"}).
+			AddRow("Москва", "77", "Europe/Moscow", false, time.Now()))
+	expectAudit(mock)
+	mock.ExpectCommit()
+	_, err = cache.UpdateCity(ctx, "Москва", CityUpdate{Enabled: &enabled})
+	require.NoError(t, err)
+
+	// После изменения справочник перечитывается
+	expectEnabledCities(mock)
+	ok, err = cache.IsCityEnabled(ctx, "Москва")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateCity_Exists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO cities`).
+		WithArgs("Казань", "16", "Europe/Moscow", true).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: citiesPKey})
+	mock.ExpectRollback()
+
+	_, err = NewPostgresCityRepository(db).CreateCity(context.Background(), City{
+		Name: "Казань", RegionCode: "16", Timezone: "Europe/Moscow", Enabled: true,
+	})
+	assert.ErrorIs(t, err, ErrCityExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCity_InUse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM cities WHERE name = \$1 RETURNING`).
+		WithArgs("Казань").
+		WillReturnError(&pq.Error{Code: "23503", Constraint: pvzCityFKey})
+	mock.ExpectRollback()
+
+	err = NewPostgresCityRepository(db).DeleteCity(context.Background(), "Казань")
+	assert.ErrorIs(t, err, ErrCityInUse)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const parallelism = 32
+
+// concurrencyBackends возвращает хранилища, на которых гоняются конкурентные тесты.
+// PostgreSQL подключается, только если задана TEST_DATABASE_DSN и схема уже применена.
+func concurrencyBackends(t *testing.T) map[string]Repositories {
+	backends := map[string]Repositories{
+		"memory": NewMemoryRepositories(),
+	}
+	if dsn := os.Getenv("TEST_DATABASE_DSN"); dsn != "" {
+		db, err := sql.Open("postgres", dsn)
+		require.NoError(t, err)
+		require.NoError(t, db.Ping())
+		t.Cleanup(func() { db.Close() })
+		backends["postgres"] = NewPostgresRepositories(db)
+	}
+	return backends
+}
+
+// parallel запускает fn в n горутинах одновременно.
+func parallel(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+}
+
+func productsOf(t *testing.T, repos Repositories, pvzId, receptionId string) []Product {
+	start := time.Now().Add(-time.Hour)
+	end := time.Now().Add(time.Hour)
+	// лимит с запасом: в общей БД могут быть ПВЗ других тестов
+	records, err := repos.PVZ.GetPVZRecords(context.Background(), &start, &end, false, 1, 10000)
+	require.NoError(t, err)
+	for _, rec := range records {
+		if rec.PVZ.ID != pvzId {
+			continue
+		}
+		for _, r := range rec.Receptions {
+			if r.Reception.ID == receptionId {
+				return r.Products
+			}
+		}
+	}
+	return nil
+}
+
+func TestConcurrent_CreateReception(t *testing.T) {
+	for name, repos := range concurrencyBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			pvz, err := repos.PVZ.CreatePVZ(ctx, "Москва")
+			require.NoError(t, err)
+
+			var created, rejected int32
+			parallel(parallelism, func(int) {
+				_, err := repos.Reception.CreateReception(ctx, pvz.ID)
+				switch err {
+				case nil:
+					atomic.AddInt32(&created, 1)
+				case ErrReceptionInProgress:
+					atomic.AddInt32(&rejected, 1)
+				default:
+					t.Errorf("unexpected error: %v", err)
+				}
+			})
+
+			assert.EqualValues(t, 1, created, "открыта должна быть ровно одна приёмка")
+			assert.EqualValues(t, parallelism-1, rejected)
+		})
+	}
+}
+
+func TestConcurrent_AddProductWhileClosing(t *testing.T) {
+	for name, repos := range concurrencyBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			pvz, err := repos.PVZ.CreatePVZ(ctx, "Казань")
+			require.NoError(t, err)
+			rec, err := repos.Reception.CreateReception(ctx, pvz.ID)
+			require.NoError(t, err)
+
+			var added int32
+			parallel(parallelism, func(i int) {
+				if i == parallelism/2 {
+					_, err := repos.Reception.CloseReception(ctx, pvz.ID)
+					assert.NoError(t, err)
+					return
+				}
+				_, err := repos.Product.AddProduct(ctx, pvz.ID, NewProduct{Type: "одежда"})
+				switch err {
+				case nil:
+					atomic.AddInt32(&added, 1)
+				case ErrNoActiveReception:
+				default:
+					t.Errorf("unexpected error: %v", err)
+				}
+			})
+
+			// Каждый успешно добавленный товар попал в приёмку, и после закрытия
+			// добавить товар уже нельзя.
+			assert.Len(t, productsOf(t, repos, pvz.ID, rec.ID), int(added))
+			_, err = repos.Product.AddProduct(ctx, pvz.ID, NewProduct{Type: "одежда"})
+			assert.ErrorIs(t, err, ErrNoActiveReception)
+		})
+	}
+}
+
+func TestConcurrent_DeleteLastProduct(t *testing.T) {
+	for name, repos := range concurrencyBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			pvz, err := repos.PVZ.CreatePVZ(ctx, "Санкт-Петербург")
+			require.NoError(t, err)
+			rec, err := repos.Reception.CreateReception(ctx, pvz.ID)
+			require.NoError(t, err)
+
+			const products = parallelism / 2
+			for i := 0; i < products; i++ {
+				_, err := repos.Product.AddProduct(ctx, pvz.ID, NewProduct{Type: "обувь"})
+				require.NoError(t, err)
+			}
+
+			var deleted, empty int32
+			parallel(parallelism, func(int) {
+				err := repos.Product.DeleteLastProduct(ctx, pvz.ID)
+				switch err {
+				case nil:
+					atomic.AddInt32(&deleted, 1)
+				case ErrNoProducts:
+					atomic.AddInt32(&empty, 1)
+				default:
+					t.Errorf("unexpected error: %v", err)
+				}
+			})
+
+			assert.EqualValues(t, products, deleted)
+			assert.EqualValues(t, parallelism-products, empty)
+			assert.Empty(t, productsOf(t, repos, pvz.ID, rec.ID))
+		})
+	}
+}
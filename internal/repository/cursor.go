@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxPageLimit — верхняя граница размера страницы списка ПВЗ.
+const MaxPageLimit = 100
+
+var ErrInvalidCursor = errors.New("Invalid cursor")
+
+// PVZCursor — позиция в списке ПВЗ, упорядоченном по (registration_date, id)
+// по убыванию. Клиентам отдаётся в непрозрачном виде через Encode.
+type PVZCursor struct {
+	RegistrationDate time.Time `json:"d"`
+	ID               string    `json:"id"`
+}
+
+func cursorOf(p PVZ) *PVZCursor {
+	return &PVZCursor{RegistrationDate: p.RegistrationDate, ID: p.ID}
+}
+
+// Encode возвращает курсор в виде base64url-строки.
+func (c *PVZCursor) Encode() string {
+	if c == nil {
+		return ""
+	}
+	return keyset{Time: c.RegistrationDate, ID: c.ID}.encode()
+}
+
+// DecodePVZCursor разбирает строку, полученную из Encode.
+func DecodePVZCursor(s string) (*PVZCursor, error) {
+	k, err := decodeKeyset(s)
+	if err != nil {
+		return nil, err
+	}
+	return &PVZCursor{RegistrationDate: k.Time, ID: k.ID}, nil
+}
+
+// ReceptionCursor — позиция в истории приёмок ПВЗ, упорядоченной
+// по (date_time, id) по убыванию.
+type ReceptionCursor struct {
+	DateTime time.Time
+	ID       string
+}
+
+func receptionCursorOf(r Reception) *ReceptionCursor {
+	return &ReceptionCursor{DateTime: r.DateTime, ID: r.ID}
+}
+
+// Encode возвращает курсор в виде base64url-строки.
+func (c *ReceptionCursor) Encode() string {
+	if c == nil {
+		return ""
+	}
+	return keyset{Time: c.DateTime, ID: c.ID}.encode()
+}
+
+// DecodeReceptionCursor разбирает строку, полученную из Encode.
+func DecodeReceptionCursor(s string) (*ReceptionCursor, error) {
+	k, err := decodeKeyset(s)
+	if err != nil {
+		return nil, err
+	}
+	return &ReceptionCursor{DateTime: k.Time, ID: k.ID}, nil
+}
+
+// keyset — общее представление курсоров по паре (время, id).
+type keyset struct {
+	Time time.Time `json:"d"`
+	ID   string    `json:"id"`
+}
+
+func (k keyset) encode() string {
+	raw, _ := json.Marshal(k)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeKeyset разбирает курсор. id должен быть UUID: иначе подделанный
+// курсор дошёл бы до сравнения в запросе к БД.
+func decodeKeyset(s string) (keyset, error) {
+	var k keyset
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return k, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &k); err != nil || k.ID == "" || k.Time.IsZero() {
+		return k, ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(k.ID); err != nil {
+		return k, ErrInvalidCursor
+	}
+	return k, nil
+}
+
+// afterCursor сообщает, идёт ли p после курсора в порядке убывания.
+func afterCursor(p PVZ, c *PVZCursor) bool {
+	if !p.RegistrationDate.Equal(c.RegistrationDate) {
+		return p.RegistrationDate.Before(c.RegistrationDate)
+	}
+	return p.ID < c.ID
+}
+
+// receptionAfter сообщает, идёт ли r после курсора в порядке убывания.
+func receptionAfter(r Reception, c *ReceptionCursor) bool {
+	if !r.DateTime.Equal(c.DateTime) {
+		return r.DateTime.Before(c.DateTime)
+	}
+	return r.ID < c.ID
+}
+
+func clampLimit(limit int) int {
+	if limit < 1 {
+		return 10
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPVZCursor_EncodeDecode(t *testing.T) {
+	c := &PVZCursor{RegistrationDate: time.Date(2025, 4, 17, 7, 23, 10, 123456000, time.UTC), ID: "b7c47d9c-5e91-4c3b-b9d0-6aa470d0f39c"}
+
+	decoded, err := DecodePVZCursor(c.Encode())
+	require.NoError(t, err)
+	assert.True(t, c.RegistrationDate.Equal(decoded.RegistrationDate))
+	assert.Equal(t, c.ID, decoded.ID)
+
+	var empty *PVZCursor
+	assert.Equal(t, "", empty.Encode())
+
+	// Подделанный курсор: id не UUID, до БД он дойти не должен
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"d":"2025-04-17T07:23:10Z","id":"pvz-1"}`))
+	for _, bad := range []string{"не base64", "e30", "bnVsbA", forged} {
+		_, err := DecodePVZCursor(bad)
+		assert.ErrorIs(t, err, ErrInvalidCursor, bad)
+	}
+}
+
+func TestMemoryStore_GetPVZRecordsPage(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	const total = 7
+	for i := 0; i < total; i++ {
+		pvz, err := store.CreatePVZ(ctx, "Москва")
+		require.NoError(t, err)
+		_, err = store.CreateReception(ctx, pvz.ID)
+		require.NoError(t, err)
+	}
+	start := time.Now().Add(-time.Hour)
+	end := time.Now().Add(time.Hour)
+
+	// Обходим все страницы по 3 записи: 3 + 3 + 1
+	var seen []string
+	var cursor *PVZCursor
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, total, "курсор должен закончиться")
+		records, next, err := store.GetPVZRecordsPage(ctx, &start, &end, false, cursor, 3)
+		require.NoError(t, err)
+		for _, rec := range records {
+			seen = append(seen, rec.PVZ.ID)
+		}
+		if next == nil {
+			break
+		}
+		// курсор проходит через строковое представление, как у клиента
+		cursor, err = DecodePVZCursor(next.Encode())
+		require.NoError(t, err)
+	}
+
+	require.Len(t, seen, total)
+	unique := make(map[string]bool)
+	for _, id := range seen {
+		unique[id] = true
+	}
+	assert.Len(t, unique, total, "страницы не должны пересекаться")
+
+	// limit больше максимума обрезается
+	records, next, err := store.GetPVZRecordsPage(ctx, &start, &end, false, nil, MaxPageLimit*10)
+	require.NoError(t, err)
+	assert.Len(t, records, total)
+	assert.Nil(t, next)
+}
+
+func TestGetPVZRecordsPage_Keyset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	start, end := time.Now().Add(-time.Hour), time.Now()
+	after := &PVZCursor{RegistrationDate: time.Now().Add(-time.Minute), ID: "pvz-9"}
+	d1, d2 := time.Now().Add(-2*time.Minute), time.Now().Add(-3*time.Minute)
+
+	// Запрашиваем limit+1 строку после курсора
+	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city, p\.name, p\.address, p\.active, p\.closed_at FROM pvz p .* AND \(p\.registration_date, p\.id\) < \(\$3, \$4\) ORDER BY p\.registration_date DESC, p\.id DESC LIMIT \$5`).
+		WithArgs(start, end, after.RegistrationDate, after.ID, 2).
+		WillReturnRows(newPVZRows().
+			AddRow("pvz-8", d1, "Москва", "", "", true, nil).
+			AddRow("pvz-7", d2, "Казань", "", "", true, nil))
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status"}).
+			AddRow("rec-8", time.Now(), "pvz-8", "close"))
+	mock.ExpectQuery(`SELECT id, date_time, type, reception_id, pvz_id, attributes, barcode, deleted_at, deleted_by FROM products`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "type", "reception_id", "pvz_id", "attributes", "barcode", "deleted_at", "deleted_by"}))
+
+	records, next, err := NewPostgresPVZRepository(db).GetPVZRecordsPage(context.Background(), &start, &end, false, after, 1)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "pvz-8", records[0].PVZ.ID)
+	require.NotNil(t, next)
+	assert.Equal(t, "pvz-8", next.ID)
+	assert.True(t, d1.Equal(next.RegistrationDate))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
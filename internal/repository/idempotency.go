@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+// IdempotencyLockTimeout — сколько ключ может оставаться занятым
+// незавершённым запросом. Дольше так бывает, только если реплика упала
+// посреди запроса; тогда ключ освобождается для повтора.
+const IdempotencyLockTimeout = time.Minute
+
+// IdempotencyRecord — запрос, выполненный с ключом идемпотентности, и его ответ.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	// StatusCode — HTTP-статус или код gRPC ответа.
+	StatusCode  int
+	ContentType string
+	Response    []byte
+	CreatedAt   time.Time
+	// CompletedAt пуст, пока первый запрос ещё выполняется.
+	CompletedAt *time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed сообщает, сохранён ли уже ответ на запрос.
+func (r *IdempotencyRecord) Completed() bool {
+	return r.CompletedAt != nil
+}
+
+// HashRequest — отпечаток запроса, по которому повтор с тем же ключом
+// отличается от другого запроса: метод или маршрут и тело.
+func HashRequest(route string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(route))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// PostgresIdempotencyRepository хранит ключи идемпотентности в PostgreSQL.
+type PostgresIdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresIdempotencyRepository(db *sql.DB) *PostgresIdempotencyRepository {
+	return &PostgresIdempotencyRepository{db: db}
+}
+
+// ReserveIdempotencyKey занимает ключ под новый запрос и возвращает nil.
+// Если ключ уже занят, возвращает запись о запросе, который его занял.
+func (r *PostgresIdempotencyRepository) ReserveIdempotencyKey(ctx context.Context, scope, key, requestHash string, ttl time.Duration) (*IdempotencyRecord, error) {
+	now := time.Now()
+	// Заодно чистим истёкшие ключи и брошенные упавшими репликами
+	if _, err := r.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE expires_at < $1 OR (completed_at IS NULL AND created_at < $2)",
+		now, now.Add(-IdempotencyLockTimeout)); err != nil {
+		return nil, err
+	}
+	res, err := r.db.ExecContext(ctx, `
+        INSERT INTO idempotency_keys (scope, key, request_hash, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (scope, key) DO NOTHING`,
+		scope, key, requestHash, now, now.Add(ttl))
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil, nil
+	}
+
+	rec := IdempotencyRecord{Scope: scope, Key: key}
+	var completedAt sql.NullTime
+	err = r.db.QueryRowContext(ctx, `
+        SELECT request_hash, status_code, content_type, response, created_at, completed_at, expires_at
+        FROM idempotency_keys
+        WHERE scope = $1 AND key = $2`, scope, key).
+		Scan(&rec.RequestHash, &rec.StatusCode, &rec.ContentType, &rec.Response,
+			&rec.CreatedAt, &completedAt, &rec.ExpiresAt)
+	if err == sql.ErrNoRows {
+		// Ключ освободили между вставкой и чтением — считаем его занятым,
+		// клиент повторит запрос
+		rec.RequestHash = requestHash
+		return &rec, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		rec.CompletedAt = &completedAt.Time
+	}
+	return &rec, nil
+}
+
+// CompleteIdempotencyKey сохраняет ответ на запрос, занявший ключ.
+func (r *PostgresIdempotencyRepository) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, contentType string, response []byte) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE idempotency_keys
+        SET status_code = $3, content_type = $4, response = $5, completed_at = NOW()
+        WHERE scope = $1 AND key = $2 AND completed_at IS NULL`,
+		scope, key, statusCode, contentType, response)
+	return err
+}
+
+// ReleaseIdempotencyKey освобождает ключ, не сохраняя ответа: запрос
+// завершился сбоем сервера, и повтор должен выполниться заново.
+func (r *PostgresIdempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	_, err := r.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND completed_at IS NULL",
+		scope, key)
+	return err
+}
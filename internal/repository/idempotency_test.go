@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReserveIdempotencyKey_Reserved(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresIdempotencyRepository(db)
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE expires_at < \$1`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO idempotency_keys .* ON CONFLICT \(scope, key\) DO NOTHING`).
+		WithArgs("staff", "k-1", "hash-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	prev, err := repo.ReserveIdempotencyKey(context.Background(), "staff", "k-1", "hash-1", time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, prev)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReserveIdempotencyKey_Taken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresIdempotencyRepository(db)
+	now := time.Now()
+	mock.ExpectExec(`DELETE FROM idempotency_keys`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO idempotency_keys`).
+		WithArgs("staff", "k-1", "hash-2", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT request_hash, status_code, content_type, response, created_at, completed_at, expires_at`).
+		WithArgs("staff", "k-1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_hash", "status_code", "content_type", "response", "created_at", "completed_at", "expires_at"}).
+			AddRow("hash-1", 201, "application/json", []byte(`{"id":"1"}`), now, now, now.Add(time.Hour)))
+
+	prev, err := repo.ReserveIdempotencyKey(context.Background(), "staff", "k-1", "hash-2", time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, prev)
+	assert.Equal(t, "hash-1", prev.RequestHash)
+	assert.True(t, prev.Completed())
+	assert.Equal(t, 201, prev.StatusCode)
+	assert.Equal(t, `{"id":"1"}`, string(prev.Response))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteAndReleaseIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresIdempotencyRepository(db)
+	mock.ExpectExec(`UPDATE idempotency_keys\s+SET status_code = \$3, content_type = \$4, response = \$5, completed_at = NOW\(\)`).
+		WithArgs("staff", "k-1", 201, "application/json", []byte(`{}`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE scope = \$1 AND key = \$2 AND completed_at IS NULL`).
+		WithArgs("staff", "k-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.CompleteIdempotencyKey(context.Background(), "staff", "k-1", 201, "application/json", []byte(`{}`)))
+	require.NoError(t, repo.ReleaseIdempotencyKey(context.Background(), "staff", "k-2"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"sync"
+)
+
+// LeaderLock не даёт нескольким репликам сервиса одновременно выполнять
+// одну и ту же фоновую задачу.
+type LeaderLock interface {
+	// RunExclusive выполняет fn, если блокировку name удалось взять,
+	// и сообщает, выполнялась ли fn. Чужая блокировка — не ошибка.
+	RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error)
+}
+
+// PostgresLeaderLock берёт сессионную advisory-блокировку PostgreSQL на
+// отдельном соединении. Если реплика упадёт, блокировка снимется вместе
+// с её соединением.
+type PostgresLeaderLock struct {
+	db *sql.DB
+}
+
+func NewPostgresLeaderLock(db *sql.DB) *PostgresLeaderLock {
+	return &PostgresLeaderLock{db: db}
+}
+
+// lockKey переводит имя задачи в ключ pg_try_advisory_lock.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+func (l *PostgresLeaderLock) RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	key := lockKey(name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+	// Снимаем блокировку и при отменённом ctx, иначе она останется
+	// на соединении, вернувшемся в пул
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+
+	return true, fn(ctx)
+}
+
+// memoryLeaderLock — блокировки внутри одного процесса для STORAGE=memory.
+type memoryLeaderLock struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *memoryLeaderLock) RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	l.mu.Lock()
+	if l.held[name] {
+		l.mu.Unlock()
+		return false, nil
+	}
+	l.held[name] = true
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		delete(l.held, name)
+		l.mu.Unlock()
+	}()
+	return true, fn(ctx)
+}
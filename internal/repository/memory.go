@@ -0,0 +1,1352 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"avito-pvz-service/internal/audit"
+	"avito-pvz-service/internal/events"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MemoryStore — хранилище в памяти процесса. Реализует все интерфейсы
+// репозиториев и соблюдает те же бизнес-правила, что и PostgreSQL-версия:
+// не больше одной открытой приёмки на ПВЗ и удаление товаров по LIFO.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	pvz        map[string]PVZ
+	receptions []Reception // в порядке создания
+	products   []Product   // в порядке добавления
+	users      map[string]User
+	refresh    map[string]*memoryRefreshToken // по хэшу
+	revoked    map[string]time.Time           // jti -> срок действия
+	staff      []StaffAssignment
+	cities     map[string]City
+	types      map[string]ProductType
+	deletions  []ProductDeletion
+	outbox     []memoryOutboxEntry
+	webhooks   []WebhookSubscription
+	deliveries []WebhookDelivery
+	auditLog   []AuditEntry
+	idem       map[[2]string]*IdempotencyRecord // scope, ключ -> запрос
+	events     *events.Bus
+}
+
+type memoryOutboxEntry struct {
+	event      WebhookEvent
+	dispatched bool
+}
+
+type memoryRefreshToken struct {
+	RefreshToken
+	used, revoked bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		pvz:     make(map[string]PVZ),
+		users:   make(map[string]User),
+		refresh: make(map[string]*memoryRefreshToken),
+		revoked: make(map[string]time.Time),
+		idem:    make(map[[2]string]*IdempotencyRecord),
+		cities:  defaultCities(),
+		types:   defaultProductTypes(),
+	}
+}
+
+// defaultCities совпадает с начальным наполнением справочника в миграции 0008.
+func defaultCities() map[string]City {
+	now := time.Now()
+	cities := make(map[string]City)
+	for _, c := range []City{
+		{Name: "Москва", RegionCode: "77"},
+		{Name: "Санкт-Петербург", RegionCode: "78"},
+		{Name: "Казань", RegionCode: "16"},
+	} {
+		c.Timezone, c.Enabled, c.CreatedAt = "Europe/Moscow", true, now
+		cities[c.Name] = c
+	}
+	return cities
+}
+
+// defaultProductTypes совпадает с начальным наполнением справочника в миграции 0009.
+func defaultProductTypes() map[string]ProductType {
+	now := time.Now()
+	types := make(map[string]ProductType)
+	for _, t := range []ProductType{
+		{Code: "электроника", Names: map[string]string{"ru": "Электроника", "en": "Electronics"}, Fragile: true},
+		{Code: "одежда", Names: map[string]string{"ru": "Одежда", "en": "Clothing"}},
+		{Code: "обувь", Names: map[string]string{"ru": "Обувь", "en": "Shoes"}},
+	} {
+		t.Attributes, t.CreatedAt = []AttributeSpec{}, now
+		types[t.Code] = t
+	}
+	return types
+}
+
+func (s *MemoryStore) CreatePVZ(ctx context.Context, city string) (*PVZ, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.cities[city].Enabled {
+		return nil, ErrCityNotAllowed
+	}
+
+	p := PVZ{
+		ID:               uuid.New().String(),
+		RegistrationDate: time.Now(),
+		City:             city,
+		Active:           true,
+	}
+	s.pvz[p.ID] = p
+	s.audit(ctx, auditChange{action: AuditPVZCreate, entityType: EntityPVZ, entityId: p.ID, after: p})
+	return &p, nil
+}
+
+func (s *MemoryStore) GetPVZRecords(_ context.Context, startDate, endDate *time.Time, includeDeleted bool, page, limit int) ([]PVZRecord, error) {
+	if startDate == nil || endDate == nil {
+		return nil, errors.New("startDate and endDate parameters are required")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pvzs, byPVZ := s.pvzWithReceptions(*startDate, *endDate)
+
+	offset := (page - 1) * limit
+	if offset >= len(pvzs) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(pvzs) {
+		end = len(pvzs)
+	}
+	return s.buildRecords(pvzs[offset:end], byPVZ, includeDeleted), nil
+}
+
+func (s *MemoryStore) GetPVZRecordsPage(_ context.Context, startDate, endDate *time.Time, includeDeleted bool, after *PVZCursor, limit int) ([]PVZRecord, *PVZCursor, error) {
+	if startDate == nil || endDate == nil {
+		return nil, nil, errors.New("startDate and endDate parameters are required")
+	}
+	limit = clampLimit(limit)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pvzs, byPVZ := s.pvzWithReceptions(*startDate, *endDate)
+	if after != nil {
+		i := sort.Search(len(pvzs), func(i int) bool { return afterCursor(pvzs[i], after) })
+		pvzs = pvzs[i:]
+	}
+
+	var next *PVZCursor
+	if len(pvzs) > limit {
+		pvzs = pvzs[:limit]
+		next = cursorOf(pvzs[limit-1])
+	}
+	return s.buildRecords(pvzs, byPVZ, includeDeleted), next, nil
+}
+
+// pvzWithReceptions возвращает ПВЗ, у которых есть приёмки в диапазоне,
+// в порядке (registration_date, id) по убыванию, и сами приёмки по ПВЗ.
+// Вызывается под блокировкой.
+func (s *MemoryStore) pvzWithReceptions(startDate, endDate time.Time) ([]PVZ, map[string][]Reception) {
+	byPVZ := make(map[string][]Reception)
+	for _, rec := range s.receptions {
+		if rec.DateTime.Before(startDate) || rec.DateTime.After(endDate) || rec.Status == StatusCancelled {
+			continue
+		}
+		byPVZ[rec.PVZId] = append(byPVZ[rec.PVZId], rec)
+	}
+
+	pvzs := make([]PVZ, 0, len(byPVZ))
+	for id := range byPVZ {
+		pvzs = append(pvzs, s.pvz[id])
+	}
+	sort.Slice(pvzs, func(i, j int) bool {
+		if !pvzs[i].RegistrationDate.Equal(pvzs[j].RegistrationDate) {
+			return pvzs[i].RegistrationDate.After(pvzs[j].RegistrationDate)
+		}
+		return pvzs[i].ID > pvzs[j].ID
+	})
+	return pvzs, byPVZ
+}
+
+// buildRecords собирает ответ для страницы ПВЗ. Вызывается под блокировкой.
+func (s *MemoryStore) buildRecords(pvzs []PVZ, byPVZ map[string][]Reception, includeDeleted bool) []PVZRecord {
+	var records []PVZRecord
+	for _, p := range pvzs {
+		recs := byPVZ[p.ID]
+		sort.SliceStable(recs, func(i, j int) bool {
+			return recs[i].DateTime.After(recs[j].DateTime)
+		})
+		var receptions []ReceptionRecord
+		for _, rec := range recs {
+			receptions = append(receptions, s.receptionRecord(rec, includeDeleted))
+		}
+		records = append(records, PVZRecord{
+			PVZ:        p,
+			Receptions: receptions,
+		})
+	}
+	return records
+}
+
+// receptionRecord дополняет приёмку её товарами; удалённые — только при
+// includeDeleted. Вызывается под блокировкой.
+func (s *MemoryStore) receptionRecord(rec Reception, includeDeleted bool) ReceptionRecord {
+	var products []Product
+	for _, prod := range s.products {
+		if prod.ReceptionId == rec.ID && (includeDeleted || prod.DeletedAt == nil) {
+			products = append(products, prod)
+		}
+	}
+	return ReceptionRecord{Reception: rec, Products: products}
+}
+
+func (s *MemoryStore) GetAllPVZ(_ context.Context) ([]PVZ, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]PVZ, 0, len(s.pvz))
+	for _, p := range s.pvz {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].RegistrationDate.Before(result[j].RegistrationDate)
+	})
+	return result, nil
+}
+
+func (s *MemoryStore) GetPVZ(_ context.Context, id string) (*PVZ, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.pvz[id]
+	if !ok {
+		return nil, ErrPVZNotFound
+	}
+	return &p, nil
+}
+
+func (s *MemoryStore) UpdatePVZ(ctx context.Context, id string, upd PVZUpdate) (*PVZ, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pvz[id]
+	if !ok {
+		return nil, ErrPVZNotFound
+	}
+	before := p
+	if upd.Name != nil {
+		p.Name = *upd.Name
+	}
+	if upd.Address != nil {
+		p.Address = *upd.Address
+	}
+	if upd.Active != nil {
+		p.Active = *upd.Active
+		switch {
+		case p.Active:
+			p.ClosedAt = nil
+		case p.ClosedAt == nil:
+			now := time.Now()
+			p.ClosedAt = &now
+		}
+	}
+	s.pvz[id] = p
+	s.audit(ctx, auditChange{action: AuditPVZUpdate, entityType: EntityPVZ, entityId: id, before: before, after: p})
+	return &p, nil
+}
+
+func (s *MemoryStore) DeletePVZ(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, ok := s.pvz[id]
+	if !ok {
+		return ErrPVZNotFound
+	}
+	if i := s.lastReception(id); i >= 0 && IsOpenStatus(s.receptions[i].Status) {
+		return ErrPVZHasOpenReception
+	}
+
+	// Каскад как в PostgreSQL: приёмки, их товары и назначения сотрудников
+	receptions := s.receptions[:0]
+	for _, r := range s.receptions {
+		if r.PVZId != id {
+			receptions = append(receptions, r)
+		}
+	}
+	s.receptions = receptions
+	products := s.products[:0]
+	for _, p := range s.products {
+		if p.PVZId != id {
+			products = append(products, p)
+		}
+	}
+	s.products = products
+	staff := s.staff[:0]
+	for _, a := range s.staff {
+		if a.PVZId != id {
+			staff = append(staff, a)
+		}
+	}
+	s.staff = staff
+	delete(s.pvz, id)
+	s.audit(ctx, auditChange{action: AuditPVZDelete, entityType: EntityPVZ, entityId: id, before: before})
+	return nil
+}
+
+// lastReception возвращает индекс последней приёмки ПВЗ или -1.
+// Вызывается под блокировкой.
+func (s *MemoryStore) lastReception(pvzId string) int {
+	for i := len(s.receptions) - 1; i >= 0; i-- {
+		if s.receptions[i].PVZId == pvzId {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryStore) CreateReception(ctx context.Context, pvzId string) (*Reception, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pvz[pvzId]
+	if !ok {
+		return nil, ErrPVZNotFound
+	}
+	if !p.Active {
+		return nil, ErrPVZInactive
+	}
+	if i := s.lastReception(pvzId); i >= 0 && IsOpenStatus(s.receptions[i].Status) {
+		return nil, ErrReceptionInProgress
+	}
+
+	rec := Reception{
+		ID:       uuid.New().String(),
+		DateTime: time.Now(),
+		PVZId:    pvzId,
+		Status:   StatusInProgress,
+	}
+	s.receptions = append(s.receptions, rec)
+	s.emit(events.Event{
+		Type:        events.ReceptionOpened,
+		Time:        rec.DateTime,
+		PVZId:       pvzId,
+		City:        s.pvz[pvzId].City,
+		ReceptionId: rec.ID,
+	})
+	s.audit(ctx, auditChange{action: AuditReceptionCreate, entityType: EntityReception, entityId: rec.ID, after: rec})
+	return &rec, nil
+}
+
+func (s *MemoryStore) CloseReception(ctx context.Context, pvzId string) (*Reception, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.lastReception(pvzId)
+	if i < 0 {
+		return nil, ErrNoReceptionToClose
+	}
+	if err := checkTransition(s.receptions[i].Status, StatusClosed); err != nil {
+		return nil, err
+	}
+	return s.closeReception(ctx, i, CloseReasonManual), nil
+}
+
+// closeReception закрывает i-ю приёмку. Вызывается под блокировкой.
+func (s *MemoryStore) closeReception(ctx context.Context, i int, reason string) *Reception {
+	before := s.receptions[i]
+	s.receptions[i].Status = StatusClosed
+	s.receptions[i].CloseReason = reason
+	rec := s.receptions[i]
+	s.emit(events.Event{
+		Type:        events.ReceptionClosed,
+		PVZId:       rec.PVZId,
+		City:        s.pvz[rec.PVZId].City,
+		ReceptionId: rec.ID,
+	})
+	action := AuditReceptionClose
+	if reason == CloseReasonAutoTimeout {
+		action = AuditReceptionAutoClose
+	}
+	s.audit(ctx, auditChange{action: action, entityType: EntityReception, entityId: rec.ID, before: before, after: rec})
+	return &rec
+}
+
+func (s *MemoryStore) CloseStaleReceptions(ctx context.Context, idleBefore time.Time) ([]Reception, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lastActivity := make(map[string]time.Time)
+	for _, p := range s.products {
+		// Удалённые товары активностью не считаются
+		if p.DeletedAt == nil && p.DateTime.After(lastActivity[p.ReceptionId]) {
+			lastActivity[p.ReceptionId] = p.DateTime
+		}
+	}
+
+	var closed []Reception
+	for i, rec := range s.receptions {
+		if rec.Status != StatusInProgress {
+			continue
+		}
+		last, ok := lastActivity[rec.ID]
+		if !ok {
+			last = rec.DateTime
+		}
+		if last.Before(idleBefore) {
+			closed = append(closed, *s.closeReception(ctx, i, CloseReasonAutoTimeout))
+		}
+	}
+	return closed, nil
+}
+
+func (s *MemoryStore) GetReception(_ context.Context, id string) (*ReceptionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rec := range s.receptions {
+		if rec.ID == id {
+			record := s.receptionRecord(rec, false)
+			return &record, nil
+		}
+	}
+	return nil, ErrReceptionNotFound
+}
+
+func (s *MemoryStore) CurrentReception(_ context.Context, pvzId string) (*ReceptionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.lastReception(pvzId)
+	if i < 0 || !IsOpenStatus(s.receptions[i].Status) {
+		return nil, ErrNoActiveReception
+	}
+	record := s.receptionRecord(s.receptions[i], false)
+	return &record, nil
+}
+
+func (s *MemoryStore) ListReceptions(_ context.Context, pvzId string, f ReceptionFilter, page, limit int) ([]Reception, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := s.filterReceptions(pvzId, f)
+	offset := (page - 1) * limit
+	if offset >= len(matched) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[offset:end], nil
+}
+
+func (s *MemoryStore) ListReceptionsPage(_ context.Context, pvzId string, f ReceptionFilter, after *ReceptionCursor, limit int) ([]Reception, *ReceptionCursor, error) {
+	limit = clampLimit(limit)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := s.filterReceptions(pvzId, f)
+	if after != nil {
+		i := sort.Search(len(matched), func(i int) bool { return receptionAfter(matched[i], after) })
+		matched = matched[i:]
+	}
+
+	var next *ReceptionCursor
+	if len(matched) > limit {
+		matched = matched[:limit]
+		next = receptionCursorOf(matched[limit-1])
+	}
+	return matched, next, nil
+}
+
+// filterReceptions возвращает приёмки ПВЗ по фильтру в порядке (date_time, id)
+// по убыванию. Вызывается под блокировкой.
+func (s *MemoryStore) filterReceptions(pvzId string, f ReceptionFilter) []Reception {
+	var matched []Reception
+	for _, rec := range s.receptions {
+		if rec.PVZId != pvzId ||
+			(f.Status != "" && rec.Status != f.Status) ||
+			(f.StartDate != nil && rec.DateTime.Before(*f.StartDate)) ||
+			(f.EndDate != nil && rec.DateTime.After(*f.EndDate)) {
+			continue
+		}
+		matched = append(matched, rec)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].DateTime.Equal(matched[j].DateTime) {
+			return matched[i].DateTime.After(matched[j].DateTime)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+	return matched
+}
+
+func (s *MemoryStore) ReopenReception(ctx context.Context, id string) (*Reception, error) {
+	return s.transition(ctx, id, StatusReopened)
+}
+
+func (s *MemoryStore) CancelReception(ctx context.Context, id string) (*Reception, error) {
+	return s.transition(ctx, id, StatusCancelled)
+}
+
+func (s *MemoryStore) transition(ctx context.Context, id, to string) (*Reception, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := -1
+	for j, r := range s.receptions {
+		if r.ID == id {
+			i = j
+		}
+	}
+	if i < 0 {
+		return nil, ErrReceptionNotFound
+	}
+	rec := s.receptions[i]
+	if err := checkTransition(rec.Status, to); err != nil {
+		return nil, err
+	}
+	if to == StatusReopened {
+		if !s.pvz[rec.PVZId].Active {
+			return nil, ErrPVZInactive
+		}
+		if s.lastReception(rec.PVZId) != i {
+			return nil, ErrReceptionNotLatest
+		}
+		for _, p := range s.products {
+			if p.ReceptionId == id && p.Barcode != "" && p.DeletedAt == nil && s.barcodeTaken(p.Barcode) {
+				return nil, ErrDuplicateBarcode
+			}
+		}
+	}
+
+	before := rec
+	s.receptions[i].Status = to
+	rec.Status = to
+	s.emit(events.Event{
+		Type:        transitionEvents[to],
+		PVZId:       rec.PVZId,
+		City:        s.pvz[rec.PVZId].City,
+		ReceptionId: id,
+	})
+	s.audit(ctx, auditChange{action: transitionAudit[to], entityType: EntityReception, entityId: id, before: before, after: rec})
+	return &rec, nil
+}
+
+// barcodeTaken сообщает, есть ли штрихкод в открытой приёмке.
+// Вызывается под блокировкой.
+func (s *MemoryStore) barcodeTaken(barcode string) bool {
+	for _, p := range s.products {
+		if p.Barcode == barcode && p.DeletedAt == nil && s.receptionOpen(p.ReceptionId) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) AddProduct(ctx context.Context, pvzId string, in NewProduct) (*Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	productType, attrs := in.Type, in.Attributes
+	pt, ok := s.types[productType]
+	if !ok {
+		return nil, ErrInvalidProductType
+	}
+	if err := pt.ValidateAttributes(attrs); err != nil {
+		return nil, err
+	}
+
+	i := s.lastReception(pvzId)
+	if i < 0 || !IsOpenStatus(s.receptions[i].Status) {
+		return nil, ErrNoActiveReception
+	}
+	if in.Barcode != "" && s.barcodeTaken(in.Barcode) {
+		return nil, ErrDuplicateBarcode
+	}
+
+	prod := Product{
+		ID:          uuid.New().String(),
+		DateTime:    time.Now(),
+		Type:        productType,
+		ReceptionId: s.receptions[i].ID,
+		PVZId:       pvzId,
+		Attributes:  attrs,
+		Barcode:     in.Barcode,
+	}
+	s.products = append(s.products, prod)
+	s.emit(events.Event{
+		Type:        events.ProductAdded,
+		Time:        prod.DateTime,
+		PVZId:       pvzId,
+		City:        s.pvz[pvzId].City,
+		ReceptionId: prod.ReceptionId,
+		ProductId:   prod.ID,
+		ProductType: productType,
+	})
+	s.audit(ctx, auditChange{action: AuditProductAdd, entityType: EntityProduct, entityId: prod.ID, after: prod})
+	return &prod, nil
+}
+
+func (s *MemoryStore) AddProducts(ctx context.Context, pvzId string, items []NewProduct, mode BatchMode) ([]ProductResult, error) {
+	if !validBatchMode(mode) {
+		return nil, ErrInvalidBatchMode
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.lastReception(pvzId)
+	if i < 0 || !IsOpenStatus(s.receptions[i].Status) {
+		return nil, ErrNoActiveReception
+	}
+	receptionId := s.receptions[i].ID
+
+	taken := make(map[string]bool)
+	for _, p := range s.products {
+		if p.Barcode != "" && p.DeletedAt == nil && s.receptionOpen(p.ReceptionId) {
+			taken[p.Barcode] = true
+		}
+	}
+	results, inserted, err := checkBatch(items, mode, func(code string) (ProductType, error) {
+		pt, ok := s.types[code]
+		if !ok {
+			return ProductType{}, ErrInvalidProductType
+		}
+		return pt, nil
+	}, taken)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for n, j := range inserted {
+		prod := Product{
+			ID:          uuid.New().String(),
+			DateTime:    now.Add(time.Duration(n) * time.Microsecond),
+			Type:        items[j].Type,
+			ReceptionId: receptionId,
+			PVZId:       pvzId,
+			Attributes:  items[j].Attributes,
+			Barcode:     items[j].Barcode,
+		}
+		s.products = append(s.products, prod)
+		results[j].Product = &prod
+		s.emit(events.Event{
+			Type:        events.ProductAdded,
+			Time:        prod.DateTime,
+			PVZId:       pvzId,
+			City:        s.pvz[pvzId].City,
+			ReceptionId: receptionId,
+			ProductId:   prod.ID,
+			ProductType: prod.Type,
+		})
+		s.audit(ctx, auditChange{action: AuditProductAdd, entityType: EntityProduct, entityId: prod.ID, after: prod})
+	}
+	return results, nil
+}
+
+// receptionOpen сообщает, открыта ли приёмка. Вызывается под блокировкой.
+func (s *MemoryStore) receptionOpen(id string) bool {
+	for _, r := range s.receptions {
+		if r.ID == id {
+			return IsOpenStatus(r.Status)
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) FindProductByBarcode(_ context.Context, barcode string) (*ProductLocation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := -1
+	for j := len(s.products) - 1; j >= 0; j-- {
+		if s.products[j].Barcode != barcode || s.products[j].DeletedAt != nil {
+			continue
+		}
+		if found < 0 {
+			found = j
+		}
+		if s.receptionOpen(s.products[j].ReceptionId) {
+			found = j
+			break
+		}
+	}
+	if found < 0 {
+		return nil, ErrProductNotFound
+	}
+
+	loc := ProductLocation{Product: s.products[found], PVZ: s.pvz[s.products[found].PVZId]}
+	for _, r := range s.receptions {
+		if r.ID == loc.Product.ReceptionId {
+			loc.Reception = r
+		}
+	}
+	return &loc, nil
+}
+
+func (s *MemoryStore) DeleteLastProduct(ctx context.Context, pvzId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.lastReception(pvzId)
+	if i < 0 {
+		return ErrNoActiveReception
+	}
+	if !IsOpenStatus(s.receptions[i].Status) {
+		return ErrReceptionClosed
+	}
+
+	receptionId := s.receptions[i].ID
+	for j := len(s.products) - 1; j >= 0; j-- {
+		if s.products[j].ReceptionId == receptionId && s.products[j].DeletedAt == nil {
+			before := s.products[j]
+			now := time.Now()
+			s.products[j].DeletedAt = &now
+			s.products[j].DeletedBy = audit.ActorFrom(ctx).Subject
+			deleted := s.products[j]
+			s.emit(events.Event{
+				Type:        events.ProductRemoved,
+				PVZId:       pvzId,
+				City:        s.pvz[pvzId].City,
+				ReceptionId: receptionId,
+				ProductId:   deleted.ID,
+			})
+			s.audit(ctx, auditChange{action: AuditProductDeleteLast, entityType: EntityProduct, entityId: deleted.ID, before: before, after: deleted})
+			return nil
+		}
+	}
+	return ErrNoProducts
+}
+
+func (s *MemoryStore) GetProduct(_ context.Context, id string) (*Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, p := range s.products {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, ErrProductNotFound
+}
+
+func (s *MemoryStore) DeleteProduct(ctx context.Context, productId string, del ProductDeletion) (*ProductDeletion, error) {
+	if !ValidDeletionReason(del.Reason) {
+		return nil, ErrInvalidDeletionReason
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for j, p := range s.products {
+		if p.ID != productId || p.DeletedAt != nil {
+			continue
+		}
+		if !s.receptionOpen(p.ReceptionId) {
+			return nil, ErrReceptionClosed
+		}
+
+		del.ID = uuid.New().String()
+		del.ProductID = p.ID
+		del.ReceptionID = p.ReceptionId
+		del.PVZId = p.PVZId
+		del.Type = p.Type
+		del.Barcode = p.Barcode
+		del.DeletedAt = time.Now()
+		deletedAt := del.DeletedAt
+		s.products[j].DeletedAt = &deletedAt
+		s.products[j].DeletedBy = del.DeletedBy
+		s.deletions = append(s.deletions, del)
+		s.emit(events.Event{
+			Type:        events.ProductRemoved,
+			PVZId:       p.PVZId,
+			City:        s.pvz[p.PVZId].City,
+			ReceptionId: p.ReceptionId,
+			ProductId:   p.ID,
+		})
+		s.audit(ctx, auditChange{action: AuditProductDelete, entityType: EntityProduct, entityId: p.ID, before: p, after: del})
+		return &del, nil
+	}
+	return nil, ErrProductNotFound
+}
+
+func (s *MemoryStore) ListProductDeletions(_ context.Context, pvzId string) ([]ProductDeletion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []ProductDeletion{}
+	for j := len(s.deletions) - 1; j >= 0; j-- {
+		if s.deletions[j].PVZId == pvzId {
+			result = append(result, s.deletions[j])
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) CreateUser(_ context.Context, email, password, role string) (*User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[email]; exists {
+		return nil, ErrUserExists
+	}
+	u := User{
+		ID:        uuid.New().String(),
+		Email:     email,
+		Password:  string(hashedPassword),
+		Role:      role,
+		CreatedAt: time.Now(),
+	}
+	s.users[email] = u
+	return &u, nil
+}
+
+func (s *MemoryStore) GetUserByEmail(_ context.Context, email string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[email]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &u, nil
+}
+
+func (s *MemoryStore) CreateRefreshToken(_ context.Context, t RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refresh[t.Hash] = &memoryRefreshToken{RefreshToken: t}
+	return nil
+}
+
+func (s *MemoryStore) RotateRefreshToken(_ context.Context, hash string, next RefreshToken) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.refresh[hash]
+	if !ok || old.revoked {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if old.used {
+		s.revokeFamily(old.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(old.ExpiresAt) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	old.used = true
+	next.FamilyID, next.Subject, next.Role = old.FamilyID, old.Subject, old.Role
+	s.refresh[next.Hash] = &memoryRefreshToken{RefreshToken: next}
+	return &next, nil
+}
+
+func (s *MemoryStore) RevokeRefreshFamily(_ context.Context, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.refresh[hash]
+	if !ok || t.revoked {
+		return ErrRefreshTokenInvalid
+	}
+	s.revokeFamily(t.FamilyID)
+	return nil
+}
+
+// revokeFamily вызывается под блокировкой.
+func (s *MemoryStore) revokeFamily(familyID string) {
+	for _, t := range s.refresh {
+		if t.FamilyID == familyID {
+			t.revoked = true
+		}
+	}
+}
+
+func (s *MemoryStore) RevokeAccessToken(_ context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range s.revoked {
+		if exp.Before(now) {
+			delete(s.revoked, id)
+		}
+	}
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryStore) IsAccessTokenRevoked(_ context.Context, jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.revoked[jti]
+	return ok, nil
+}
+
+func (s *MemoryStore) AssignStaff(ctx context.Context, subject, pvzId, assignedBy string) (*StaffAssignment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.pvz[pvzId]; !ok {
+		return nil, ErrPVZNotFound
+	}
+	var a StaffAssignment
+	if i := s.staffIndex(subject, pvzId); i >= 0 {
+		a = s.staff[i]
+	} else {
+		a = StaffAssignment{Subject: subject, PVZId: pvzId, AssignedBy: assignedBy, AssignedAt: time.Now()}
+		s.staff = append(s.staff, a)
+	}
+	s.audit(ctx, auditChange{action: AuditStaffAssign, entityType: EntityStaffAssignment, entityId: staffEntityID(subject, pvzId), after: a})
+	return &a, nil
+}
+
+func (s *MemoryStore) UnassignStaff(ctx context.Context, subject, pvzId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.staffIndex(subject, pvzId)
+	if i < 0 {
+		return ErrAssignmentNotFound
+	}
+	before := s.staff[i]
+	s.staff = append(s.staff[:i], s.staff[i+1:]...)
+	s.audit(ctx, auditChange{action: AuditStaffUnassign, entityType: EntityStaffAssignment, entityId: staffEntityID(subject, pvzId), before: before})
+	return nil
+}
+
+func (s *MemoryStore) ListStaff(_ context.Context, pvzId string) ([]StaffAssignment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []StaffAssignment{}
+	for _, a := range s.staff {
+		if a.PVZId == pvzId {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) IsStaffAssigned(_ context.Context, subject, pvzId string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.staffIndex(subject, pvzId) >= 0, nil
+}
+
+// staffIndex возвращает индекс назначения или -1. Вызывается под блокировкой.
+func (s *MemoryStore) staffIndex(subject, pvzId string) int {
+	for i, a := range s.staff {
+		if a.Subject == subject && a.PVZId == pvzId {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryStore) ListCities(_ context.Context, includeDisabled bool) ([]City, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []City{}
+	for _, c := range s.cities {
+		if c.Enabled || includeDisabled {
+			result = append(result, c)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (s *MemoryStore) CreateCity(ctx context.Context, city City) (*City, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.cities[city.Name]; ok {
+		return nil, ErrCityExists
+	}
+	city.CreatedAt = time.Now()
+	s.cities[city.Name] = city
+	s.audit(ctx, auditChange{action: AuditCityCreate, entityType: EntityCity, entityId: city.Name, after: city})
+	return &city, nil
+}
+
+func (s *MemoryStore) UpdateCity(ctx context.Context, name string, upd CityUpdate) (*City, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cities[name]
+	if !ok {
+		return nil, ErrCityNotFound
+	}
+	before := c
+	if upd.RegionCode != nil {
+		c.RegionCode = *upd.RegionCode
+	}
+	if upd.Timezone != nil {
+		c.Timezone = *upd.Timezone
+	}
+	if upd.Enabled != nil {
+		c.Enabled = *upd.Enabled
+	}
+	s.cities[name] = c
+	s.audit(ctx, auditChange{action: AuditCityUpdate, entityType: EntityCity, entityId: name, before: before, after: c})
+	return &c, nil
+}
+
+func (s *MemoryStore) DeleteCity(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, ok := s.cities[name]
+	if !ok {
+		return ErrCityNotFound
+	}
+	for _, p := range s.pvz {
+		if p.City == name {
+			return ErrCityInUse
+		}
+	}
+	delete(s.cities, name)
+	s.audit(ctx, auditChange{action: AuditCityDelete, entityType: EntityCity, entityId: name, before: before})
+	return nil
+}
+
+func (s *MemoryStore) ListProductTypes(_ context.Context) ([]ProductType, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]ProductType, 0, len(s.types))
+	for _, t := range s.types {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	return result, nil
+}
+
+func (s *MemoryStore) CreateProductType(ctx context.Context, t ProductType) (*ProductType, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.types[t.Code]; ok {
+		return nil, ErrProductTypeExists
+	}
+	t = withCatalogDefaults(t)
+	t.CreatedAt = time.Now()
+	s.types[t.Code] = t
+	s.audit(ctx, auditChange{action: AuditProductTypeCreate, entityType: EntityProductType, entityId: t.Code, after: t})
+	return &t, nil
+}
+
+func (s *MemoryStore) UpdateProductType(ctx context.Context, t ProductType) (*ProductType, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.types[t.Code]
+	if !ok {
+		return nil, ErrProductTypeNotFound
+	}
+	t = withCatalogDefaults(t)
+	t.CreatedAt = old.CreatedAt
+	s.types[t.Code] = t
+	s.audit(ctx, auditChange{action: AuditProductTypeUpdate, entityType: EntityProductType, entityId: t.Code, before: old, after: t})
+	return &t, nil
+}
+
+func (s *MemoryStore) DeleteProductType(ctx context.Context, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, ok := s.types[code]
+	if !ok {
+		return ErrProductTypeNotFound
+	}
+	for _, p := range s.products {
+		if p.Type == code {
+			return ErrProductTypeInUse
+		}
+	}
+	delete(s.types, code)
+	s.audit(ctx, auditChange{action: AuditProductTypeDelete, entityType: EntityProductType, entityId: code, before: before})
+	return nil
+}
+
+// emit записывает событие в outbox и публикует его в шину — под той же
+// блокировкой, что и само изменение. Вызывается под блокировкой.
+func (s *MemoryStore) emit(e events.Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	ev := webhookEventOf(e)
+	ev.ID = int64(len(s.outbox) + 1)
+	s.outbox = append(s.outbox, memoryOutboxEntry{event: ev})
+	s.events.Publish(e)
+}
+
+func (s *MemoryStore) CreateWebhook(ctx context.Context, sub WebhookSubscription) (*WebhookSubscription, error) {
+	if err := prepareWebhook(&sub); err != nil {
+		return nil, err
+	}
+	sub.CreatedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhooks = append(s.webhooks, sub)
+	s.audit(ctx, auditChange{action: AuditWebhookCreate, entityType: EntityWebhook, entityId: sub.ID, after: withoutSecret(sub)})
+	return &sub, nil
+}
+
+func (s *MemoryStore) ListWebhooks(_ context.Context) ([]WebhookSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subs := make([]WebhookSubscription, 0, len(s.webhooks))
+	for _, sub := range s.webhooks {
+		sub.Secret = ""
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+func (s *MemoryStore) DeleteWebhook(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, sub := range s.webhooks {
+		if sub.ID != id {
+			continue
+		}
+		s.webhooks = append(s.webhooks[:i], s.webhooks[i+1:]...)
+		kept := s.deliveries[:0]
+		for _, d := range s.deliveries {
+			if d.SubscriptionID != id {
+				kept = append(kept, d)
+			}
+		}
+		s.deliveries = kept
+		s.audit(ctx, auditChange{action: AuditWebhookDelete, entityType: EntityWebhook, entityId: id, before: withoutSecret(sub)})
+		return nil
+	}
+	return ErrWebhookNotFound
+}
+
+// webhook возвращает подписку по id. Вызывается под блокировкой.
+func (s *MemoryStore) webhook(id string) (WebhookSubscription, bool) {
+	for _, sub := range s.webhooks {
+		if sub.ID == id {
+			return sub, true
+		}
+	}
+	return WebhookSubscription{}, false
+}
+
+func (s *MemoryStore) ListDeliveries(_ context.Context, subscriptionId, status string, limit int) ([]WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.webhook(subscriptionId); !ok {
+		return nil, ErrWebhookNotFound
+	}
+	var result []WebhookDelivery
+	for i := len(s.deliveries) - 1; i >= 0 && len(result) < limit; i-- {
+		d := s.deliveries[i]
+		if d.SubscriptionID == subscriptionId && (status == "" || d.Status == status) {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) FanOutOutbox(_ context.Context, limit int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	n := 0
+	for i := range s.outbox {
+		if n == limit {
+			break
+		}
+		if s.outbox[i].dispatched {
+			continue
+		}
+		ev := s.outbox[i].event
+		for _, sub := range s.webhooks {
+			if !subscribed(sub.EventTypes, ev.Type) {
+				continue
+			}
+			next := now
+			s.deliveries = append(s.deliveries, WebhookDelivery{
+				ID:             uuid.New().String(),
+				SubscriptionID: sub.ID,
+				Event:          ev,
+				Status:         DeliveryPending,
+				NextAttemptAt:  &next,
+				CreatedAt:      now,
+			})
+		}
+		s.outbox[i].dispatched = true
+		n++
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) DueDeliveries(_ context.Context, now time.Time, limit int) ([]PendingDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var due []PendingDelivery
+	for _, d := range s.deliveries {
+		if len(due) == limit {
+			break
+		}
+		if d.Status != DeliveryPending || d.NextAttemptAt.After(now) {
+			continue
+		}
+		sub, _ := s.webhook(d.SubscriptionID)
+		due = append(due, PendingDelivery{WebhookDelivery: d, URL: sub.URL, Secret: sub.Secret})
+	}
+	return due, nil
+}
+
+func (s *MemoryStore) MarkDelivered(_ context.Context, id string, at time.Time) error {
+	return s.updateDelivery(id, func(d *WebhookDelivery) {
+		d.Status = DeliveryDelivered
+		d.DeliveredAt = &at
+		d.NextAttemptAt = nil
+		d.LastError = ""
+	})
+}
+
+func (s *MemoryStore) MarkFailed(_ context.Context, id, lastError string, next *time.Time) error {
+	return s.updateDelivery(id, func(d *WebhookDelivery) {
+		d.Status = DeliveryPending
+		if next == nil {
+			d.Status = DeliveryDead
+		}
+		d.NextAttemptAt = next
+		d.LastError = lastError
+	})
+}
+
+func (s *MemoryStore) updateDelivery(id string, fn func(d *WebhookDelivery)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.deliveries {
+		if s.deliveries[i].ID == id {
+			s.deliveries[i].Attempts++
+			fn(&s.deliveries[i])
+			return nil
+		}
+	}
+	return nil
+}
+
+// audit дописывает изменения в журнал аудита — под той же блокировкой,
+// что и само изменение. Вызывается под блокировкой.
+func (s *MemoryStore) audit(ctx context.Context, changes ...auditChange) {
+	now := time.Now()
+	for _, c := range changes {
+		e := auditEntry(ctx, now, c)
+		e.ID = int64(len(s.auditLog) + 1)
+		s.auditLog = append(s.auditLog, e)
+	}
+}
+
+func (s *MemoryStore) ListAudit(_ context.Context, f AuditFilter, page, limit int) ([]AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []AuditEntry
+	for i := len(s.auditLog) - 1; i >= 0; i-- {
+		e := s.auditLog[i]
+		if (f.Actor != "" && e.Actor != f.Actor) ||
+			(f.Action != "" && e.Action != f.Action) ||
+			(f.EntityType != "" && e.EntityType != f.EntityType) ||
+			(f.EntityID != "" && e.EntityID != f.EntityID) ||
+			(f.From != nil && e.Time.Before(*f.From)) ||
+			(f.To != nil && e.Time.After(*f.To)) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+
+	offset := (page - 1) * limit
+	if offset >= len(matched) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[offset:end], nil
+}
+
+func (s *MemoryStore) ReserveIdempotencyKey(ctx context.Context, scope, key, requestHash string, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, rec := range s.idem {
+		if rec.ExpiresAt.Before(now) || (!rec.Completed() && rec.CreatedAt.Before(now.Add(-IdempotencyLockTimeout))) {
+			delete(s.idem, k)
+		}
+	}
+	if rec, ok := s.idem[[2]string{scope, key}]; ok {
+		cp := *rec
+		return &cp, nil
+	}
+	s.idem[[2]string{scope, key}] = &IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, contentType string, response []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.idem[[2]string{scope, key}]
+	if !ok || rec.Completed() {
+		return nil
+	}
+	now := time.Now()
+	rec.StatusCode, rec.ContentType = statusCode, contentType
+	rec.Response = append([]byte(nil), response...)
+	rec.CompletedAt = &now
+	return nil
+}
+
+func (s *MemoryStore) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.idem[[2]string{scope, key}]; ok && !rec.Completed() {
+		delete(s.idem, [2]string{scope, key})
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"avito-pvz-service/internal/audit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_ReceptionFlow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	pvz, err := store.CreatePVZ(ctx, "Казань")
+	require.NoError(t, err)
+
+	// товар без приёмки добавить нельзя
+	_, err = store.AddProduct(ctx, pvz.ID, NewProduct{Type: "обувь"})
+	assert.ErrorIs(t, err, ErrNoActiveReception)
+
+	rec, err := store.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "in_progress", rec.Status)
+
+	// вторая открытая приёмка запрещена
+	_, err = store.CreateReception(ctx, pvz.ID)
+	assert.ErrorIs(t, err, ErrReceptionInProgress)
+
+	first, err := store.AddProduct(ctx, pvz.ID, NewProduct{Type: "обувь"})
+	require.NoError(t, err)
+	_, err = store.AddProduct(ctx, pvz.ID, NewProduct{Type: "одежда"})
+	require.NoError(t, err)
+
+	// LIFO: удаляется последний добавленный товар
+	require.NoError(t, store.DeleteLastProduct(ctx, pvz.ID))
+
+	closed, err := store.CloseReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "close", closed.Status)
+
+	_, err = store.CloseReception(ctx, pvz.ID)
+	assert.ErrorIs(t, err, ErrReceptionClosed)
+	assert.ErrorIs(t, store.DeleteLastProduct(ctx, pvz.ID), ErrReceptionClosed)
+
+	start := time.Now().Add(-time.Hour)
+	end := time.Now().Add(time.Hour)
+	records, err := store.GetPVZRecords(ctx, &start, &end, false, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Len(t, records[0].Receptions, 1)
+	require.Len(t, records[0].Receptions[0].Products, 1)
+	assert.Equal(t, first.ID, records[0].Receptions[0].Products[0].ID)
+}
+
+func TestMemoryStore_Validation(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	_, err := store.CreatePVZ(ctx, "Новосибирск")
+	assert.ErrorIs(t, err, ErrCityNotAllowed)
+
+	_, err = store.CreateReception(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrPVZNotFound)
+
+	_, err = store.AddProduct(ctx, "unknown", NewProduct{Type: "мебель"})
+	assert.ErrorIs(t, err, ErrInvalidProductType)
+
+	_, err = store.CloseReception(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrNoReceptionToClose)
+}
+
+func TestMemoryStore_Users(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	user, err := store.CreateUser(ctx, "test@example.com", "pass123", "client")
+	require.NoError(t, err)
+	assert.NotEqual(t, "pass123", user.Password)
+
+	_, err = store.CreateUser(ctx, "test@example.com", "other", "moderator")
+	assert.ErrorIs(t, err, ErrUserExists)
+
+	found, err := store.GetUserByEmail(ctx, "test@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	_, err = store.GetUserByEmail(ctx, "missing@example.com")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestMemoryStore_Barcode(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	pvz, err := store.CreatePVZ(ctx, "Москва")
+	require.NoError(t, err)
+	_, err = store.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+
+	first, err := store.AddProduct(ctx, pvz.ID, NewProduct{Type: "обувь", Barcode: "ORDER-1"})
+	require.NoError(t, err)
+	_, err = store.AddProduct(ctx, pvz.ID, NewProduct{Type: "обувь", Barcode: "ORDER-1"})
+	assert.ErrorIs(t, err, ErrDuplicateBarcode)
+
+	// После закрытия приёмки посылку с тем же номером можно принять снова
+	_, err = store.CloseReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	rec, err := store.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	second, err := store.AddProduct(ctx, pvz.ID, NewProduct{Type: "обувь", Barcode: "ORDER-1"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	loc, err := store.FindProductByBarcode(ctx, "ORDER-1")
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, loc.Product.ID)
+	assert.Equal(t, rec.ID, loc.Reception.ID)
+	assert.Equal(t, pvz.ID, loc.PVZ.ID)
+
+	_, err = store.FindProductByBarcode(ctx, "ORDER-2")
+	assert.ErrorIs(t, err, ErrProductNotFound)
+}
+
+func TestMemoryStore_ReopenCancel(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	pvz, err := store.CreatePVZ(ctx, "Москва")
+	require.NoError(t, err)
+	old, err := store.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	_, err = store.CloseReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	last, err := store.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	_, err = store.AddProduct(ctx, pvz.ID, NewProduct{Type: "обувь", Barcode: "ORDER-1"})
+	require.NoError(t, err)
+
+	_, err = store.ReopenReception(ctx, last.ID)
+	assert.ErrorIs(t, err, ErrInvalidTransition, "открытую приёмку переоткрыть нельзя")
+	_, err = store.CloseReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	_, err = store.ReopenReception(ctx, old.ID)
+	assert.ErrorIs(t, err, ErrReceptionNotLatest)
+
+	// Пока приёмка закрыта, штрихкод занял другой ПВЗ
+	other, err := store.CreatePVZ(ctx, "Казань")
+	require.NoError(t, err)
+	_, err = store.CreateReception(ctx, other.ID)
+	require.NoError(t, err)
+	_, err = store.AddProduct(ctx, other.ID, NewProduct{Type: "обувь", Barcode: "ORDER-1"})
+	require.NoError(t, err)
+	_, err = store.ReopenReception(ctx, last.ID)
+	assert.ErrorIs(t, err, ErrDuplicateBarcode)
+	_, err = store.CloseReception(ctx, other.ID)
+	require.NoError(t, err)
+
+	rec, err := store.ReopenReception(ctx, last.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusReopened, rec.Status)
+	_, err = store.AddProduct(ctx, pvz.ID, NewProduct{Type: "одежда"})
+	require.NoError(t, err)
+
+	rec, err = store.CancelReception(ctx, last.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelled, rec.Status)
+	_, err = store.AddProduct(ctx, pvz.ID, NewProduct{Type: "одежда"})
+	assert.ErrorIs(t, err, ErrNoActiveReception)
+	_, err = store.CancelReception(ctx, last.ID)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	_, err = store.CancelReception(ctx, "no-such-reception")
+	assert.ErrorIs(t, err, ErrReceptionNotFound)
+}
+
+func TestMemoryStore_ReceptionHistory(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	pvz, err := store.CreatePVZ(ctx, "Москва")
+	require.NoError(t, err)
+	_, err = store.CurrentReception(ctx, pvz.ID)
+	assert.ErrorIs(t, err, ErrNoActiveReception)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		rec, err := store.CreateReception(ctx, pvz.ID)
+		require.NoError(t, err)
+		ids = append(ids, rec.ID)
+		if i < 2 {
+			_, err = store.CloseReception(ctx, pvz.ID)
+			require.NoError(t, err)
+		}
+	}
+	_, err = store.AddProduct(ctx, pvz.ID, NewProduct{Type: "обувь"})
+	require.NoError(t, err)
+
+	current, err := store.CurrentReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ids[2], current.Reception.ID)
+	assert.Len(t, current.Products, 1)
+
+	all, err := store.ListReceptions(ctx, pvz.ID, ReceptionFilter{}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, ids[2], all[0].ID, "новые первыми")
+
+	closed, err := store.ListReceptions(ctx, pvz.ID, ReceptionFilter{Status: StatusClosed}, 2, 1)
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.Equal(t, ids[0], closed[0].ID)
+
+	record, err := store.GetReception(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Empty(t, record.Products)
+	_, err = store.GetReception(ctx, "no-such-reception")
+	assert.ErrorIs(t, err, ErrReceptionNotFound)
+}
+
+func TestMemoryStore_AuditLog(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := audit.WithActor(context.Background(), audit.Actor{
+		Subject: "moderator@example.com", Role: "moderator", RequestID: "req-1", ClientIP: "10.0.0.1",
+	})
+
+	pvz, err := store.CreatePVZ(ctx, "Казань")
+	require.NoError(t, err)
+	_, err = store.CreateReception(context.Background(), pvz.ID)
+	require.NoError(t, err)
+
+	// Новые записи первыми; без инициатора в контексте действие приписано system
+	entries, err := store.ListAudit(ctx, AuditFilter{}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, AuditReceptionCreate, entries[0].Action)
+	assert.Equal(t, audit.System, entries[0].Actor)
+	assert.Nil(t, entries[0].Before)
+
+	created := entries[1]
+	assert.Equal(t, AuditPVZCreate, created.Action)
+	assert.Equal(t, EntityPVZ, created.EntityType)
+	assert.Equal(t, pvz.ID, created.EntityID)
+	assert.Equal(t, "moderator@example.com", created.Actor)
+	assert.Equal(t, "req-1", created.RequestID)
+	assert.Equal(t, "10.0.0.1", created.ClientIP)
+	assert.Contains(t, string(created.After), `"city":"Казань"`)
+
+	entries, err = store.ListAudit(ctx, AuditFilter{Actor: "moderator@example.com"}, 1, 10)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	future := time.Now().Add(time.Hour)
+	entries, err = store.ListAudit(ctx, AuditFilter{From: &future}, 1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMemoryStore_SoftDelete(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := audit.WithActor(context.Background(), audit.Actor{Subject: "staff@example.com", Role: "staff"})
+
+	pvz, err := store.CreatePVZ(ctx, "Казань")
+	require.NoError(t, err)
+	rec, err := store.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	kept, err := store.AddProduct(ctx, pvz.ID, NewProduct{Type: "обувь"})
+	require.NoError(t, err)
+	_, err = store.AddProduct(ctx, pvz.ID, NewProduct{Type: "одежда", Barcode: "ORDER-1"})
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteLastProduct(ctx, pvz.ID))
+
+	// Штрихкод удалённого товара можно принять снова
+	_, err = store.AddProduct(ctx, pvz.ID, NewProduct{Type: "одежда", Barcode: "ORDER-1"})
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteLastProduct(ctx, pvz.ID))
+
+	start, end := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	records, err := store.GetPVZRecords(ctx, &start, &end, false, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Len(t, records[0].Receptions[0].Products, 1)
+	assert.Equal(t, kept.ID, records[0].Receptions[0].Products[0].ID)
+
+	records, _, err = store.GetPVZRecordsPage(ctx, &start, &end, true, nil, 10)
+	require.NoError(t, err)
+	products := records[0].Receptions[0].Products
+	require.Len(t, products, 3)
+	assert.Nil(t, products[0].DeletedAt)
+	require.NotNil(t, products[2].DeletedAt)
+	assert.Equal(t, "staff@example.com", products[2].DeletedBy)
+
+	detail, err := store.GetReception(ctx, rec.ID)
+	require.NoError(t, err)
+	assert.Len(t, detail.Products, 1)
+	_, err = store.FindProductByBarcode(ctx, "ORDER-1")
+	assert.ErrorIs(t, err, ErrProductNotFound)
+}
+
+func TestMemoryStore_IdempotencyKey(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	prev, err := store.ReserveIdempotencyKey(ctx, "staff", "k-1", "hash-1", time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, prev)
+
+	// Пока запрос выполняется, ключ занят
+	prev, err = store.ReserveIdempotencyKey(ctx, "staff", "k-1", "hash-1", time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, prev)
+	assert.False(t, prev.Completed())
+
+	require.NoError(t, store.CompleteIdempotencyKey(ctx, "staff", "k-1", 201, "application/json", []byte(`{"id":"1"}`)))
+	prev, err = store.ReserveIdempotencyKey(ctx, "staff", "k-1", "hash-1", time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, prev)
+	assert.True(t, prev.Completed())
+	assert.Equal(t, 201, prev.StatusCode)
+	assert.Equal(t, `{"id":"1"}`, string(prev.Response))
+
+	// Ключи разных пользователей независимы
+	prev, err = store.ReserveIdempotencyKey(ctx, "moderator", "k-1", "hash-2", time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, prev)
+
+	// Освобождённый после сбоя ключ можно занять снова
+	require.NoError(t, store.ReleaseIdempotencyKey(ctx, "moderator", "k-1"))
+	prev, err = store.ReserveIdempotencyKey(ctx, "moderator", "k-1", "hash-3", time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, prev)
+
+	// Истёкший ключ тоже
+	_, err = store.ReserveIdempotencyKey(ctx, "staff", "k-2", "hash-1", -time.Second)
+	require.NoError(t, err)
+	prev, err = store.ReserveIdempotencyKey(ctx, "staff", "k-2", "hash-2", time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, prev)
+}
+
+func TestMemoryStore_CloseStaleIgnoresDeletedProducts(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	stale, err := store.CreatePVZ(ctx, "Казань")
+	require.NoError(t, err)
+	_, err = store.CreateReception(ctx, stale.ID)
+	require.NoError(t, err)
+	_, err = store.AddProduct(ctx, stale.ID, NewProduct{Type: "обувь"})
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteLastProduct(ctx, stale.ID))
+
+	active, err := store.CreatePVZ(ctx, "Казань")
+	require.NoError(t, err)
+	_, err = store.CreateReception(ctx, active.ID)
+	require.NoError(t, err)
+	_, err = store.AddProduct(ctx, active.ID, NewProduct{Type: "обувь"})
+	require.NoError(t, err)
+
+	// Обе приёмки открыты давно; свежий товар остался только во второй
+	for i := range store.receptions {
+		store.receptions[i].DateTime = time.Now().Add(-2 * time.Hour)
+	}
+	closed, err := store.CloseStaleReceptions(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.Equal(t, stale.ID, closed[0].PVZId)
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"avito-pvz-service/internal/events"
+)
+
+// WebhookEvent — доменное событие в том виде, в каком оно хранится в outbox
+// и уходит подписчикам в теле вебхука.
+type WebhookEvent struct {
+	// ID — номер записи outbox, по нему получатель отсеивает повторы.
+	ID          int64     `json:"id"`
+	Type        string    `json:"type"`
+	Time        time.Time `json:"time"`
+	PVZId       string    `json:"pvzId"`
+	City        string    `json:"city,omitempty"`
+	ReceptionId string    `json:"receptionId,omitempty"`
+	ProductId   string    `json:"productId,omitempty"`
+	ProductType string    `json:"productType,omitempty"`
+}
+
+func webhookEventOf(e events.Event) WebhookEvent {
+	return WebhookEvent{
+		Type:        string(e.Type),
+		Time:        e.Time,
+		PVZId:       e.PVZId,
+		City:        e.City,
+		ReceptionId: e.ReceptionId,
+		ProductId:   e.ProductId,
+		ProductType: e.ProductType,
+	}
+}
+
+// insertOutbox записывает события в outbox в транзакции изменения, чтобы
+// событие появилось тогда и только тогда, когда изменение закоммичено.
+// Время событий должно быть заполнено: с ним же они уйдут в шину.
+func insertOutbox(ctx context.Context, tx *sql.Tx, evs ...events.Event) error {
+	if len(evs) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(evs))
+	args := make([]interface{}, 0, len(evs)*3)
+	for _, e := range evs {
+		payload, err := json.Marshal(webhookEventOf(e))
+		if err != nil {
+			return err
+		}
+		k := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d)", k+1, k+2, k+3))
+		args = append(args, string(e.Type), payload, e.Time)
+	}
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO outbox (event_type, payload, created_at) VALUES "+strings.Join(values, ", "), args...)
+	return err
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"avito-pvz-service/internal/events"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// BatchMode — как AddProducts поступает с позициями, не прошедшими проверку.
+type BatchMode string
+
+const (
+	// BatchAllOrNothing — одна неверная позиция отклоняет весь пакет.
+	BatchAllOrNothing BatchMode = "all_or_nothing"
+	// BatchBestEffort — верные позиции добавляются, неверные пропускаются.
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+// MaxBatchSize ограничивает пакет: на каждую позицию в INSERT уходит
+// семь параметров, а PostgreSQL принимает не больше 65535.
+const MaxBatchSize = 500
+
+// ProductResult — итог по одной позиции пакета в том же порядке, что и на входе.
+type ProductResult struct {
+	Product *Product
+	Err     error
+}
+
+// checkBatch проверяет позиции пакета по справочнику типов и уже занятым
+// штрихкодам и возвращает индексы позиций, которые нужно вставить.
+// В режиме BatchAllOrNothing при любой ошибке вставлять нечего.
+func checkBatch(items []NewProduct, mode BatchMode, typeOf func(code string) (ProductType, error), taken map[string]bool) ([]ProductResult, []int, error) {
+	results := make([]ProductResult, len(items))
+	seen := make(map[string]bool, len(items))
+	var ok []int
+	for i, in := range items {
+		pt, err := typeOf(in.Type)
+		if err == ErrInvalidProductType {
+			results[i].Err = err
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := pt.ValidateAttributes(in.Attributes); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if in.Barcode != "" {
+			if taken[in.Barcode] || seen[in.Barcode] {
+				results[i].Err = ErrDuplicateBarcode
+				continue
+			}
+			seen[in.Barcode] = true
+		}
+		ok = append(ok, i)
+	}
+
+	if mode == BatchAllOrNothing && len(ok) < len(items) {
+		for _, i := range ok {
+			results[i].Err = ErrBatchAborted
+		}
+		return results, nil, nil
+	}
+	return results, ok, nil
+}
+
+func validBatchMode(mode BatchMode) bool {
+	return mode == BatchAllOrNothing || mode == BatchBestEffort
+}
+
+// batchBarcodes возвращает непустые штрихкоды пакета.
+func batchBarcodes(items []NewProduct) []string {
+	var codes []string
+	for _, in := range items {
+		if in.Barcode != "" {
+			codes = append(codes, in.Barcode)
+		}
+	}
+	return codes
+}
+
+// AddProducts добавляет пакет товаров в открытую приёмку ПВЗ одной транзакцией
+// и одним многострочным INSERT. Ошибка возвращается, только если пакет нельзя
+// обработать целиком (нет открытой приёмки, сбой БД); ошибки отдельных позиций
+// лежат в результатах.
+func (r *PostgresProductRepository) AddProducts(ctx context.Context, pvzId string, items []NewProduct, mode BatchMode) ([]ProductResult, error) {
+	if !validBatchMode(mode) {
+		return nil, ErrInvalidBatchMode
+	}
+
+	var results []ProductResult
+	var inserted []int
+	var added []events.Event
+	var changes []auditChange
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		city, _, err := lockPVZ(ctx, tx, pvzId)
+		if err != nil {
+			if err == ErrPVZNotFound {
+				return ErrNoActiveReception
+			}
+			return err
+		}
+
+		var receptionId, status string
+		err = tx.QueryRowContext(ctx, `
+        SELECT id, status
+        FROM receptions
+        WHERE pvz_id = $1
+        ORDER BY date_time DESC
+        LIMIT 1`, pvzId).Scan(&receptionId, &status)
+		if err == sql.ErrNoRows {
+			return ErrNoActiveReception
+		}
+		if err != nil {
+			return err
+		}
+		if !IsOpenStatus(status) {
+			return ErrNoActiveReception
+		}
+
+		taken := make(map[string]bool)
+		if codes := batchBarcodes(items); len(codes) > 0 {
+			rows, err := tx.QueryContext(ctx,
+				"SELECT barcode FROM products WHERE reception_open AND deleted_at IS NULL AND barcode = ANY($1)", pq.Array(codes))
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				var code string
+				if err := rows.Scan(&code); err != nil {
+					rows.Close()
+					return err
+				}
+				taken[code] = true
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+
+		results, inserted, err = checkBatch(items, mode, func(code string) (ProductType, error) {
+			return r.types.ProductType(ctx, code)
+		}, taken)
+		if err != nil || len(inserted) == 0 {
+			return err
+		}
+
+		// Время растёт на микросекунду на позицию, чтобы удаление по LIFO
+		// снимало товары пакета в обратном порядке.
+		now := time.Now()
+		values := make([]string, 0, len(inserted))
+		args := make([]interface{}, 0, len(inserted)*7)
+		for n, i := range inserted {
+			in := items[i]
+			attrsJSON, err := marshalAttributes(in.Attributes)
+			if err != nil {
+				return err
+			}
+			p := &Product{
+				ID:          uuid.New().String(),
+				DateTime:    now.Add(time.Duration(n) * time.Microsecond),
+				Type:        in.Type,
+				ReceptionId: receptionId,
+				PVZId:       pvzId,
+				Attributes:  in.Attributes,
+				Barcode:     in.Barcode,
+			}
+			results[i].Product = p
+			added = append(added, events.Event{
+				Type:        events.ProductAdded,
+				Time:        p.DateTime,
+				PVZId:       pvzId,
+				City:        city,
+				ReceptionId: receptionId,
+				ProductId:   p.ID,
+				ProductType: p.Type,
+			})
+			changes = append(changes, auditChange{
+				action: AuditProductAdd, entityType: EntityProduct, entityId: p.ID, after: p,
+			})
+			k := len(args)
+			values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)", k+1, k+2, k+3, k+4, k+5, k+6, k+7))
+			args = append(args, p.ID, p.DateTime, p.Type, receptionId, pvzId, attrsJSON, nullIfEmpty(in.Barcode))
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO products (id, date_time, type, reception_id, pvz_id, attributes, barcode) VALUES "+
+				strings.Join(values, ", "), args...)
+		if isUniqueViolation(err, productsOpenBarcode) {
+			// Штрихкод заняли параллельно в другом ПВЗ
+			return ErrDuplicateBarcode
+		}
+		if isForeignKeyViolation(err, productsTypeFKey) {
+			return ErrInvalidProductType
+		}
+		if err != nil {
+			return err
+		}
+		if err := insertOutbox(ctx, tx, added...); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, changes...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range added {
+		r.events.Publish(e)
+	}
+	return results, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddProducts_BestEffort(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresProductRepository(db)
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-1")
+	mock.ExpectQuery(`SELECT id, status FROM receptions`).
+		WithArgs("pvz-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("r1", "in_progress"))
+	mock.ExpectQuery(`SELECT barcode FROM products WHERE reception_open AND deleted_at IS NULL AND barcode = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{"A", "B", "A"})).
+		WillReturnRows(sqlmock.NewRows([]string{"barcode"}).AddRow("B"))
+	expectProductTypes(mock)
+
+	// Вставляются только первая и последняя позиции, одним запросом
+	mock.ExpectExec(`INSERT INTO products \(id, date_time, type, reception_id, pvz_id, attributes, barcode\) VALUES \(\$1, .*\), \(\$8, .*\$14\)$`).
+		WithArgs(
+			sqlmock.AnyArg(), sqlmock.AnyArg(), "обувь", "r1", "pvz-1", []byte("{}"), nullIfEmpty("A"),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), "одежда", "r1", "pvz-1", []byte("{}"), nullIfEmpty(""),
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	expectOutbox(mock)
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	results, err := repo.AddProducts(context.Background(), "pvz-1", []NewProduct{
+		{Type: "обувь", Barcode: "A"},
+		{Type: "обувь", Barcode: "B"},
+		{Type: "мебель"},
+		{Type: "обувь", Barcode: "A"},
+		{Type: "одежда"},
+	}, BatchBestEffort)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	assert.NotNil(t, results[0].Product)
+	assert.ErrorIs(t, results[1].Err, ErrDuplicateBarcode)
+	assert.ErrorIs(t, results[2].Err, ErrInvalidProductType)
+	assert.ErrorIs(t, results[3].Err, ErrDuplicateBarcode)
+	assert.NotNil(t, results[4].Product)
+	assert.True(t, results[4].Product.DateTime.After(results[0].Product.DateTime))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddProducts_AllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	pvz, err := store.CreatePVZ(ctx, "Москва")
+	require.NoError(t, err)
+	_, err = store.AddProducts(ctx, pvz.ID, []NewProduct{{Type: "обувь"}}, BatchAllOrNothing)
+	assert.ErrorIs(t, err, ErrNoActiveReception)
+	_, err = store.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	_, err = store.AddProducts(ctx, pvz.ID, []NewProduct{{Type: "обувь"}}, "some")
+	assert.ErrorIs(t, err, ErrInvalidBatchMode)
+
+	results, err := store.AddProducts(ctx, pvz.ID, []NewProduct{{Type: "обувь"}, {Type: "мебель"}}, BatchAllOrNothing)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, ErrBatchAborted)
+	assert.ErrorIs(t, results[1].Err, ErrInvalidProductType)
+	assert.Nil(t, results[0].Product)
+
+	results, err = store.AddProducts(ctx, pvz.ID, []NewProduct{{Type: "обувь"}, {Type: "одежда"}}, BatchAllOrNothing)
+	require.NoError(t, err)
+	for _, res := range results {
+		assert.NoError(t, res.Err)
+	}
+
+	// LIFO снимает товары пакета в обратном порядке; удалённые остаются в хранилище с отметкой
+	require.NoError(t, store.DeleteLastProduct(ctx, pvz.ID))
+	require.Len(t, store.products, 2)
+	assert.Equal(t, results[0].Product.ID, store.products[0].ID)
+	assert.Nil(t, store.products[0].DeletedAt)
+	assert.NotNil(t, store.products[1].DeletedAt)
+	require.NoError(t, store.DeleteLastProduct(ctx, pvz.ID))
+	assert.NotNil(t, store.products[0].DeletedAt)
+	assert.ErrorIs(t, store.DeleteLastProduct(ctx, pvz.ID), ErrNoProducts)
+}
+
+func TestAddProducts_ReceptionQueryError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresProductRepository(db)
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-1")
+	mock.ExpectQuery(`SELECT id, status FROM receptions`).
+		WithArgs("pvz-1").
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	// Сбой БД не выдаётся за отсутствие приёмки
+	_, err = repo.AddProducts(context.Background(), "pvz-1", []NewProduct{{Type: "обувь"}}, BatchAllOrNothing)
+	assert.EqualError(t, err, "connection reset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
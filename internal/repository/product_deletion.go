@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"avito-pvz-service/internal/events"
+
+	"github.com/google/uuid"
+)
+
+// Причины удаления товара из открытой приёмки.
+const (
+	ReasonWrongScan = "wrong_scan" // отсканирован не тот товар
+	ReasonDuplicate = "duplicate"  // товар принят дважды
+	ReasonDamaged   = "damaged"    // товар повреждён, в приёмку не берётся
+	ReasonOther     = "other"      // подробности в комментарии
+)
+
+// ValidDeletionReason сообщает, известен ли код причины удаления.
+func ValidDeletionReason(reason string) bool {
+	switch reason {
+	case ReasonWrongScan, ReasonDuplicate, ReasonDamaged, ReasonOther:
+		return true
+	}
+	return false
+}
+
+// ProductDeletion — запись журнала удалений товаров.
+type ProductDeletion struct {
+	ID          string    `json:"id"`
+	ProductID   string    `json:"productId"`
+	ReceptionID string    `json:"receptionId"`
+	PVZId       string    `json:"pvzId"`
+	Type        string    `json:"type"`
+	Barcode     string    `json:"barcode,omitempty"`
+	Reason      string    `json:"reason"`
+	Comment     string    `json:"comment,omitempty"`
+	DeletedBy   string    `json:"deletedBy"`
+	DeletedAt   time.Time `json:"deletedAt"`
+}
+
+// GetProduct возвращает товар по id, в том числе удалённый.
+func (r *PostgresProductRepository) GetProduct(ctx context.Context, id string) (*Product, error) {
+	p, err := scanProduct(r.db.QueryRowContext(ctx, "SELECT "+productColumns+" FROM products WHERE id = $1", id))
+	if err == sql.ErrNoRows || isInvalidText(err) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DeleteProduct помечает товар из открытой приёмки удалённым и записывает
+// удаление в журнал в той же транзакции. В del нужно заполнить Reason,
+// Comment и DeletedBy.
+func (r *PostgresProductRepository) DeleteProduct(ctx context.Context, productId string, del ProductDeletion) (*ProductDeletion, error) {
+	if !ValidDeletionReason(del.Reason) {
+		return nil, ErrInvalidDeletionReason
+	}
+	product, err := r.GetProduct(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+
+	var event events.Event
+	err = withTx(ctx, r.db, func(tx *sql.Tx) error {
+		city, _, err := lockPVZ(ctx, tx, product.PVZId)
+		if err != nil {
+			if err == ErrPVZNotFound {
+				return ErrProductNotFound
+			}
+			return err
+		}
+
+		// Под блокировкой ПВЗ проверяем, что товар ещё не удалён и приёмка открыта
+		var status string
+		var barcode sql.NullString
+		err = tx.QueryRowContext(ctx, `
+        SELECT r.status, p.barcode
+        FROM products p
+        JOIN receptions r ON r.id = p.reception_id
+        WHERE p.id = $1 AND p.deleted_at IS NULL`, productId).Scan(&status, &barcode)
+		if err == sql.ErrNoRows {
+			return ErrProductNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !IsOpenStatus(status) {
+			return ErrReceptionClosed
+		}
+
+		del.ID = uuid.New().String()
+		del.ProductID = productId
+		del.ReceptionID = product.ReceptionId
+		del.PVZId = product.PVZId
+		del.Type = product.Type
+		del.Barcode = barcode.String
+		del.DeletedAt = time.Now()
+		_, err = tx.ExecContext(ctx, "UPDATE products SET deleted_at = $2, deleted_by = $3 WHERE id = $1",
+			productId, del.DeletedAt, del.DeletedBy)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+        INSERT INTO product_deletions (id, product_id, reception_id, pvz_id, type, barcode, reason, comment, deleted_by, deleted_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			del.ID, del.ProductID, del.ReceptionID, del.PVZId, del.Type, barcode,
+			del.Reason, del.Comment, del.DeletedBy, del.DeletedAt)
+		if err != nil {
+			return err
+		}
+		event = events.Event{
+			Type:        events.ProductRemoved,
+			Time:        del.DeletedAt,
+			PVZId:       del.PVZId,
+			City:        city,
+			ReceptionId: del.ReceptionID,
+			ProductId:   productId,
+		}
+		if err := insertOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: AuditProductDelete, entityType: EntityProduct, entityId: productId, before: product, after: del,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.events.Publish(event)
+	return &del, nil
+}
+
+// ListProductDeletions возвращает журнал удалений по ПВЗ, новые записи первыми.
+func (r *PostgresProductRepository) ListProductDeletions(ctx context.Context, pvzId string) ([]ProductDeletion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, product_id, reception_id, pvz_id, type, barcode, reason, comment, deleted_by, deleted_at
+        FROM product_deletions
+        WHERE pvz_id = $1
+        ORDER BY deleted_at DESC`, pvzId)
+	if isInvalidText(err) {
+		return nil, ErrPVZNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []ProductDeletion{}
+	for rows.Next() {
+		var d ProductDeletion
+		var barcode sql.NullString
+		if err := rows.Scan(&d.ID, &d.ProductID, &d.ReceptionID, &d.PVZId, &d.Type, &barcode,
+			&d.Reason, &d.Comment, &d.DeletedBy, &d.DeletedAt); err != nil {
+			return nil, err
+		}
+		d.Barcode = barcode.String
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
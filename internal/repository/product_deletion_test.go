@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectGetProduct(mock sqlmock.Sqlmock, id, receptionId, pvzId string) {
+	mock.ExpectQuery(`SELECT id, date_time, type, reception_id, pvz_id, attributes, barcode, deleted_at, deleted_by FROM products WHERE id = \$1`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "type", "reception_id", "pvz_id", "attributes", "barcode", "deleted_at", "deleted_by"}).
+			AddRow(id, time.Now(), "обувь", receptionId, pvzId, []byte("{}"), nil, nil, nil))
+}
+
+func TestDeleteProduct_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresProductRepository(db)
+	expectGetProduct(mock, "prod-1", "rec-1", "pvz-1")
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-1")
+	mock.ExpectQuery(`SELECT r\.status, p\.barcode FROM products p JOIN receptions r`).
+		WithArgs("prod-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "barcode"}).AddRow("in_progress", "ORDER-3"))
+	mock.ExpectExec(`UPDATE products SET deleted_at = \$2, deleted_by = \$3 WHERE id = \$1`).
+		WithArgs("prod-1", sqlmock.AnyArg(), "staff").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO product_deletions`).
+		WithArgs(sqlmock.AnyArg(), "prod-1", "rec-1", "pvz-1", "обувь", nullIfEmpty("ORDER-3"),
+			ReasonWrongScan, "", "staff", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectOutbox(mock)
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	del, err := repo.DeleteProduct(context.Background(), "prod-1", ProductDeletion{Reason: ReasonWrongScan, DeletedBy: "staff"})
+	require.NoError(t, err)
+	assert.Equal(t, "rec-1", del.ReceptionID)
+	assert.Equal(t, "ORDER-3", del.Barcode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteProduct_ReceptionClosed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresProductRepository(db)
+	expectGetProduct(mock, "prod-2", "rec-2", "pvz-2")
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-2")
+	mock.ExpectQuery(`SELECT r\.status, p\.barcode FROM products p JOIN receptions r`).
+		WithArgs("prod-2").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "barcode"}).AddRow("close", nil))
+	mock.ExpectRollback()
+
+	_, err = repo.DeleteProduct(context.Background(), "prod-2", ProductDeletion{Reason: ReasonDamaged, DeletedBy: "staff"})
+	assert.ErrorIs(t, err, ErrReceptionClosed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteProduct_InvalidReason(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = NewPostgresProductRepository(db).DeleteProduct(context.Background(), "prod-3", ProductDeletion{Reason: "oops"})
+	assert.ErrorIs(t, err, ErrInvalidDeletionReason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
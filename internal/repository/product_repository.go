@@ -1,96 +1,298 @@
 package repository
 
 import (
-	"avito-pvz-service/internal/database"
-	"errors"
+	"context"
+	"database/sql"
+	"encoding/json"
 	"time"
 
+	"avito-pvz-service/internal/audit"
+	"avito-pvz-service/internal/events"
+
 	"github.com/google/uuid"
 )
 
 type Product struct {
-	ID          string    `json:"id"`
-	DateTime    time.Time `json:"date_time"`
-	Type        string    `json:"type"`
-	ReceptionId string    `json:"reception_id"`
-	PVZId       string    `json:"pvz_id"`
+	ID          string                 `json:"id"`
+	DateTime    time.Time              `json:"date_time"`
+	Type        string                 `json:"type"`
+	ReceptionId string                 `json:"reception_id"`
+	PVZId       string                 `json:"pvz_id"`
+	Attributes  map[string]interface{} `json:"attributes,omitempty"`
+	Barcode     string                 `json:"barcode,omitempty"`
+	// DeletedAt и DeletedBy заполнены у удалённого товара. Такие товары
+	// видны только модератору, который явно попросил их показать.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy string     `json:"deleted_by,omitempty"`
+}
+
+// NewProduct — данные товара, которые передаёт сотрудник при приёмке.
+type NewProduct struct {
+	Type       string
+	Attributes map[string]interface{}
+	// Barcode — штрихкод или внешний номер заказа, необязателен
+	Barcode string
 }
 
-var allowedProductTypes = map[string]bool{
-	"электроника": true,
-	"одежда":      true,
-	"обувь":       true,
+// ProductLocation — где находится товар, найденный по штрихкоду.
+type ProductLocation struct {
+	Product   Product   `json:"product"`
+	Reception Reception `json:"reception"`
+	PVZ       PVZ       `json:"pvz"`
+}
+
+// productsOpenBarcode — частичный уникальный индекс из миграции 0010.
+const productsOpenBarcode = "products_open_barcode_key"
+
+// productColumns — колонки товара в порядке, который ожидает scanProduct.
+const productColumns = "id, date_time, type, reception_id, pvz_id, attributes, barcode, deleted_at, deleted_by"
+
+func scanProduct(row rowScanner) (Product, error) {
+	var p Product
+	var attrs []byte
+	var barcode, deletedBy sql.NullString
+	var deletedAt sql.NullTime
+	err := row.Scan(&p.ID, &p.DateTime, &p.Type, &p.ReceptionId, &p.PVZId, &attrs, &barcode, &deletedAt, &deletedBy)
+	if err != nil {
+		return p, err
+	}
+	if deletedAt.Valid {
+		p.DeletedAt = &deletedAt.Time
+	}
+	p.DeletedBy = deletedBy.String
+	return p, fillProduct(&p, attrs, barcode)
 }
 
-func AddProduct(pvzId, productType string) (*Product, error) {
-	if !allowedProductTypes[productType] {
-		return nil, errors.New("Invalid product type")
+// fillProduct разбирает колонки товара, которые не сканируются напрямую.
+func fillProduct(p *Product, attrs []byte, barcode sql.NullString) error {
+	p.Barcode = barcode.String
+	if len(attrs) == 0 {
+		return nil
 	}
+	return json.Unmarshal(attrs, &p.Attributes)
+}
+
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// PostgresProductRepository хранит товары в PostgreSQL.
+type PostgresProductRepository struct {
+	db     *sql.DB
+	types  *ProductTypeCache
+	events *events.Bus
+}
 
-	var receptionId, status string
-	err := database.DB.QueryRow(`
+func NewPostgresProductRepository(db *sql.DB) *PostgresProductRepository {
+	return &PostgresProductRepository{db: db, types: NewProductTypeCache(NewPostgresProductTypeRepository(db), catalogCacheTTL)}
+}
+
+func (r *PostgresProductRepository) AddProduct(ctx context.Context, pvzId string, in NewProduct) (*Product, error) {
+	productType, attrs := in.Type, in.Attributes
+	pt, err := r.types.ProductType(ctx, productType)
+	if err != nil {
+		return nil, err
+	}
+	if err := pt.ValidateAttributes(attrs); err != nil {
+		return nil, err
+	}
+	attrsJSON, err := marshalAttributes(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	var product *Product
+	var event events.Event
+	err = withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// Блокировка ПВЗ не даёт закрыть приёмку, пока в неё добавляется товар.
+		city, _, err := lockPVZ(ctx, tx, pvzId)
+		if err != nil {
+			if err == ErrPVZNotFound {
+				return ErrNoActiveReception
+			}
+			return err
+		}
+
+		var receptionId, status string
+		err = tx.QueryRowContext(ctx, `
 	    SELECT id, status 
 	    FROM receptions 
 	    WHERE pvz_id = $1 
 	    ORDER BY date_time DESC 
 	    LIMIT 1`, pvzId).Scan(&receptionId, &status)
-	if err != nil {
-		return nil, errors.New("Нет активной приемки")
-	}
-	if status != "in_progress" {
-		return nil, errors.New("Нет активной приемки")
-	}
+		if err == sql.ErrNoRows {
+			return ErrNoActiveReception
+		}
+		if err != nil {
+			return err
+		}
+		if !IsOpenStatus(status) {
+			return ErrNoActiveReception
+		}
 
-	id := uuid.New().String()
-	dateTime := time.Now()
+		id := uuid.New().String()
+		dateTime := time.Now()
 
-	// Вставляем запись с указанием reception_id и pvz_id.
-	_, err = database.DB.Exec(`
-	    INSERT INTO products (id, date_time, type, reception_id, pvz_id)
-	    VALUES ($1, $2, $3, $4, $5)`,
-		id, dateTime, productType, receptionId, pvzId)
+		// Вставляем запись с указанием reception_id и pvz_id.
+		_, err = tx.ExecContext(ctx, `
+	    INSERT INTO products (id, date_time, type, reception_id, pvz_id, attributes, barcode)
+	    VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			id, dateTime, productType, receptionId, pvzId, attrsJSON, nullIfEmpty(in.Barcode))
+		if isUniqueViolation(err, productsOpenBarcode) {
+			return ErrDuplicateBarcode
+		}
+		if isForeignKeyViolation(err, productsTypeFKey) {
+			// Тип удалили после загрузки кэша
+			return ErrInvalidProductType
+		}
+		if err != nil {
+			return err
+		}
+
+		product = &Product{
+			ID:          id,
+			DateTime:    dateTime,
+			Type:        productType,
+			ReceptionId: receptionId,
+			PVZId:       pvzId,
+			Attributes:  attrs,
+			Barcode:     in.Barcode,
+		}
+		event = events.Event{
+			Type:        events.ProductAdded,
+			Time:        dateTime,
+			PVZId:       pvzId,
+			City:        city,
+			ReceptionId: receptionId,
+			ProductId:   id,
+			ProductType: productType,
+		}
+		if err := insertOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: AuditProductAdd, entityType: EntityProduct, entityId: id, after: product,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Product{
-		ID:          id,
-		DateTime:    dateTime,
-		Type:        productType,
-		ReceptionId: receptionId,
-		PVZId:       pvzId,
-	}, nil
+	r.events.Publish(event)
+	return product, nil
+}
+
+// marshalAttributes готовит атрибуты товара к записи в JSONB.
+func marshalAttributes(attrs map[string]interface{}) ([]byte, error) {
+	if attrs == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(attrs)
 }
 
-func DeleteLastProduct(pvzId string) error {
-    // Сначала находим последнюю приёмку для данного PVZ.
-    var receptionId, status string
-    err := database.DB.QueryRow(`
+func (r *PostgresProductRepository) DeleteLastProduct(ctx context.Context, pvzId string) error {
+	var event events.Event
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		city, _, err := lockPVZ(ctx, tx, pvzId)
+		if err != nil {
+			if err == ErrPVZNotFound {
+				return ErrNoActiveReception
+			}
+			return err
+		}
+
+		// Сначала находим последнюю приёмку для данного PVZ.
+		var receptionId, productId, status string
+		err = tx.QueryRowContext(ctx, `
         SELECT id, status 
         FROM receptions 
         WHERE pvz_id = $1 
         ORDER BY date_time DESC 
         LIMIT 1`, pvzId).Scan(&receptionId, &status)
-    if err != nil {
-        return errors.New("Нет активной приемки")
-    }
-    if status != "in_progress" {
-        return errors.New("Приемка уже закрыта")
-    }
-    // Находим последний добавленный товар в этой приёмке (сортируем по времени добавления)
-    var productId string
-    err = database.DB.QueryRow(`
+		if err == sql.ErrNoRows {
+			return ErrNoActiveReception
+		}
+		if err != nil {
+			return err
+		}
+		if !IsOpenStatus(status) {
+			return ErrReceptionClosed
+		}
+		// Находим последний добавленный товар в этой приёмке (сортируем по времени добавления);
+		// уже удалённые товары пропускаем
+		err = tx.QueryRowContext(ctx, `
         SELECT id FROM products 
-        WHERE reception_id = $1 
+        WHERE reception_id = $1 AND deleted_at IS NULL
         ORDER BY date_time DESC 
         LIMIT 1`, receptionId).Scan(&productId)
-    if err != nil {
-        return errors.New("Нет товаров для удаления")
-    }
-    // Удаляем найденный товар
-    _, err = database.DB.Exec("DELETE FROM products WHERE id = $1", productId)
-    if err != nil {
-        return err
-    }
-    return nil
-}
\ No newline at end of file
+		if err == sql.ErrNoRows {
+			return ErrNoProducts
+		}
+		if err != nil {
+			return err
+		}
+		// Товар не удаляется физически, а помечается удалённым
+		deletedAt := time.Now()
+		deleted, err := scanProduct(tx.QueryRowContext(ctx,
+			"UPDATE products SET deleted_at = $2, deleted_by = $3 WHERE id = $1 RETURNING "+productColumns,
+			productId, deletedAt, audit.ActorFrom(ctx).Subject))
+		if err != nil {
+			return err
+		}
+		before := deleted
+		before.DeletedAt, before.DeletedBy = nil, ""
+		event = events.Event{
+			Type:        events.ProductRemoved,
+			Time:        deletedAt,
+			PVZId:       pvzId,
+			City:        city,
+			ReceptionId: receptionId,
+			ProductId:   productId,
+		}
+		if err := insertOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: AuditProductDeleteLast, entityType: EntityProduct, entityId: productId, before: before, after: deleted,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	r.events.Publish(event)
+	return nil
+}
+
+// FindProductByBarcode ищет товар по штрихкоду среди неудалённых. Если штрихкод
+// встречался в нескольких приёмках, возвращается товар из открытой приёмки, иначе — последний.
+func (r *PostgresProductRepository) FindProductByBarcode(ctx context.Context, barcode string) (*ProductLocation, error) {
+	var loc ProductLocation
+	var attrs []byte
+	var code sql.NullString
+	p := &loc.PVZ
+	err := r.db.QueryRowContext(ctx, `
+        SELECT pr.id, pr.date_time, pr.type, pr.reception_id, pr.pvz_id, pr.attributes, pr.barcode,
+               r.id, r.date_time, r.pvz_id, r.status,
+               `+pvzColumns+`
+        FROM products pr
+        JOIN receptions r ON r.id = pr.reception_id
+        JOIN pvz p ON p.id = pr.pvz_id
+        WHERE pr.barcode = $1 AND pr.deleted_at IS NULL
+        ORDER BY pr.reception_open DESC, pr.date_time DESC
+        LIMIT 1`, barcode).Scan(
+		&loc.Product.ID, &loc.Product.DateTime, &loc.Product.Type, &loc.Product.ReceptionId, &loc.Product.PVZId, &attrs, &code,
+		&loc.Reception.ID, &loc.Reception.DateTime, &loc.Reception.PVZId, &loc.Reception.Status,
+		&p.ID, &p.RegistrationDate, &p.City, &p.Name, &p.Address, &p.Active, &p.ClosedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := fillProduct(&loc.Product, attrs, code); err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
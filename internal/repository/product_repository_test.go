@@ -1,12 +1,14 @@
 package repository
 
 import (
-	"avito-pvz-service/internal/database"
+	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -16,10 +18,14 @@ func TestAddProduct_Success(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	database.DB = db
+	repo := NewPostgresProductRepository(db)
 	pvzID := "1234-pvz"
 	receptionID := "5678-reception"
 
+	expectProductTypes(mock)
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzID)
+
 	// mock получения последней открытой приёмки
 	mock.ExpectQuery(`SELECT id, status FROM receptions`).
 		WithArgs(pvzID).
@@ -27,10 +33,14 @@ func TestAddProduct_Success(t *testing.T) {
 
 	// mock вставки продукта
 	mock.ExpectExec(`INSERT INTO products`).
-		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "электроника", receptionID, pvzID).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "электроника", receptionID, pvzID, []byte("{}"), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	product, err := AddProduct(pvzID, "электроника")
+	expectOutbox(mock)
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	product, err := repo.AddProduct(context.Background(), pvzID, NewProduct{Type: "электроника"})
 	require.NoError(t, err)
 	assert.Equal(t, "электроника", product.Type)
 	assert.Equal(t, receptionID, product.ReceptionId)
@@ -39,39 +49,105 @@ func TestAddProduct_Success(t *testing.T) {
 }
 
 func TestAddProduct_InvalidType(t *testing.T) {
-	product, err := AddProduct("any", "мебель")
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresProductRepository(db)
+	expectProductTypes(mock)
+
+	product, err := repo.AddProduct(context.Background(), "any", NewProduct{Type: "мебель"})
 	assert.Nil(t, product)
 	assert.EqualError(t, err, "Invalid product type")
 }
 
+func TestAddProduct_InvalidAttributes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresProductRepository(db)
+	expectProductTypes(mock, ProductType{Code: "мебель", Attributes: []AttributeSpec{
+		{Name: "вес", Kind: AttributeNumber, Unit: "кг", Required: true},
+		{Name: "цвет", Kind: AttributeString},
+	}})
+
+	// До транзакции дело не доходит, справочник читается один раз
+	for _, attrs := range []map[string]interface{}{
+		nil,
+		{"вес": "тяжёлый"},
+		{"вес": 12.0, "размер": "XL"},
+	} {
+		product, err := repo.AddProduct(context.Background(), "pvz-1", NewProduct{Type: "мебель", Attributes: attrs})
+		assert.Nil(t, product)
+		assert.ErrorIs(t, err, ErrInvalidProductAttributes)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestAddProduct_NoReception(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
-	database.DB = db
+	repo := NewPostgresProductRepository(db)
+
+	expectProductTypes(mock)
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-1")
 
 	mock.ExpectQuery(`SELECT id, status FROM receptions`).
 		WithArgs("pvz-1").
-		WillReturnError(errors.New("no rows"))
+		WillReturnError(sql.ErrNoRows)
 
-	product, err := AddProduct("pvz-1", "одежда")
+	mock.ExpectRollback()
+
+	product, err := repo.AddProduct(context.Background(), "pvz-1", NewProduct{Type: "одежда"})
 	assert.Nil(t, product)
 	assert.EqualError(t, err, "Нет активной приемки")
 }
 
+func TestAddProduct_ReceptionQueryError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresProductRepository(db)
+
+	expectProductTypes(mock)
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-1")
+
+	mock.ExpectQuery(`SELECT id, status FROM receptions`).
+		WithArgs("pvz-1").
+		WillReturnError(errors.New("connection reset"))
+
+	mock.ExpectRollback()
+
+	// Сбой БД не выдаётся за отсутствие приёмки
+	product, err := repo.AddProduct(context.Background(), "pvz-1", NewProduct{Type: "одежда"})
+	assert.Nil(t, product)
+	assert.EqualError(t, err, "connection reset")
+}
+
 func TestAddProduct_ReceptionClosed(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
-	database.DB = db
+	repo := NewPostgresProductRepository(db)
+
+	expectProductTypes(mock)
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-2")
 
 	mock.ExpectQuery(`SELECT id, status FROM receptions`).
 		WithArgs("pvz-2").
 		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("abc", "close"))
 
-	product, err := AddProduct("pvz-2", "обувь")
+	mock.ExpectRollback()
+
+	product, err := repo.AddProduct(context.Background(), "pvz-2", NewProduct{Type: "обувь"})
 	assert.Nil(t, product)
 	assert.EqualError(t, err, "Нет активной приемки")
 }
@@ -81,47 +157,132 @@ func TestAddProduct_InsertError(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	database.DB = db
+	repo := NewPostgresProductRepository(db)
+
+	expectProductTypes(mock)
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-3")
 
 	mock.ExpectQuery(`SELECT id, status FROM receptions`).
 		WithArgs("pvz-3").
 		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("r1", "in_progress"))
 
 	mock.ExpectExec(`INSERT INTO products`).
-		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "обувь", "r1", "pvz-3").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "обувь", "r1", "pvz-3", []byte("{}"), sqlmock.AnyArg()).
 		WillReturnError(errors.New("insert error"))
 
-	product, err := AddProduct("pvz-3", "обувь")
+	mock.ExpectRollback()
+
+	product, err := repo.AddProduct(context.Background(), "pvz-3", NewProduct{Type: "обувь"})
 	assert.Nil(t, product)
 	assert.EqualError(t, err, "insert error")
 }
 
+func TestAddProduct_DuplicateBarcode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresProductRepository(db)
+
+	expectProductTypes(mock)
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-4")
+
+	mock.ExpectQuery(`SELECT id, status FROM receptions`).
+		WithArgs("pvz-4").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("r4", "in_progress"))
+
+	mock.ExpectExec(`INSERT INTO products`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "обувь", "r4", "pvz-4", []byte("{}"), nullIfEmpty("4600000000001")).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: productsOpenBarcode})
+
+	mock.ExpectRollback()
+
+	product, err := repo.AddProduct(context.Background(), "pvz-4", NewProduct{Type: "обувь", Barcode: "4600000000001"})
+	assert.Nil(t, product)
+	assert.ErrorIs(t, err, ErrDuplicateBarcode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindProductByBarcode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresProductRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT pr\.id, .* FROM products pr JOIN receptions r .* WHERE pr\.barcode = \$1 AND pr\.deleted_at IS NULL ORDER BY pr\.reception_open DESC`).
+		WithArgs("ORDER-42").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "date_time", "type", "reception_id", "pvz_id", "attributes", "barcode",
+			"id", "date_time", "pvz_id", "status",
+			"id", "registration_date", "city", "name", "address", "active", "closed_at",
+		}).AddRow("p1", now, "обувь", "r1", "pvz-1", []byte("{}"), "ORDER-42",
+			"r1", now, "pvz-1", "in_progress",
+			"pvz-1", now, "Казань", "", "", true, nil))
+
+	loc, err := repo.FindProductByBarcode(context.Background(), "ORDER-42")
+	require.NoError(t, err)
+	assert.Equal(t, "ORDER-42", loc.Product.Barcode)
+	assert.Equal(t, "in_progress", loc.Reception.Status)
+	assert.Equal(t, "Казань", loc.PVZ.City)
+
+	mock.ExpectQuery(`FROM products pr`).WithArgs("missing").WillReturnError(sql.ErrNoRows)
+	_, err = repo.FindProductByBarcode(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrProductNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectProductTypes ожидает загрузку справочника типов в кэш. Кроме
+// переданных типов в нём всегда есть прежние три без атрибутов.
+func expectProductTypes(mock sqlmock.Sqlmock, types ...ProductType) {
+	rows := sqlmock.NewRows([]string{"code", "names", "attributes", "fragile", "created_at"})
+	for _, code := range []string{"электроника", "одежда", "обувь"} {
+		rows.AddRow(code, []byte("{}"), []byte("[]"), false, time.Now())
+	}
+	for _, t := range types {
+		names, attrs, _ := productTypeArgs(t)
+		rows.AddRow(t.Code, names, attrs, t.Fragile, time.Now())
+	}
+	mock.ExpectQuery(`SELECT code, names, attributes, fragile, created_at FROM product_types`).WillReturnRows(rows)
+}
+
 func TestDeleteLastProduct_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
-	database.DB = db
+	repo := NewPostgresProductRepository(db)
 	pvzID := "pvz-1"
 	receptionID := "reception-1"
 	productID := "product-1"
 
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzID)
+
 	// Получить последнюю приёмку
 	mock.ExpectQuery(`SELECT id, status FROM receptions`).
 		WithArgs(pvzID).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(receptionID, "in_progress"))
 
-	// Найти последний товар
-	mock.ExpectQuery(`SELECT id FROM products`).
+	// Найти последний неудалённый товар
+	mock.ExpectQuery(`SELECT id FROM products WHERE reception_id = \$1 AND deleted_at IS NULL`).
 		WithArgs(receptionID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(productID))
 
-	// Удалить товар
-	mock.ExpectExec(`DELETE FROM products`).
-		WithArgs(productID).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	// Пометить товар удалённым; строка остаётся в таблице
+	mock.ExpectQuery(`UPDATE products SET deleted_at = \$2, deleted_by = \$3 WHERE id = \$1 RETURNING id, date_time, type, reception_id, pvz_id, attributes, barcode, deleted_at, deleted_by`).
+		WithArgs(productID, sqlmock.AnyArg(), "system").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "type", "reception_id", "pvz_id", "attributes", "barcode", "deleted_at", "deleted_by"}).
+			AddRow(productID, time.Now(), "обувь", receptionID, pvzID, []byte("{}"), nil, time.Now(), "system"))
+
+	expectOutbox(mock)
+	expectAudit(mock)
+	mock.ExpectCommit()
 
-	err = DeleteLastProduct(pvzID)
+	err = repo.DeleteLastProduct(context.Background(), pvzID)
 	assert.NoError(t, err)
 }
 
@@ -130,28 +291,58 @@ func TestDeleteLastProduct_NoReception(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	database.DB = db
+	repo := NewPostgresProductRepository(db)
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-x")
 
 	mock.ExpectQuery(`SELECT id, status FROM receptions`).
 		WithArgs("pvz-x").
-		WillReturnError(errors.New("sql: no rows in result set"))
+		WillReturnError(sql.ErrNoRows)
+
+	mock.ExpectRollback()
 
-	err = DeleteLastProduct("pvz-x")
+	err = repo.DeleteLastProduct(context.Background(), "pvz-x")
 	assert.EqualError(t, err, "Нет активной приемки")
 }
 
+func TestDeleteLastProduct_ReceptionQueryError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresProductRepository(db)
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-x")
+
+	mock.ExpectQuery(`SELECT id, status FROM receptions`).
+		WithArgs("pvz-x").
+		WillReturnError(errors.New("connection reset"))
+
+	mock.ExpectRollback()
+
+	err = repo.DeleteLastProduct(context.Background(), "pvz-x")
+	assert.EqualError(t, err, "connection reset")
+}
+
 func TestDeleteLastProduct_ReceptionClosed(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
-	database.DB = db
+	repo := NewPostgresProductRepository(db)
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-y")
 
 	mock.ExpectQuery(`SELECT id, status FROM receptions`).
 		WithArgs("pvz-y").
 		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("reception-closed", "close"))
 
-	err = DeleteLastProduct("pvz-y")
+	mock.ExpectRollback()
+
+	err = repo.DeleteLastProduct(context.Background(), "pvz-y")
 	assert.EqualError(t, err, "Приемка уже закрыта")
 }
 
@@ -160,19 +351,24 @@ func TestDeleteLastProduct_NoProducts(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	database.DB = db
+	repo := NewPostgresProductRepository(db)
 	pvzID := "pvz-2"
 	receptionID := "reception-2"
 
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzID)
+
 	mock.ExpectQuery(`SELECT id, status FROM receptions`).
 		WithArgs(pvzID).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(receptionID, "in_progress"))
 
 	mock.ExpectQuery(`SELECT id FROM products`).
 		WithArgs(receptionID).
-		WillReturnError(errors.New("no products"))
+		WillReturnError(sql.ErrNoRows)
+
+	mock.ExpectRollback()
 
-	err = DeleteLastProduct(pvzID)
+	err = repo.DeleteLastProduct(context.Background(), pvzID)
 	assert.EqualError(t, err, "Нет товаров для удаления")
 }
 
@@ -181,11 +377,14 @@ func TestDeleteLastProduct_DeleteFails(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	database.DB = db
+	repo := NewPostgresProductRepository(db)
 	pvzID := "pvz-3"
 	receptionID := "reception-3"
 	productID := "product-3"
 
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzID)
+
 	mock.ExpectQuery(`SELECT id, status FROM receptions`).
 		WithArgs(pvzID).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(receptionID, "in_progress"))
@@ -194,10 +393,12 @@ func TestDeleteLastProduct_DeleteFails(t *testing.T) {
 		WithArgs(receptionID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(productID))
 
-	mock.ExpectExec(`DELETE FROM products`).
-		WithArgs(productID).
+	mock.ExpectQuery(`UPDATE products SET deleted_at`).
+		WithArgs(productID, sqlmock.AnyArg(), "system").
 		WillReturnError(errors.New("delete failed"))
 
-	err = DeleteLastProduct(pvzID)
+	mock.ExpectRollback()
+
+	err = repo.DeleteLastProduct(context.Background(), pvzID)
 	assert.EqualError(t, err, "delete failed")
 }
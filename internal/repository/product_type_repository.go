@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Виды значений атрибутов товара.
+const (
+	AttributeNumber = "number"
+	AttributeString = "string"
+)
+
+// AttributeSpec описывает атрибут товара определённого типа.
+type AttributeSpec struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Unit     string `json:"unit,omitempty"`
+	Required bool   `json:"required"`
+}
+
+// ProductType — тип товара из справочника.
+type ProductType struct {
+	Code       string            `json:"code"`
+	Names      map[string]string `json:"names"` // язык -> название
+	Attributes []AttributeSpec   `json:"attributes"`
+	Fragile    bool              `json:"fragile"`
+	CreatedAt  time.Time         `json:"createdAt"`
+}
+
+// ValidateSpec проверяет описание атрибутов перед сохранением типа.
+func (t ProductType) ValidateSpec() error {
+	seen := make(map[string]bool, len(t.Attributes))
+	for _, a := range t.Attributes {
+		if a.Name == "" {
+			return fmt.Errorf("%w: пустое имя атрибута", ErrInvalidProductTypeSpec)
+		}
+		if seen[a.Name] {
+			return fmt.Errorf("%w: атрибут %q описан дважды", ErrInvalidProductTypeSpec, a.Name)
+		}
+		seen[a.Name] = true
+		if a.Kind != AttributeNumber && a.Kind != AttributeString {
+			return fmt.Errorf("%w: неизвестный вид %q у атрибута %q", ErrInvalidProductTypeSpec, a.Kind, a.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateAttributes сверяет атрибуты товара с описанием типа: все
+// обязательные заданы, неизвестных нет, значения нужного вида.
+func (t ProductType) ValidateAttributes(attrs map[string]interface{}) error {
+	specs := make(map[string]AttributeSpec, len(t.Attributes))
+	for _, a := range t.Attributes {
+		specs[a.Name] = a
+		if _, ok := attrs[a.Name]; a.Required && !ok {
+			return fmt.Errorf("%w: не задан %q", ErrInvalidProductAttributes, a.Name)
+		}
+	}
+	for name, value := range attrs {
+		spec, ok := specs[name]
+		if !ok {
+			return fmt.Errorf("%w: неизвестный атрибут %q", ErrInvalidProductAttributes, name)
+		}
+		switch v := value.(type) {
+		case float64:
+			if spec.Kind != AttributeNumber || v < 0 {
+				return fmt.Errorf("%w: неверное значение %q", ErrInvalidProductAttributes, name)
+			}
+		case string:
+			if spec.Kind != AttributeString || v == "" {
+				return fmt.Errorf("%w: неверное значение %q", ErrInvalidProductAttributes, name)
+			}
+		default:
+			return fmt.Errorf("%w: неверное значение %q", ErrInvalidProductAttributes, name)
+		}
+	}
+	return nil
+}
+
+// Имена ограничений из миграции 0009.
+const (
+	productTypesPKey = "product_types_pkey"
+	productsTypeFKey = "products_type_fkey"
+)
+
+const productTypeColumns = "code, names, attributes, fragile, created_at"
+
+// PostgresProductTypeRepository хранит справочник типов товаров в PostgreSQL.
+type PostgresProductTypeRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresProductTypeRepository(db *sql.DB) *PostgresProductTypeRepository {
+	return &PostgresProductTypeRepository{db: db}
+}
+
+func scanProductType(row rowScanner) (ProductType, error) {
+	var t ProductType
+	var names, attrs []byte
+	if err := row.Scan(&t.Code, &names, &attrs, &t.Fragile, &t.CreatedAt); err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal(names, &t.Names); err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal(attrs, &t.Attributes); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// withCatalogDefaults заменяет nil-поля пустыми, как их хранит БД.
+func withCatalogDefaults(t ProductType) ProductType {
+	if t.Names == nil {
+		t.Names = map[string]string{}
+	}
+	if t.Attributes == nil {
+		t.Attributes = []AttributeSpec{}
+	}
+	return t
+}
+
+// productTypeArgs готовит JSONB-колонки типа к записи.
+func productTypeArgs(t ProductType) (names, attrs []byte, err error) {
+	t = withCatalogDefaults(t)
+	if names, err = json.Marshal(t.Names); err != nil {
+		return nil, nil, err
+	}
+	attrs, err = json.Marshal(t.Attributes)
+	return names, attrs, err
+}
+
+func (r *PostgresProductTypeRepository) ListProductTypes(ctx context.Context) ([]ProductType, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+productTypeColumns+" FROM product_types ORDER BY code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []ProductType{}
+	for rows.Next() {
+		t, err := scanProductType(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, rows.Err()
+}
+
+func (r *PostgresProductTypeRepository) CreateProductType(ctx context.Context, t ProductType) (*ProductType, error) {
+	names, attrs, err := productTypeArgs(t)
+	if err != nil {
+		return nil, err
+	}
+	var created ProductType
+	err = withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		created, err = scanProductType(tx.QueryRowContext(ctx, `
+            INSERT INTO product_types (code, names, attributes, fragile)
+            VALUES ($1, $2, $3, $4)
+            RETURNING `+productTypeColumns, t.Code, names, attrs, t.Fragile))
+		if isUniqueViolation(err, productTypesPKey) {
+			return ErrProductTypeExists
+		}
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: AuditProductTypeCreate, entityType: EntityProductType, entityId: t.Code, after: created,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateProductType заменяет названия, атрибуты и признак хрупкости типа.
+// Уже принятые товары не перепроверяются.
+func (r *PostgresProductTypeRepository) UpdateProductType(ctx context.Context, t ProductType) (*ProductType, error) {
+	names, attrs, err := productTypeArgs(t)
+	if err != nil {
+		return nil, err
+	}
+	var updated ProductType
+	err = withTx(ctx, r.db, func(tx *sql.Tx) error {
+		before, err := scanProductType(tx.QueryRowContext(ctx,
+			"SELECT "+productTypeColumns+" FROM product_types WHERE code = $1 FOR UPDATE", t.Code))
+		if err == sql.ErrNoRows {
+			return ErrProductTypeNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		updated, err = scanProductType(tx.QueryRowContext(ctx, `
+            UPDATE product_types SET names = $2, attributes = $3, fragile = $4
+            WHERE code = $1
+            RETURNING `+productTypeColumns, t.Code, names, attrs, t.Fragile))
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: AuditProductTypeUpdate, entityType: EntityProductType, entityId: t.Code, before: before, after: updated,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (r *PostgresProductTypeRepository) DeleteProductType(ctx context.Context, code string) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		before, err := scanProductType(tx.QueryRowContext(ctx,
+			"DELETE FROM product_types WHERE code = $1 RETURNING "+productTypeColumns, code))
+		if isForeignKeyViolation(err, productsTypeFKey) {
+			return ErrProductTypeInUse
+		}
+		if err == sql.ErrNoRows {
+			return ErrProductTypeNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: AuditProductTypeDelete, entityType: EntityProductType, entityId: code, before: before,
+		})
+	})
+}
+
+// ProductTypeCache кэширует справочник типов для проверки в AddProduct.
+// Работает так же, как CityCache.
+type ProductTypeCache struct {
+	ProductTypeRepository
+	ttl time.Duration
+
+	mu       sync.Mutex
+	types    map[string]ProductType
+	loadedAt time.Time
+}
+
+func NewProductTypeCache(repo ProductTypeRepository, ttl time.Duration) *ProductTypeCache {
+	return &ProductTypeCache{ProductTypeRepository: repo, ttl: ttl}
+}
+
+// ProductType возвращает тип по коду или ErrInvalidProductType.
+func (c *ProductTypeCache) ProductType(ctx context.Context, code string) (ProductType, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.types == nil || time.Since(c.loadedAt) > c.ttl {
+		types, err := c.ProductTypeRepository.ListProductTypes(ctx)
+		if err != nil {
+			return ProductType{}, err
+		}
+		c.types = make(map[string]ProductType, len(types))
+		for _, t := range types {
+			c.types[t.Code] = t
+		}
+		c.loadedAt = time.Now()
+	}
+	t, ok := c.types[code]
+	if !ok {
+		return ProductType{}, ErrInvalidProductType
+	}
+	return t, nil
+}
+
+func (c *ProductTypeCache) invalidate() {
+	c.mu.Lock()
+	c.types = nil
+	c.mu.Unlock()
+}
+
+func (c *ProductTypeCache) CreateProductType(ctx context.Context, t ProductType) (*ProductType, error) {
+	defer c.invalidate()
+	return c.ProductTypeRepository.CreateProductType(ctx, t)
+}
+
+func (c *ProductTypeCache) UpdateProductType(ctx context.Context, t ProductType) (*ProductType, error) {
+	defer c.invalidate()
+	return c.ProductTypeRepository.UpdateProductType(ctx, t)
+}
+
+func (c *ProductTypeCache) DeleteProductType(ctx context.Context, code string) error {
+	defer c.invalidate()
+	return c.ProductTypeRepository.DeleteProductType(ctx, code)
+}
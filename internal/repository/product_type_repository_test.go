@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductType_ValidateSpec(t *testing.T) {
+	for _, attrs := range [][]AttributeSpec{
+		{{Name: "", Kind: AttributeNumber}},
+		{{Name: "weight", Kind: AttributeNumber}, {Name: "weight", Kind: AttributeString}},
+		{{Name: "weight", Kind: "date"}},
+	} {
+		err := ProductType{Code: "мебель", Attributes: attrs}.ValidateSpec()
+		assert.ErrorIs(t, err, ErrInvalidProductTypeSpec, "атрибуты %v", attrs)
+	}
+	assert.NoError(t, ProductType{Code: "мебель"}.ValidateSpec())
+}
+
+func TestDeleteProductType_InUse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM product_types WHERE code = \$1 RETURNING`).
+		WithArgs("обувь").
+		WillReturnError(&pq.Error{Code: "23503", Constraint: productsTypeFKey})
+	mock.ExpectRollback()
+
+	err = NewPostgresProductTypeRepository(db).DeleteProductType(context.Background(), "обувь")
+	assert.ErrorIs(t, err, ErrProductTypeInUse)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateProductType_Exists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO product_types`).
+		WithArgs("обувь", []byte(`{}`), []byte(`[]`), false).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: productTypesPKey})
+	mock.ExpectRollback()
+
+	_, err = NewPostgresProductTypeRepository(db).CreateProductType(context.Background(), ProductType{Code: "обувь"})
+	assert.ErrorIs(t, err, ErrProductTypeExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingMatcher считает запросы, дошедшие до БД.
+type countingMatcher struct {
+	queries int64
+}
+
+func (m *countingMatcher) Match(expectedSQL, actualSQL string) error {
+	if err := sqlmock.QueryMatcherRegexp.Match(expectedSQL, actualSQL); err != nil {
+		return err
+	}
+	atomic.AddInt64(&m.queries, 1)
+	return nil
+}
+
+// expectPVZRecords настраивает ответы БД для pvzCount ПВЗ, у каждого
+// receptionsPerPVZ приёмок по productsPerReception товаров.
+func expectPVZRecords(mock sqlmock.Sqlmock, pvzCount, receptionsPerPVZ, productsPerReception int) {
+	now := time.Now()
+	pvzRows := newPVZRows()
+	recRows := sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status"})
+	prodRows := sqlmock.NewRows([]string{"id", "date_time", "type", "reception_id", "pvz_id", "attributes", "barcode", "deleted_at", "deleted_by"})
+	for p := 0; p < pvzCount; p++ {
+		pvzID := fmt.Sprintf("pvz-%d", p)
+		pvzRows.AddRow(pvzID, now, "Москва", "", "", true, nil)
+		for r := 0; r < receptionsPerPVZ; r++ {
+			recID := fmt.Sprintf("%s-rec-%d", pvzID, r)
+			recRows.AddRow(recID, now, pvzID, "close")
+			for i := 0; i < productsPerReception; i++ {
+				prodRows.AddRow(fmt.Sprintf("%s-prod-%d", recID, i), now, "обувь", recID, pvzID, nil, nil, nil, nil)
+			}
+		}
+	}
+	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city, p\.name, p\.address, p\.active, p\.closed_at FROM pvz p`).WillReturnRows(pvzRows)
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).WillReturnRows(recRows)
+	mock.ExpectQuery(`SELECT id, date_time, type, reception_id, pvz_id, attributes, barcode, deleted_at, deleted_by FROM products`).WillReturnRows(prodRows)
+}
+
+func TestGetPVZRecords_QueryCountIsConstant(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	end := time.Now()
+
+	for _, size := range []struct{ pvz, receptions, products int }{
+		{1, 1, 1},
+		{10, 50, 5},
+	} {
+		matcher := &countingMatcher{}
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(matcher))
+		require.NoError(t, err)
+
+		expectPVZRecords(mock, size.pvz, size.receptions, size.products)
+		records, err := NewPostgresPVZRepository(db).GetPVZRecords(context.Background(), &start, &end, false, 1, size.pvz)
+		require.NoError(t, err)
+
+		require.Len(t, records, size.pvz)
+		for _, rec := range records {
+			require.Len(t, rec.Receptions, size.receptions)
+			for _, r := range rec.Receptions {
+				assert.Len(t, r.Products, size.products)
+				assert.Equal(t, rec.PVZ.ID, r.Reception.PVZId)
+			}
+		}
+		assert.EqualValues(t, 3, matcher.queries, "ПВЗ=%d, приёмок=%d", size.pvz, size.receptions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	}
+}
+
+func TestGetPVZRecords_NoReceptionsSkipsProductsQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	start, end := time.Now(), time.Now()
+	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city, p\.name, p\.address, p\.active, p\.closed_at FROM pvz p`).
+		WillReturnRows(newPVZRows().AddRow("pvz-1", time.Now(), "Казань", "", "", true, nil))
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status"}))
+
+	records, err := NewPostgresPVZRepository(db).GetPVZRecords(context.Background(), &start, &end, false, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Empty(t, records[0].Receptions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// BenchmarkGetPVZRecords показывает, что число запросов (queries/op)
+// не зависит от количества ПВЗ, приёмок и товаров.
+func BenchmarkGetPVZRecords(b *testing.B) {
+	start := time.Now().Add(-time.Hour)
+	end := time.Now()
+
+	for _, size := range []struct{ pvz, receptions, products int }{
+		{1, 1, 1},
+		{10, 10, 10},
+		{10, 50, 10},
+	} {
+		b.Run(fmt.Sprintf("pvz=%d/receptions=%d/products=%d", size.pvz, size.receptions, size.products), func(b *testing.B) {
+			matcher := &countingMatcher{}
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(matcher))
+			require.NoError(b, err)
+			defer db.Close()
+			repo := NewPostgresPVZRepository(db)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				expectPVZRecords(mock, size.pvz, size.receptions, size.products)
+				b.StartTimer()
+
+				if _, err := repo.GetPVZRecords(context.Background(), &start, &end, false, 1, size.pvz); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(matcher.queries)/float64(b.N), "queries/op")
+		})
+	}
+}
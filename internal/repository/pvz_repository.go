@@ -1,23 +1,49 @@
 package repository
 
 import (
-	"avito-pvz-service/internal/database"
+	"context"
+	"database/sql"
 	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type PVZ struct {
-	ID               string    `json:"id"`
-	RegistrationDate time.Time `json:"registration_date"`
-	City             string    `json:"city"`
+	ID               string     `json:"id"`
+	RegistrationDate time.Time  `json:"registration_date"`
+	City             string     `json:"city"`
+	Name             string     `json:"name"`
+	Address          string     `json:"address"`
+	Active           bool       `json:"active"`
+	ClosedAt         *time.Time `json:"closed_at,omitempty"`
 }
 
-var allowedCities = map[string]bool{
-	"Москва":          true,
-	"Санкт-Петербург": true,
-	"Казань":          true,
+// PVZUpdate — частичное изменение ПВЗ: nil-поля не меняются.
+// Active=false деактивирует ПВЗ и проставляет closed_at, Active=true
+// возвращает его в работу.
+type PVZUpdate struct {
+	Name    *string
+	Address *string
+	Active  *bool
+}
+
+// pvzColumns — колонки ПВЗ в порядке, который ожидает scanPVZ.
+const pvzColumns = "p.id, p.registration_date, p.city, p.name, p.address, p.active, p.closed_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPVZ(row rowScanner) (PVZ, error) {
+	var p PVZ
+	var closedAt sql.NullTime
+	err := row.Scan(&p.ID, &p.RegistrationDate, &p.City, &p.Name, &p.Address, &p.Active, &closedAt)
+	if closedAt.Valid {
+		p.ClosedAt = &closedAt.Time
+	}
+	return p, err
 }
 
 // PVZRecord объединяет данные ПВЗ и связанные с ним приёмки.
@@ -32,102 +58,215 @@ type ReceptionRecord struct {
 	Products  []Product `json:"products"`
 }
 
-func CreatePVZ(city string) (*PVZ, error) {
-	if !allowedCities[city] {
-		return nil, errors.New("ПВЗ можно завести только в Москве, Санкт-Петербурге или Казани")
-	}
+// PostgresPVZRepository хранит ПВЗ в PostgreSQL.
+type PostgresPVZRepository struct {
+	db     *sql.DB
+	cities *CityCache
+}
 
-	id := uuid.New().String()
-	registrationDate := time.Now()
+func NewPostgresPVZRepository(db *sql.DB) *PostgresPVZRepository {
+	return &PostgresPVZRepository{db: db, cities: NewCityCache(NewPostgresCityRepository(db), catalogCacheTTL)}
+}
 
-	query := "INSERT INTO pvz (id, registration_date, city) VALUES ($1, $2, $3)"
-	_, err := database.DB.Exec(query, id, registrationDate, city)
+func (r *PostgresPVZRepository) CreatePVZ(ctx context.Context, city string) (*PVZ, error) {
+	enabled, err := r.cities.IsCityEnabled(ctx, city)
 	if err != nil {
 		return nil, err
 	}
+	if !enabled {
+		return nil, ErrCityNotAllowed
+	}
+
+	id := uuid.New().String()
+	registrationDate := time.Now()
 
-	return &PVZ{
+	pvz := &PVZ{
 		ID:               id,
 		RegistrationDate: registrationDate,
 		City:             city,
-	}, nil
+		Active:           true,
+	}
+	err = withTx(ctx, r.db, func(tx *sql.Tx) error {
+		query := "INSERT INTO pvz (id, registration_date, city) VALUES ($1, $2, $3)"
+		_, err := tx.ExecContext(ctx, query, id, registrationDate, city)
+		if isForeignKeyViolation(err, pvzCityFKey) {
+			// Город удалили после загрузки кэша
+			return ErrCityNotAllowed
+		}
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{action: AuditPVZCreate, entityType: EntityPVZ, entityId: id, after: pvz})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pvz, nil
 }
 
-func GetPVZRecords(startDate, endDate *time.Time, page, limit int) ([]PVZRecord, error) {
+// GetPVZRecords возвращает страницу по номеру (OFFSET). Оставлен для
+// совместимости, новые клиенты используют GetPVZRecordsPage. Удалённые
+// товары попадают в ответ только при includeDeleted.
+func (r *PostgresPVZRepository) GetPVZRecords(ctx context.Context, startDate, endDate *time.Time, includeDeleted bool, page, limit int) ([]PVZRecord, error) {
 	if startDate == nil || endDate == nil {
 		return nil, errors.New("startDate and endDate parameters are required")
 	}
 	offset := (page - 1) * limit
 
 	// Извлекаем список уникальных ПВЗ, у которых есть приёмки в указанном диапазоне.
-	rows, err := database.DB.Query(`
-        SELECT DISTINCT p.id, p.registration_date, p.city
+	pvzs, err := r.queryPVZ(ctx, `
+        SELECT DISTINCT `+pvzColumns+`
         FROM pvz p
         JOIN receptions r ON p.id = r.pvz_id
-        WHERE r.date_time BETWEEN $1 AND $2
-        ORDER BY p.registration_date DESC
+        WHERE r.date_time BETWEEN $1 AND $2 AND r.status <> 'cancelled'
+        ORDER BY p.registration_date DESC, p.id DESC
         OFFSET $3 LIMIT $4`,
 		*startDate, *endDate, offset, limit)
 	if err != nil {
 		return nil, err
 	}
+	return r.loadRecords(ctx, pvzs, *startDate, *endDate, includeDeleted)
+}
+
+// GetPVZRecordsPage возвращает страницу ПВЗ строго после курсора after
+// (nil — с начала) в порядке (registration_date, id) по убыванию.
+// Курсор следующей страницы равен nil, если страница последняя.
+func (r *PostgresPVZRepository) GetPVZRecordsPage(ctx context.Context, startDate, endDate *time.Time, includeDeleted bool, after *PVZCursor, limit int) ([]PVZRecord, *PVZCursor, error) {
+	if startDate == nil || endDate == nil {
+		return nil, nil, errors.New("startDate and endDate parameters are required")
+	}
+	limit = clampLimit(limit)
+
+	// Берём на одну запись больше, чтобы понять, есть ли следующая страница.
+	var pvzs []PVZ
+	var err error
+	if after == nil {
+		pvzs, err = r.queryPVZ(ctx, `
+            SELECT DISTINCT `+pvzColumns+`
+            FROM pvz p
+            JOIN receptions r ON p.id = r.pvz_id
+            WHERE r.date_time BETWEEN $1 AND $2 AND r.status <> 'cancelled'
+            ORDER BY p.registration_date DESC, p.id DESC
+            LIMIT $3`,
+			*startDate, *endDate, limit+1)
+	} else {
+		pvzs, err = r.queryPVZ(ctx, `
+            SELECT DISTINCT `+pvzColumns+`
+            FROM pvz p
+            JOIN receptions r ON p.id = r.pvz_id
+            WHERE r.date_time BETWEEN $1 AND $2 AND r.status <> 'cancelled'
+              AND (p.registration_date, p.id) < ($3, $4)
+            ORDER BY p.registration_date DESC, p.id DESC
+            LIMIT $5`,
+			*startDate, *endDate, after.RegistrationDate, after.ID, limit+1)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *PVZCursor
+	if len(pvzs) > limit {
+		pvzs = pvzs[:limit]
+		next = cursorOf(pvzs[limit-1])
+	}
+	records, err := r.loadRecords(ctx, pvzs, *startDate, *endDate, includeDeleted)
+	if err != nil {
+		return nil, nil, err
+	}
+	return records, next, nil
+}
+
+func (r *PostgresPVZRepository) queryPVZ(ctx context.Context, query string, args ...interface{}) ([]PVZ, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	var records []PVZRecord
+	var pvzs []PVZ
 	for rows.Next() {
-		var pvz PVZ
-		err := rows.Scan(&pvz.ID, &pvz.RegistrationDate, &pvz.City)
+		pvz, err := scanPVZ(rows)
 		if err != nil {
 			return nil, err
 		}
+		pvzs = append(pvzs, pvz)
+	}
+	return pvzs, rows.Err()
+}
 
-		// Извлекаем приёмки для данного ПВЗ в указанном диапазоне.
-		recRows, err := database.DB.Query(`
-            SELECT id, date_time, pvz_id, status
-            FROM receptions
-            WHERE pvz_id = $1 AND date_time BETWEEN $2 AND $3
-            ORDER BY date_time DESC`,
-			pvz.ID, *startDate, *endDate)
+// loadRecords дополняет страницу ПВЗ приёмками и товарами за фиксированное
+// число запросов: все приёмки одним запросом по ANY($1), затем все товары
+// этих приёмок тоже одним запросом.
+func (r *PostgresPVZRepository) loadRecords(ctx context.Context, pvzs []PVZ, startDate, endDate time.Time, includeDeleted bool) ([]PVZRecord, error) {
+	if len(pvzs) == 0 {
+		return nil, nil
+	}
+	pvzIds := make([]string, 0, len(pvzs))
+	for _, pvz := range pvzs {
+		pvzIds = append(pvzIds, pvz.ID)
+	}
+
+	// Все приёмки выбранных ПВЗ в указанном диапазоне.
+	recRows, err := r.db.QueryContext(ctx, `
+        SELECT id, date_time, pvz_id, status
+        FROM receptions
+        WHERE pvz_id = ANY($1) AND date_time BETWEEN $2 AND $3 AND status <> 'cancelled'
+        ORDER BY date_time DESC`,
+		pq.Array(pvzIds), startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	receptionsByPVZ := make(map[string][]Reception)
+	var receptionIds []string
+	for recRows.Next() {
+		var rec Reception
+		if err := recRows.Scan(&rec.ID, &rec.DateTime, &rec.PVZId, &rec.Status); err != nil {
+			recRows.Close()
+			return nil, err
+		}
+		receptionsByPVZ[rec.PVZId] = append(receptionsByPVZ[rec.PVZId], rec)
+		receptionIds = append(receptionIds, rec.ID)
+	}
+	recRows.Close()
+	if err := recRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Все товары найденных приёмок.
+	productsByReception := make(map[string][]Product)
+	if len(receptionIds) > 0 {
+		prodRows, err := r.db.QueryContext(ctx, `
+            SELECT `+productColumns+`
+            FROM products
+            WHERE reception_id = ANY($1) AND ($2 OR deleted_at IS NULL)
+            ORDER BY date_time ASC`,
+			pq.Array(receptionIds), includeDeleted)
 		if err != nil {
 			return nil, err
 		}
-		var receptions []ReceptionRecord
-		for recRows.Next() {
-			var rec Reception
-			err := recRows.Scan(&rec.ID, &rec.DateTime, &rec.PVZId, &rec.Status)
+		for prodRows.Next() {
+			prod, err := scanProduct(prodRows)
 			if err != nil {
-				recRows.Close()
+				prodRows.Close()
 				return nil, err
 			}
-			// Извлекаем товары для данной приёмки.
-			prodRows, err := database.DB.Query(`
-                SELECT id, date_time, type, reception_id, pvz_id
-                FROM products
-                WHERE reception_id = $1
-                ORDER BY date_time ASC`, rec.ID)
-			if err != nil {
-				recRows.Close()
-				return nil, err
-			}
-			var products []Product
-			for prodRows.Next() {
-				var prod Product
-				err := prodRows.Scan(&prod.ID, &prod.DateTime, &prod.Type, &prod.ReceptionId, &prod.PVZId)
-				if err != nil {
-					prodRows.Close()
-					recRows.Close()
-					return nil, err
-				}
-				products = append(products, prod)
-			}
-			prodRows.Close()
+			productsByReception[prod.ReceptionId] = append(productsByReception[prod.ReceptionId], prod)
+		}
+		prodRows.Close()
+		if err := prodRows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	records := make([]PVZRecord, 0, len(pvzs))
+	for _, pvz := range pvzs {
+		var receptions []ReceptionRecord
+		for _, rec := range receptionsByPVZ[pvz.ID] {
 			receptions = append(receptions, ReceptionRecord{
 				Reception: rec,
-				Products:  products,
+				Products:  productsByReception[rec.ID],
 			})
 		}
-		recRows.Close()
-
 		records = append(records, PVZRecord{
 			PVZ:        pvz,
 			Receptions: receptions,
@@ -137,20 +276,93 @@ func GetPVZRecords(startDate, endDate *time.Time, page, limit int) ([]PVZRecord,
 	return records, nil
 }
 
-func GetAllPVZ() ([]PVZ, error) {
-    rows, err := database.DB.Query("SELECT id, registration_date, city FROM pvz")
-    if err != nil {
-        return nil, err
-    }
-    defer rows.Close()
-
-    var result []PVZ
-    for rows.Next() {
-        var p PVZ
-        if err := rows.Scan(&p.ID, &p.RegistrationDate, &p.City); err != nil {
-            return nil, err
-        }
-        result = append(result, p)
-    }
-    return result, nil
-}
\ No newline at end of file
+func (r *PostgresPVZRepository) GetAllPVZ(ctx context.Context) ([]PVZ, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+pvzColumns+" FROM pvz p")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []PVZ
+	for rows.Next() {
+		p, err := scanPVZ(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (r *PostgresPVZRepository) GetPVZ(ctx context.Context, id string) (*PVZ, error) {
+	p, err := scanPVZ(r.db.QueryRowContext(ctx, "SELECT "+pvzColumns+" FROM pvz p WHERE p.id = $1", id))
+	if err == sql.ErrNoRows || isInvalidText(err) {
+		return nil, ErrPVZNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PostgresPVZRepository) UpdatePVZ(ctx context.Context, id string, upd PVZUpdate) (*PVZ, error) {
+	var p PVZ
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		before, err := scanPVZ(tx.QueryRowContext(ctx, "SELECT "+pvzColumns+" FROM pvz p WHERE p.id = $1 FOR UPDATE", id))
+		if err == sql.ErrNoRows || isInvalidText(err) {
+			return ErrPVZNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// closed_at фиксирует первую деактивацию и сбрасывается при возврате в работу
+		p, err = scanPVZ(tx.QueryRowContext(ctx, `
+        UPDATE pvz p SET
+            name = COALESCE($2, p.name),
+            address = COALESCE($3, p.address),
+            active = COALESCE($4, p.active),
+            closed_at = CASE
+                WHEN $4::boolean IS NULL THEN p.closed_at
+                WHEN $4::boolean THEN NULL
+                ELSE COALESCE(p.closed_at, NOW())
+            END
+        WHERE p.id = $1
+        RETURNING `+pvzColumns, id, upd.Name, upd.Address, upd.Active))
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{action: AuditPVZUpdate, entityType: EntityPVZ, entityId: id, before: before, after: p})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DeletePVZ удаляет ПВЗ вместе с историей приёмок. ПВЗ с незакрытой
+// приёмкой удалить нельзя — сначала её нужно закрыть.
+func (r *PostgresPVZRepository) DeletePVZ(ctx context.Context, id string) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, _, err := lockPVZ(ctx, tx, id); err != nil {
+			return err
+		}
+
+		var open bool
+		err := tx.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM receptions WHERE pvz_id = $1 AND status IN ('in_progress', 'reopened'))", id).
+			Scan(&open)
+		if err != nil {
+			return err
+		}
+		if open {
+			return ErrPVZHasOpenReception
+		}
+
+		before, err := scanPVZ(tx.QueryRowContext(ctx, "DELETE FROM pvz p WHERE p.id = $1 RETURNING "+pvzColumns, id))
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{action: AuditPVZDelete, entityType: EntityPVZ, entityId: id, before: before})
+	})
+}
@@ -1,12 +1,13 @@
 package repository
 
 import (
-	"avito-pvz-service/internal/database"
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -16,28 +17,39 @@ func TestCreatePVZ_AllowedCity(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	// временно подменим базу
-	original := database.DB
-	database.DB = db
-	defer func() { database.DB = original }()
+	repo := NewPostgresPVZRepository(db)
 
 	city := "Москва"
 
+	expectEnabledCities(mock, city)
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO pvz").
 		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), city).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(mock)
+	mock.ExpectCommit()
 
-	pvz, err := CreatePVZ(city)
+	pvz, err := repo.CreatePVZ(context.Background(), city)
 	require.NoError(t, err)
 	assert.Equal(t, city, pvz.City)
 	assert.WithinDuration(t, time.Now(), pvz.RegistrationDate, time.Second)
 }
 
 func TestCreatePVZ_DisallowedCity(t *testing.T) {
-	// этот тест не использует базу, можно без моков
-	pvz, err := CreatePVZ("Новосибирск")
-	assert.Nil(t, pvz)
-	assert.EqualError(t, err, "ПВЗ можно завести только в Москве, Санкт-Петербурге или Казани")
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresPVZRepository(db)
+
+	// Справочник читается один раз и дальше берётся из кэша
+	expectEnabledCities(mock, "Москва", "Казань")
+	for i := 0; i < 2; i++ {
+		pvz, err := repo.CreatePVZ(context.Background(), "Новосибирск")
+		assert.Nil(t, pvz)
+		assert.ErrorIs(t, err, ErrCityNotAllowed)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestCreatePVZ_SQLFail(t *testing.T) {
@@ -45,15 +57,16 @@ func TestCreatePVZ_SQLFail(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	original := database.DB
-	database.DB = db
-	defer func() { database.DB = original }()
+	repo := NewPostgresPVZRepository(db)
 
+	expectEnabledCities(mock, "Казань")
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO pvz").
 		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "Казань").
 		WillReturnError(errors.New("db insert failed"))
+	mock.ExpectRollback()
 
-	pvz, err := CreatePVZ("Казань")
+	pvz, err := repo.CreatePVZ(context.Background(), "Казань")
 	assert.Nil(t, pvz)
 	assert.EqualError(t, err, "db insert failed")
 }
@@ -62,29 +75,29 @@ func TestGetPVZRecords_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
-	database.DB = db
+	repo := NewPostgresPVZRepository(db)
 
 	start := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
 	end := time.Date(2025, 4, 17, 23, 59, 59, 0, time.UTC)
 
 	// ПВЗ
-	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city FROM pvz p JOIN receptions r ON p\.id = r\.pvz_id`).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "registration_date", "city"}).
-			AddRow("pvz-1", time.Now(), "Москва"))
+	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city, p\.name, p\.address, p\.active, p\.closed_at FROM pvz p JOIN receptions r ON p\.id = r\.pvz_id`).
+		WillReturnRows(newPVZRows().
+			AddRow("pvz-1", time.Now(), "Москва", "", "", true, nil))
 
 	// Приёмки
-	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
-		WithArgs("pvz-1", start, end).
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions WHERE pvz_id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{"pvz-1"}), start, end).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status"}).
 			AddRow("reception-1", time.Now(), "pvz-1", "in_progress"))
 
 	// Товары
-	mock.ExpectQuery(`SELECT id, date_time, type, reception_id, pvz_id FROM products WHERE reception_id = .* ORDER BY date_time ASC`).
-		WithArgs("reception-1").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "type", "reception_id", "pvz_id"}).
-			AddRow("product-1", time.Now(), "одежда", "reception-1", "pvz-1"))
+	mock.ExpectQuery(`SELECT id, date_time, type, reception_id, pvz_id, attributes, barcode, deleted_at, deleted_by FROM products WHERE reception_id = ANY\(\$1\) AND \(\$2 OR deleted_at IS NULL\) ORDER BY date_time ASC`).
+		WithArgs(pq.Array([]string{"reception-1"}), false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "type", "reception_id", "pvz_id", "attributes", "barcode", "deleted_at", "deleted_by"}).
+			AddRow("product-1", time.Now(), "одежда", "reception-1", "pvz-1", []byte(`{"вес": 0.5}`), "A-1", nil, nil))
 
-	result, err := GetPVZRecords(&start, &end, 1, 10)
+	result, err := repo.GetPVZRecords(context.Background(), &start, &end, false, 1, 10)
 	require.NoError(t, err)
 	require.Len(t, result, 1)
 
@@ -93,20 +106,21 @@ func TestGetPVZRecords_Success(t *testing.T) {
 	require.Len(t, rec.Receptions, 1)
 	assert.Equal(t, "reception-1", rec.Receptions[0].Reception.ID)
 	assert.Equal(t, "одежда", rec.Receptions[0].Products[0].Type)
+	assert.Equal(t, "A-1", rec.Receptions[0].Products[0].Barcode)
 }
 
 func TestGetPVZRecords_PVZQueryFails(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
-	database.DB = db
+	repo := NewPostgresPVZRepository(db)
 
 	start, end := time.Now(), time.Now()
 
-	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city FROM pvz p JOIN receptions r ON p\.id = r\.pvz_id`).
+	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city, p\.name, p\.address, p\.active, p\.closed_at FROM pvz p JOIN receptions r ON p\.id = r\.pvz_id`).
 		WillReturnError(errors.New("pvz error"))
 
-	result, err := GetPVZRecords(&start, &end, 1, 10)
+	result, err := repo.GetPVZRecords(context.Background(), &start, &end, false, 1, 10)
 	assert.Nil(t, result)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "pvz error")
@@ -116,19 +130,19 @@ func TestGetPVZRecords_ReceptionsQueryFails(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
-	database.DB = db
+	repo := NewPostgresPVZRepository(db)
 
 	start, end := time.Now(), time.Now()
 
-	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city FROM pvz p JOIN receptions r ON p\.id = r\.pvz_id`).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "registration_date", "city"}).
-			AddRow("pvz-1", time.Now(), "Москва"))
+	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city, p\.name, p\.address, p\.active, p\.closed_at FROM pvz p JOIN receptions r ON p\.id = r\.pvz_id`).
+		WillReturnRows(newPVZRows().
+			AddRow("pvz-1", time.Now(), "Москва", "", "", true, nil))
 
-	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
-		WithArgs("pvz-1", start, end).
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions WHERE pvz_id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{"pvz-1"}), start, end).
 		WillReturnError(errors.New("reception error"))
 
-	result, err := GetPVZRecords(&start, &end, 1, 10)
+	result, err := repo.GetPVZRecords(context.Background(), &start, &end, false, 1, 10)
 	assert.Nil(t, result)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "reception error")
@@ -138,25 +152,131 @@ func TestGetPVZRecords_ProductsQueryFails(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
-	database.DB = db
+	repo := NewPostgresPVZRepository(db)
 
 	start, end := time.Now(), time.Now()
 
-	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city FROM pvz p JOIN receptions r ON p\.id = r\.pvz_id`).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "registration_date", "city"}).
-			AddRow("pvz-1", time.Now(), "Москва"))
+	mock.ExpectQuery(`SELECT DISTINCT p\.id, p\.registration_date, p\.city, p\.name, p\.address, p\.active, p\.closed_at FROM pvz p JOIN receptions r ON p\.id = r\.pvz_id`).
+		WillReturnRows(newPVZRows().
+			AddRow("pvz-1", time.Now(), "Москва", "", "", true, nil))
 
-	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
-		WithArgs("pvz-1", start, end).
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions WHERE pvz_id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{"pvz-1"}), start, end).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status"}).
 			AddRow("reception-1", time.Now(), "pvz-1", "in_progress"))
 
-	mock.ExpectQuery(`SELECT id, date_time, type, reception_id, pvz_id FROM products WHERE reception_id = .* ORDER BY date_time ASC`).
-		WithArgs("reception-1").
+	mock.ExpectQuery(`SELECT id, date_time, type, reception_id, pvz_id, attributes, barcode, deleted_at, deleted_by FROM products WHERE reception_id = ANY\(\$1\) AND \(\$2 OR deleted_at IS NULL\) ORDER BY date_time ASC`).
+		WithArgs(pq.Array([]string{"reception-1"}), false).
 		WillReturnError(errors.New("product error"))
 
-	result, err := GetPVZRecords(&start, &end, 1, 10)
+	result, err := repo.GetPVZRecords(context.Background(), &start, &end, false, 1, 10)
 	assert.Nil(t, result)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "product error")
-}
\ No newline at end of file
+}
+// expectEnabledCities ожидает загрузку включённых городов в кэш справочника.
+func expectEnabledCities(mock sqlmock.Sqlmock, names ...string) {
+	rows := sqlmock.NewRows([]string{"name", "region_code", "timezone", "enabled", "created_at"})
+	for _, name := range names {
+		rows.AddRow(name, "00", "Europe/Moscow", true, time.Now())
+	}
+	mock.ExpectQuery(`SELECT name, region_code, timezone, enabled, created_at FROM cities WHERE enabled OR \$1`).
+		WithArgs(false).
+		WillReturnRows(rows)
+}
+
+// newPVZRows — строки ПВЗ с колонками из pvzColumns.
+func newPVZRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "registration_date", "city", "name", "address", "active", "closed_at"})
+}
+
+func TestGetPVZ(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewPostgresPVZRepository(db)
+
+	closedAt := time.Now()
+	mock.ExpectQuery(`SELECT p\.id, .* FROM pvz p WHERE p\.id = \$1`).
+		WithArgs("pvz-1").
+		WillReturnRows(newPVZRows().AddRow("pvz-1", time.Now(), "Казань", "На Баумана", "ул. Баумана, 1", false, closedAt))
+
+	pvz, err := repo.GetPVZ(context.Background(), "pvz-1")
+	require.NoError(t, err)
+	assert.Equal(t, "На Баумана", pvz.Name)
+	assert.False(t, pvz.Active)
+	require.NotNil(t, pvz.ClosedAt)
+	assert.Equal(t, closedAt, *pvz.ClosedAt)
+
+	mock.ExpectQuery(`SELECT p\.id, .* FROM pvz p WHERE p\.id = \$1`).
+		WithArgs("pvz-2").
+		WillReturnRows(newPVZRows())
+
+	_, err = repo.GetPVZ(context.Background(), "pvz-2")
+	assert.ErrorIs(t, err, ErrPVZNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdatePVZ_Deactivate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewPostgresPVZRepository(db)
+
+	name, active := "Новое имя", false
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT p\.id, .* FROM pvz p WHERE p\.id = \$1 FOR UPDATE`).
+		WithArgs("pvz-1").
+		WillReturnRows(newPVZRows().AddRow("pvz-1", time.Now(), "Москва", "", "", true, nil))
+	mock.ExpectQuery(`UPDATE pvz p SET .* RETURNING p\.id`).
+		WithArgs("pvz-1", &name, nil, &active).
+		WillReturnRows(newPVZRows().AddRow("pvz-1", time.Now(), "Москва", name, "", false, time.Now()))
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	pvz, err := repo.UpdatePVZ(context.Background(), "pvz-1", PVZUpdate{Name: &name, Active: &active})
+	require.NoError(t, err)
+	assert.Equal(t, name, pvz.Name)
+	assert.False(t, pvz.Active)
+	assert.NotNil(t, pvz.ClosedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeletePVZ_OpenReception(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewPostgresPVZRepository(db)
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-1")
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM receptions WHERE pvz_id = \$1 AND status IN \('in_progress', 'reopened'\)\)`).
+		WithArgs("pvz-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err = repo.DeletePVZ(context.Background(), "pvz-1")
+	assert.ErrorIs(t, err, ErrPVZHasOpenReception)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeletePVZ_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewPostgresPVZRepository(db)
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-1")
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs("pvz-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`DELETE FROM pvz p WHERE p\.id = \$1 RETURNING`).
+		WithArgs("pvz-1").
+		WillReturnRows(newPVZRows().AddRow("pvz-1", time.Now(), "Москва", "", "", true, nil))
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.DeletePVZ(context.Background(), "pvz-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Причины закрытия приёмки.
+const (
+	CloseReasonManual      = "manual"
+	CloseReasonAutoTimeout = "auto_timeout"
+)
+
+// errNotStale — приёмка перестала быть зависшей, пока ждали блокировку ПВЗ.
+var errNotStale = errors.New("reception is not stale")
+
+// CloseStaleReceptions закрывает с причиной CloseReasonAutoTimeout приёмки
+// in_progress, в которых последний товар (или сама приёмка, если товаров нет)
+// старше idleBefore, и возвращает закрытые приёмки. Удалённые товары
+// активностью не считаются. Переоткрытые модератором
+// приёмки не трогаются: их закрывают вручную.
+func (r *PostgresReceptionRepository) CloseStaleReceptions(ctx context.Context, idleBefore time.Time) ([]Reception, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT r.pvz_id
+        FROM receptions r
+        WHERE r.status = 'in_progress'
+          AND COALESCE((SELECT MAX(p.date_time) FROM products p WHERE p.reception_id = r.id AND p.deleted_at IS NULL), r.date_time) < $1`,
+		idleBefore)
+	if err != nil {
+		return nil, err
+	}
+	var pvzIds []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pvzIds = append(pvzIds, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var closed []Reception
+	for _, pvzId := range pvzIds {
+		reception, err := r.closeLast(ctx, pvzId, CloseReasonAutoTimeout, &idleBefore)
+		switch {
+		case err == nil:
+			closed = append(closed, *reception)
+		case errors.Is(err, errNotStale), errors.Is(err, ErrReceptionClosed), errors.Is(err, ErrNoReceptionToClose):
+			// Приёмку закрыли или дополнили параллельно
+		default:
+			return closed, err
+		}
+	}
+	return closed, nil
+}
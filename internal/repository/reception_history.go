@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ReceptionFilter — условия выборки истории приёмок ПВЗ. Пустые поля
+// не ограничивают выборку.
+type ReceptionFilter struct {
+	Status    string
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+// receptionColumns — колонки приёмки для ручек истории, вместе с причиной закрытия.
+const receptionColumns = "id, date_time, pvz_id, status, COALESCE(close_reason, '')"
+
+// GetReception возвращает приёмку в любом статусе вместе с её товарами.
+func (r *PostgresReceptionRepository) GetReception(ctx context.Context, id string) (*ReceptionRecord, error) {
+	var rec Reception
+	err := r.db.QueryRowContext(ctx, "SELECT "+receptionColumns+" FROM receptions WHERE id = $1", id).
+		Scan(&rec.ID, &rec.DateTime, &rec.PVZId, &rec.Status, &rec.CloseReason)
+	if err == sql.ErrNoRows || isInvalidText(err) {
+		return nil, ErrReceptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.withProducts(ctx, rec)
+}
+
+// CurrentReception возвращает открытую приёмку ПВЗ с товарами
+// или ErrNoActiveReception.
+func (r *PostgresReceptionRepository) CurrentReception(ctx context.Context, pvzId string) (*ReceptionRecord, error) {
+	var rec Reception
+	err := r.db.QueryRowContext(ctx, `
+        SELECT `+receptionColumns+`
+        FROM receptions
+        WHERE pvz_id = $1 AND status IN ('in_progress', 'reopened')
+        ORDER BY date_time DESC
+        LIMIT 1`, pvzId).Scan(&rec.ID, &rec.DateTime, &rec.PVZId, &rec.Status, &rec.CloseReason)
+	if err == sql.ErrNoRows || isInvalidText(err) {
+		return nil, ErrNoActiveReception
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.withProducts(ctx, rec)
+}
+
+// ListReceptions возвращает страницу page приёмок ПВЗ, новые первыми, без товаров.
+func (r *PostgresReceptionRepository) ListReceptions(ctx context.Context, pvzId string, f ReceptionFilter, page, limit int) ([]Reception, error) {
+	query, args := receptionFilterQuery(pvzId, f)
+	args = append(args, limit, (page-1)*limit)
+	query += fmt.Sprintf(" ORDER BY date_time DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	return r.queryReceptions(ctx, query, args...)
+}
+
+// ListReceptionsPage возвращает приёмки ПВЗ строго после курсора after
+// (nil — с начала) в порядке (date_time, id) по убыванию. Курсор следующей
+// страницы равен nil, если страница последняя.
+func (r *PostgresReceptionRepository) ListReceptionsPage(ctx context.Context, pvzId string, f ReceptionFilter, after *ReceptionCursor, limit int) ([]Reception, *ReceptionCursor, error) {
+	limit = clampLimit(limit)
+	query, args := receptionFilterQuery(pvzId, f)
+	if after != nil {
+		args = append(args, after.DateTime, after.ID)
+		query += fmt.Sprintf(" AND (date_time, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+	// Берём на одну запись больше, чтобы понять, есть ли следующая страница.
+	args = append(args, limit+1)
+	query += fmt.Sprintf(" ORDER BY date_time DESC, id DESC LIMIT $%d", len(args))
+
+	receptions, err := r.queryReceptions(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var next *ReceptionCursor
+	if len(receptions) > limit {
+		receptions = receptions[:limit]
+		next = receptionCursorOf(receptions[limit-1])
+	}
+	return receptions, next, nil
+}
+
+// receptionFilterQuery строит выборку приёмок ПВЗ по фильтру, без сортировки.
+func receptionFilterQuery(pvzId string, f ReceptionFilter) (string, []interface{}) {
+	query := "SELECT " + receptionColumns + " FROM receptions WHERE pvz_id = $1"
+	args := []interface{}{pvzId}
+	if f.Status != "" {
+		args = append(args, f.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if f.StartDate != nil {
+		args = append(args, *f.StartDate)
+		query += fmt.Sprintf(" AND date_time >= $%d", len(args))
+	}
+	if f.EndDate != nil {
+		args = append(args, *f.EndDate)
+		query += fmt.Sprintf(" AND date_time <= $%d", len(args))
+	}
+	return query, args
+}
+
+func (r *PostgresReceptionRepository) queryReceptions(ctx context.Context, query string, args ...interface{}) ([]Reception, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if isInvalidText(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receptions []Reception
+	for rows.Next() {
+		var rec Reception
+		if err := rows.Scan(&rec.ID, &rec.DateTime, &rec.PVZId, &rec.Status, &rec.CloseReason); err != nil {
+			return nil, err
+		}
+		receptions = append(receptions, rec)
+	}
+	return receptions, rows.Err()
+}
+
+// withProducts дополняет приёмку её неудалёнными товарами.
+func (r *PostgresReceptionRepository) withProducts(ctx context.Context, rec Reception) (*ReceptionRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+productColumns+`
+        FROM products
+        WHERE reception_id = $1 AND deleted_at IS NULL
+        ORDER BY date_time ASC`, rec.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	record := &ReceptionRecord{Reception: rec}
+	for rows.Next() {
+		prod, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		record.Products = append(record.Products, prod)
+	}
+	return record, rows.Err()
+}
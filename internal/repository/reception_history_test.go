@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReception_WithProducts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status, COALESCE\(close_reason, ''\) FROM receptions WHERE id = \$1`).
+		WithArgs("rec-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status", "close_reason"}).
+			AddRow("rec-1", now, "pvz-1", "cancelled", "auto_timeout"))
+	mock.ExpectQuery(`SELECT id, date_time, type, reception_id, pvz_id, attributes, barcode, deleted_at, deleted_by\s+FROM products\s+WHERE reception_id = \$1 AND deleted_at IS NULL`).
+		WithArgs("rec-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "type", "reception_id", "pvz_id", "attributes", "barcode", "deleted_at", "deleted_by"}).
+			AddRow("prod-1", now, "обувь", "rec-1", "pvz-1", []byte(`{"size": 42}`), "ORDER-1", nil, nil).
+			AddRow("prod-2", now, "одежда", "rec-1", "pvz-1", []byte("{}"), nil, nil, nil))
+
+	record, err := NewPostgresReceptionRepository(db).GetReception(context.Background(), "rec-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", record.Reception.Status)
+	assert.Equal(t, "auto_timeout", record.Reception.CloseReason)
+	require.Len(t, record.Products, 2)
+	assert.Equal(t, "ORDER-1", record.Products[0].Barcode)
+	assert.EqualValues(t, 42, record.Products[0].Attributes["size"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetReception_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status, COALESCE\(close_reason, ''\) FROM receptions WHERE id = \$1`).
+		WithArgs("rec-404").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = NewPostgresReceptionRepository(db).GetReception(context.Background(), "rec-404")
+	assert.ErrorIs(t, err, ErrReceptionNotFound)
+}
+
+func TestCurrentReception_None(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`WHERE pvz_id = \$1 AND status IN \('in_progress', 'reopened'\)`).
+		WithArgs("pvz-1").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = NewPostgresReceptionRepository(db).CurrentReception(context.Background(), "pvz-1")
+	assert.ErrorIs(t, err, ErrNoActiveReception)
+}
+
+func TestListReceptions_Filters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM receptions WHERE pvz_id = \$1 AND status = \$2 AND date_time >= \$3 ORDER BY date_time DESC, id DESC LIMIT \$4 OFFSET \$5`).
+		WithArgs("pvz-1", "close", start, 20, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status", "close_reason"}).
+			AddRow("rec-2", start.Add(time.Hour), "pvz-1", "close", "manual"))
+
+	receptions, err := NewPostgresReceptionRepository(db).ListReceptions(context.Background(), "pvz-1",
+		ReceptionFilter{Status: "close", StartDate: &start}, 2, 20)
+	require.NoError(t, err)
+	require.Len(t, receptions, 1)
+	assert.Equal(t, "rec-2", receptions[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListReceptionsPage_Keyset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	after := &ReceptionCursor{DateTime: at, ID: "b7c47d9c-5e91-4c3b-b9d0-6aa470d0f39c"}
+	// Приёмки с тем же date_time, что у курсора, отсекаются по id
+	mock.ExpectQuery(`FROM receptions WHERE pvz_id = \$1 AND status = \$2 AND \(date_time, id\) < \(\$3, \$4\) ORDER BY date_time DESC, id DESC LIMIT \$5`).
+		WithArgs("pvz-1", "close", at, after.ID, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status", "close_reason"}).
+			AddRow("rec-3", at, "pvz-1", "close", "manual").
+			AddRow("rec-2", at.Add(-time.Hour), "pvz-1", "close", "manual"))
+
+	receptions, next, err := NewPostgresReceptionRepository(db).ListReceptionsPage(context.Background(), "pvz-1",
+		ReceptionFilter{Status: "close"}, after, 1)
+	require.NoError(t, err)
+	require.Len(t, receptions, 1)
+	assert.Equal(t, "rec-3", receptions[0].ID)
+	require.NotNil(t, next)
+	assert.Equal(t, "rec-3", next.ID)
+	assert.True(t, at.Equal(next.DateTime))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCloseStaleReceptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	idleBefore := time.Now().Add(-time.Hour)
+	created := idleBefore.Add(-time.Hour)
+	mock.ExpectQuery(`SELECT r\.pvz_id\s+FROM receptions r\s+WHERE r\.status = 'in_progress'\s+AND COALESCE\(\(SELECT MAX\(p\.date_time\) FROM products p WHERE p\.reception_id = r\.id AND p\.deleted_at IS NULL\)`).
+		WithArgs(idleBefore).
+		WillReturnRows(sqlmock.NewRows([]string{"pvz_id"}).AddRow("pvz-1").AddRow("pvz-2"))
+
+	// pvz-1 всё ещё простаивает
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-1")
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
+		WithArgs("pvz-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status"}).
+			AddRow("rec-1", created, "pvz-1", "in_progress"))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(date_time\), \$2\) FROM products WHERE reception_id = \$1 AND deleted_at IS NULL`).
+		WithArgs("rec-1", created).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(created))
+	mock.ExpectExec(`UPDATE receptions SET status = 'close', close_reason = \$2 WHERE id =`).
+		WithArgs("rec-1", "auto_timeout").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE products SET reception_open = FALSE WHERE reception_id =`).
+		WithArgs("rec-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectOutbox(mock)
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	// В pvz-2 товар добавили, пока ждали блокировку
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-2")
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
+		WithArgs("pvz-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status"}).
+			AddRow("rec-2", created, "pvz-2", "in_progress"))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(date_time\), \$2\) FROM products`).
+		WithArgs("rec-2", created).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(time.Now()))
+	mock.ExpectRollback()
+
+	closed, err := NewPostgresReceptionRepository(db).CloseStaleReceptions(context.Background(), idleBefore)
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.Equal(t, "rec-1", closed[0].ID)
+	assert.Equal(t, CloseReasonAutoTimeout, closed[0].CloseReason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresLeaderLock_Busy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WithArgs(lockKey("job")).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+
+	ran, err := NewPostgresLeaderLock(db).RunExclusive(context.Background(), "job", func(context.Context) error {
+		t.Fatal("задача не должна выполняться без блокировки")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.False(t, ran)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
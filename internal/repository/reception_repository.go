@@ -1,10 +1,12 @@
 package repository
 
 import (
-	"avito-pvz-service/internal/database"
-	"errors"
+	"context"
+	"database/sql"
 	"time"
 
+	"avito-pvz-service/internal/events"
+
 	"github.com/google/uuid"
 )
 
@@ -13,54 +15,268 @@ type Reception struct {
 	DateTime time.Time `json:"date_time"`
 	PVZId    string    `json:"pvz_id"`
 	Status   string    `json:"status"`
+	// CloseReason — причина последнего закрытия (CloseReasonManual или
+	// CloseReasonAutoTimeout). Заполняется при закрытии и в ручках истории.
+	CloseReason string `json:"close_reason,omitempty"`
+}
+
+// PostgresReceptionRepository хранит приёмки в PostgreSQL.
+type PostgresReceptionRepository struct {
+	db     *sql.DB
+	events *events.Bus
+}
+
+func NewPostgresReceptionRepository(db *sql.DB) *PostgresReceptionRepository {
+	return &PostgresReceptionRepository{db: db}
 }
 
-func CreateReception(pvzId string) (*Reception, error) {
-	var status string
-	err := database.DB.QueryRow("SELECT status FROM receptions WHERE pvz_id = $1 ORDER BY date_time DESC LIMIT 1", pvzId).Scan(&status)
-	if err == nil {
-		if status == "in_progress" {
-			return nil, errors.New("Нельзя создать новую приёмку: предыдущая не закрыта")
+// receptionsOneOpen — частичный уникальный индекс из миграции 0012:
+// не больше одной открытой (in_progress или reopened) приёмки на ПВЗ.
+const receptionsOneOpen = "receptions_one_open_per_pvz"
+
+func (r *PostgresReceptionRepository) CreateReception(ctx context.Context, pvzId string) (*Reception, error) {
+	var reception *Reception
+	var event events.Event
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		city, active, err := lockPVZ(ctx, tx, pvzId)
+		if err != nil {
+			return err
 		}
-	} else if err.Error() != "sql: no rows in result set" {
-		return nil, err
-	}
+		if !active {
+			return ErrPVZInactive
+		}
+
+		var status string
+		err = tx.QueryRowContext(ctx, "SELECT status FROM receptions WHERE pvz_id = $1 ORDER BY date_time DESC LIMIT 1", pvzId).Scan(&status)
+		if err == nil {
+			if IsOpenStatus(status) {
+				return ErrReceptionInProgress
+			}
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+
+		id := uuid.New().String()
+		dateTime := time.Now()
 
-	id := uuid.New().String()
-	dateTime := time.Now()
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO receptions (id, date_time, pvz_id, status) VALUES ($1, $2, $3, $4)",
+			id, dateTime, pvzId, StatusInProgress,
+		)
+		if err != nil {
+			if isUniqueViolation(err, receptionsOneOpen) {
+				return ErrReceptionInProgress
+			}
+			return err
+		}
 
-	_, err = database.DB.Exec(
-		"INSERT INTO receptions (id, date_time, pvz_id, status) VALUES ($1, $2, $3, $4)",
-		id, dateTime, pvzId, "in_progress",
-	)
+		reception = &Reception{
+			ID:       id,
+			DateTime: dateTime,
+			PVZId:    pvzId,
+			Status:   StatusInProgress,
+		}
+		event = events.Event{
+			Type:        events.ReceptionOpened,
+			Time:        dateTime,
+			PVZId:       pvzId,
+			City:        city,
+			ReceptionId: id,
+		}
+		if err := insertOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: AuditReceptionCreate, entityType: EntityReception, entityId: id, after: reception,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Reception{
-		ID:       id,
-		DateTime: dateTime,
-		PVZId:    pvzId,
-		Status:   "in_progress",
-	}, nil
+	r.events.Publish(event)
+	return reception, nil
 }
 
-func CloseReception(pvzId string) (*Reception, error) {
+func (r *PostgresReceptionRepository) CloseReception(ctx context.Context, pvzId string) (*Reception, error) {
+	return r.closeLast(ctx, pvzId, CloseReasonManual, nil)
+}
+
+// closeLast закрывает последнюю приёмку ПВЗ с причиной reason. Если задан
+// idleBefore, закрывается только приёмка in_progress без товаров новее
+// idleBefore, иначе возвращается errNotStale.
+func (r *PostgresReceptionRepository) closeLast(ctx context.Context, pvzId, reason string, idleBefore *time.Time) (*Reception, error) {
 	var reception Reception
-	err := database.DB.QueryRow("SELECT id, date_time, pvz_id, status FROM receptions WHERE pvz_id = $1 ORDER BY date_time DESC LIMIT 1", pvzId).
-		Scan(&reception.ID, &reception.DateTime, &reception.PVZId, &reception.Status)
+	var event events.Event
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		city, _, err := lockPVZ(ctx, tx, pvzId)
+		if err != nil {
+			if err == ErrPVZNotFound {
+				return ErrNoReceptionToClose
+			}
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, "SELECT id, date_time, pvz_id, status FROM receptions WHERE pvz_id = $1 ORDER BY date_time DESC LIMIT 1", pvzId).
+			Scan(&reception.ID, &reception.DateTime, &reception.PVZId, &reception.Status)
+		if err == sql.ErrNoRows || isInvalidText(err) {
+			return ErrNoReceptionToClose
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkTransition(reception.Status, StatusClosed); err != nil {
+			return err
+		}
+		if idleBefore != nil {
+			// Пока ждали блокировку, в приёмку могли добавить товар
+			var lastActivity time.Time
+			err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(date_time), $2) FROM products WHERE reception_id = $1 AND deleted_at IS NULL",
+				reception.ID, reception.DateTime).Scan(&lastActivity)
+			if err != nil {
+				return err
+			}
+			if reception.Status != StatusInProgress || !lastActivity.Before(*idleBefore) {
+				return errNotStale
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE receptions SET status = 'close', close_reason = $2 WHERE id = $1", reception.ID, reason)
+		if err != nil {
+			return err
+		}
+		// Штрихкоды закрытой приёмки снова можно принимать
+		_, err = tx.ExecContext(ctx, "UPDATE products SET reception_open = FALSE WHERE reception_id = $1", reception.ID)
+		if err != nil {
+			return err
+		}
+		event = events.Event{
+			Type:        events.ReceptionClosed,
+			Time:        time.Now(),
+			PVZId:       pvzId,
+			City:        city,
+			ReceptionId: reception.ID,
+		}
+		if err := insertOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+
+		before := reception
+		reception.Status = StatusClosed
+		reception.CloseReason = reason
+		action := AuditReceptionClose
+		if reason == CloseReasonAutoTimeout {
+			action = AuditReceptionAutoClose
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: action, entityType: EntityReception, entityId: reception.ID, before: before, after: reception,
+		})
+	})
 	if err != nil {
-		return nil, errors.New("Нет приемки для закрытия")
+		return nil, err
+	}
+
+	r.events.Publish(event)
+	return &reception, nil
+}
+
+// ReopenReception возвращает закрытую приёмку в работу. Переоткрыть можно
+// только последнюю приёмку активного ПВЗ.
+func (r *PostgresReceptionRepository) ReopenReception(ctx context.Context, id string) (*Reception, error) {
+	return r.transition(ctx, id, StatusReopened)
+}
+
+// CancelReception отменяет приёмку: она остаётся в БД, но не попадает в отчёты.
+func (r *PostgresReceptionRepository) CancelReception(ctx context.Context, id string) (*Reception, error) {
+	return r.transition(ctx, id, StatusCancelled)
+}
+
+var transitionEvents = map[string]events.Type{
+	StatusReopened:  events.ReceptionReopened,
+	StatusCancelled: events.ReceptionCancelled,
+}
+
+var transitionAudit = map[string]string{
+	StatusReopened:  AuditReceptionReopen,
+	StatusCancelled: AuditReceptionCancel,
+}
+
+func (r *PostgresReceptionRepository) transition(ctx context.Context, id, to string) (*Reception, error) {
+	var pvzId string
+	err := r.db.QueryRowContext(ctx, "SELECT pvz_id FROM receptions WHERE id = $1", id).Scan(&pvzId)
+	if err == sql.ErrNoRows || isInvalidText(err) {
+		return nil, ErrReceptionNotFound
 	}
-	if reception.Status != "in_progress" {
-		return nil, errors.New("Приемка уже закрыта")
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = database.DB.Exec("UPDATE receptions SET status = 'close' WHERE id = $1", reception.ID)
+	var reception Reception
+	var event events.Event
+	err = withTx(ctx, r.db, func(tx *sql.Tx) error {
+		city, active, err := lockPVZ(ctx, tx, pvzId)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, "SELECT id, date_time, pvz_id, status FROM receptions WHERE id = $1", id).
+			Scan(&reception.ID, &reception.DateTime, &reception.PVZId, &reception.Status)
+		if err == sql.ErrNoRows {
+			return ErrReceptionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkTransition(reception.Status, to); err != nil {
+			return err
+		}
+
+		if to == StatusReopened {
+			if !active {
+				return ErrPVZInactive
+			}
+			var latest string
+			err = tx.QueryRowContext(ctx, "SELECT id FROM receptions WHERE pvz_id = $1 ORDER BY date_time DESC LIMIT 1", pvzId).Scan(&latest)
+			if err != nil {
+				return err
+			}
+			if latest != id {
+				return ErrReceptionNotLatest
+			}
+		}
+
+		if _, err = tx.ExecContext(ctx, "UPDATE receptions SET status = $2 WHERE id = $1", id, to); err != nil {
+			return err
+		}
+		// Штрихкоды переоткрытой приёмки снова заняты, отменённой — свободны
+		_, err = tx.ExecContext(ctx, "UPDATE products SET reception_open = $2 WHERE reception_id = $1", id, IsOpenStatus(to))
+		if isUniqueViolation(err, productsOpenBarcode) {
+			return ErrDuplicateBarcode
+		}
+		if err != nil {
+			return err
+		}
+		event = events.Event{
+			Type:        transitionEvents[to],
+			Time:        time.Now(),
+			PVZId:       pvzId,
+			City:        city,
+			ReceptionId: id,
+		}
+		if err := insertOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+
+		before := reception
+		reception.Status = to
+		return writeAudit(ctx, tx, auditChange{
+			action: transitionAudit[to], entityType: EntityReception, entityId: id, before: before, after: reception,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	reception.Status = "close"
+	r.events.Publish(event)
 	return &reception, nil
 }
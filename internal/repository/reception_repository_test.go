@@ -1,12 +1,16 @@
 package repository
 
 import (
-	"avito-pvz-service/internal/database"
+	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
 
+	"avito-pvz-service/internal/events"
+
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -16,12 +20,16 @@ func TestCreateReception_Success(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	original := database.DB
-	database.DB = db
-	defer func() { database.DB = original }()
+	bus := events.NewBus(10)
+	repo := &PostgresReceptionRepository{db: db, events: bus}
+	sub, _, err := bus.Subscribe(events.Filter{}, 0, 1)
+	require.NoError(t, err)
 
 	pvzId := "pvz-123"
 
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzId)
+
 	// Нет открытой приёмки
 	mock.ExpectQuery("SELECT status FROM receptions").
 		WithArgs(pvzId).
@@ -32,10 +40,20 @@ func TestCreateReception_Success(t *testing.T) {
 		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), pvzId, "in_progress").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	reception, err := CreateReception(pvzId)
+	expectOutbox(mock)
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	reception, err := repo.CreateReception(context.Background(), pvzId)
 	require.NoError(t, err)
 	assert.Equal(t, "in_progress", reception.Status)
 	assert.Equal(t, pvzId, reception.PVZId)
+
+	// После коммита публикуется событие с городом из заблокированной строки ПВЗ
+	e := <-sub.Events()
+	assert.Equal(t, events.ReceptionOpened, e.Type)
+	assert.Equal(t, "Москва", e.City)
+	assert.Equal(t, reception.ID, e.ReceptionId)
 }
 
 func TestCreateReception_AlreadyInProgress(t *testing.T) {
@@ -43,18 +61,21 @@ func TestCreateReception_AlreadyInProgress(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	original := database.DB
-	database.DB = db
-	defer func() { database.DB = original }()
+	repo := NewPostgresReceptionRepository(db)
 
 	pvzId := "pvz-456"
 
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzId)
+
 	// Возвращаем уже открытую приёмку
 	mock.ExpectQuery("SELECT status FROM receptions").
 		WithArgs(pvzId).
 		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("in_progress"))
 
-	reception, err := CreateReception(pvzId)
+	mock.ExpectRollback()
+
+	reception, err := repo.CreateReception(context.Background(), pvzId)
 	assert.Nil(t, reception)
 	assert.EqualError(t, err, "Нельзя создать новую приёмку: предыдущая не закрыта")
 }
@@ -64,15 +85,18 @@ func TestCreateReception_SelectError(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	original := database.DB
-	database.DB = db
-	defer func() { database.DB = original }()
+	repo := NewPostgresReceptionRepository(db)
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-error")
 
 	mock.ExpectQuery("SELECT status FROM receptions").
 		WithArgs("pvz-error").
 		WillReturnError(errors.New("db select error"))
 
-	reception, err := CreateReception("pvz-error")
+	mock.ExpectRollback()
+
+	reception, err := repo.CreateReception(context.Background(), "pvz-error")
 	assert.Nil(t, reception)
 	assert.EqualError(t, err, "db select error")
 }
@@ -82,12 +106,13 @@ func TestCreateReception_InsertError(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	original := database.DB
-	database.DB = db
-	defer func() { database.DB = original }()
+	repo := NewPostgresReceptionRepository(db)
 
 	pvzId := "pvz-insert-fail"
 
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzId)
+
 	mock.ExpectQuery("SELECT status FROM receptions").
 		WithArgs(pvzId).
 		WillReturnRows(sqlmock.NewRows([]string{}))
@@ -96,21 +121,90 @@ func TestCreateReception_InsertError(t *testing.T) {
 		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), pvzId, "in_progress").
 		WillReturnError(errors.New("insert failed"))
 
-	reception, err := CreateReception(pvzId)
+	mock.ExpectRollback()
+
+	reception, err := repo.CreateReception(context.Background(), pvzId)
 	assert.Nil(t, reception)
 	assert.EqualError(t, err, "insert failed")
 }
 
+func TestCreateReception_PVZNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresReceptionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT city, active FROM pvz WHERE id = \$1 FOR UPDATE`).
+		WithArgs("pvz-missing").
+		WillReturnRows(sqlmock.NewRows([]string{"city", "active"}))
+	mock.ExpectRollback()
+
+	reception, err := repo.CreateReception(context.Background(), "pvz-missing")
+	assert.Nil(t, reception)
+	assert.ErrorIs(t, err, ErrPVZNotFound)
+}
+
+func TestCreateReception_PVZInactive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresReceptionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT city, active FROM pvz WHERE id = \$1 FOR UPDATE`).
+		WithArgs("pvz-closed").
+		WillReturnRows(sqlmock.NewRows([]string{"city", "active"}).AddRow("Москва", false))
+	mock.ExpectRollback()
+
+	reception, err := repo.CreateReception(context.Background(), "pvz-closed")
+	assert.Nil(t, reception)
+	assert.ErrorIs(t, err, ErrPVZInactive)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateReception_UniqueViolation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresReceptionRepository(db)
+	pvzId := "pvz-race"
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzId)
+
+	mock.ExpectQuery("SELECT status FROM receptions").
+		WithArgs(pvzId).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+
+	// Параллельная транзакция успела вставить открытую приёмку
+	mock.ExpectExec("INSERT INTO receptions").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), pvzId, "in_progress").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: receptionsOneOpen})
+
+	mock.ExpectRollback()
+
+	reception, err := repo.CreateReception(context.Background(), pvzId)
+	assert.Nil(t, reception)
+	assert.ErrorIs(t, err, ErrReceptionInProgress)
+}
+
 func TestCloseReception_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
-	database.DB = db
+	repo := NewPostgresReceptionRepository(db)
 
 	pvzID := "pvz-1"
 	receptionID := "rec-1"
 	now := time.Now()
 
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzID)
+
 	// Найти последнюю приёмку
 	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
 		WithArgs(pvzID).
@@ -118,13 +212,23 @@ func TestCloseReception_Success(t *testing.T) {
 			AddRow(receptionID, now, pvzID, "in_progress"))
 
 	// Обновить статус
-	mock.ExpectExec(`UPDATE receptions SET status = 'close' WHERE id =`).
-		WithArgs(receptionID).
+	mock.ExpectExec(`UPDATE receptions SET status = 'close', close_reason = \$2 WHERE id =`).
+		WithArgs(receptionID, "manual").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	rec, err := CloseReception(pvzID)
+	// Освободить штрихкоды товаров приёмки
+	mock.ExpectExec(`UPDATE products SET reception_open = FALSE WHERE reception_id =`).
+		WithArgs(receptionID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	expectOutbox(mock)
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	rec, err := repo.CloseReception(context.Background(), pvzID)
 	require.NoError(t, err)
 	assert.Equal(t, "close", rec.Status)
+	assert.Equal(t, "manual", rec.CloseReason)
 	assert.Equal(t, receptionID, rec.ID)
 	assert.Equal(t, pvzID, rec.PVZId)
 	assert.WithinDuration(t, now, rec.DateTime, time.Second)
@@ -134,32 +238,65 @@ func TestCloseReception_NoReception(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
-	database.DB = db
+	repo := NewPostgresReceptionRepository(db)
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-404")
 
 	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
 		WithArgs("pvz-404").
-		WillReturnError(errors.New("sql: no rows in result set"))
+		WillReturnError(sql.ErrNoRows)
 
-	rec, err := CloseReception("pvz-404")
+	mock.ExpectRollback()
+
+	rec, err := repo.CloseReception(context.Background(), "pvz-404")
 	assert.Nil(t, rec)
 	assert.EqualError(t, err, "Нет приемки для закрытия")
 }
 
+func TestCloseReception_SelectError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewPostgresReceptionRepository(db)
+
+	mock.ExpectBegin()
+	expectPVZLock(mock, "pvz-1")
+
+	// Сбой БД — не «нет приёмки для закрытия»
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
+		WithArgs("pvz-1").
+		WillReturnError(sql.ErrConnDone)
+
+	mock.ExpectRollback()
+
+	rec, err := repo.CloseReception(context.Background(), "pvz-1")
+	assert.Nil(t, rec)
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.NotErrorIs(t, err, ErrNoReceptionToClose)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestCloseReception_AlreadyClosed(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
-	database.DB = db
+	repo := NewPostgresReceptionRepository(db)
 
 	pvzID := "pvz-2"
 	now := time.Now()
 
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzID)
+
 	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
 		WithArgs(pvzID).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status"}).
 			AddRow("rec-closed", now, pvzID, "close"))
 
-	rec, err := CloseReception(pvzID)
+	mock.ExpectRollback()
+
+	rec, err := repo.CloseReception(context.Background(), pvzID)
 	assert.Nil(t, rec)
 	assert.EqualError(t, err, "Приемка уже закрыта")
 }
@@ -168,22 +305,143 @@ func TestCloseReception_UpdateFails(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
-	database.DB = db
+	repo := NewPostgresReceptionRepository(db)
 
 	pvzID := "pvz-3"
 	recID := "rec-3"
 	now := time.Now()
 
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzID)
+
 	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions`).
 		WithArgs(pvzID).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status"}).
 			AddRow(recID, now, pvzID, "in_progress"))
 
-	mock.ExpectExec(`UPDATE receptions SET status = 'close' WHERE id =`).
-		WithArgs(recID).
+	mock.ExpectExec(`UPDATE receptions SET status = 'close', close_reason = \$2 WHERE id =`).
+		WithArgs(recID, "manual").
 		WillReturnError(errors.New("update error"))
 
-	rec, err := CloseReception(pvzID)
+	mock.ExpectRollback()
+
+	rec, err := repo.CloseReception(context.Background(), pvzID)
 	assert.Nil(t, rec)
 	assert.EqualError(t, err, "update error")
-}
\ No newline at end of file
+}
+func TestCheckTransition(t *testing.T) {
+	assert.NoError(t, checkTransition(StatusInProgress, StatusClosed))
+	assert.NoError(t, checkTransition(StatusClosed, StatusReopened))
+	assert.NoError(t, checkTransition(StatusReopened, StatusClosed))
+	assert.NoError(t, checkTransition(StatusReopened, StatusCancelled))
+
+	assert.ErrorIs(t, checkTransition(StatusClosed, StatusClosed), ErrReceptionClosed)
+	assert.ErrorIs(t, checkTransition(StatusInProgress, StatusReopened), ErrInvalidTransition)
+	assert.ErrorIs(t, checkTransition(StatusCancelled, StatusReopened), ErrInvalidTransition)
+	assert.ErrorIs(t, checkTransition(StatusCancelled, StatusClosed), ErrReceptionClosed)
+}
+
+func expectReception(mock sqlmock.Sqlmock, recID, pvzID, status string) {
+	mock.ExpectQuery(`SELECT pvz_id FROM receptions WHERE id = \$1`).
+		WithArgs(recID).
+		WillReturnRows(sqlmock.NewRows([]string{"pvz_id"}).AddRow(pvzID))
+	mock.ExpectBegin()
+	expectPVZLock(mock, pvzID)
+	mock.ExpectQuery(`SELECT id, date_time, pvz_id, status FROM receptions WHERE id = \$1`).
+		WithArgs(recID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_time", "pvz_id", "status"}).
+			AddRow(recID, time.Now(), pvzID, status))
+}
+
+func TestReopenReception_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewPostgresReceptionRepository(db)
+
+	expectReception(mock, "rec-1", "pvz-1", "close")
+	mock.ExpectQuery(`SELECT id FROM receptions WHERE pvz_id = \$1 ORDER BY date_time DESC LIMIT 1`).
+		WithArgs("pvz-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("rec-1"))
+	mock.ExpectExec(`UPDATE receptions SET status = \$2 WHERE id = \$1`).
+		WithArgs("rec-1", "reopened").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE products SET reception_open = \$2 WHERE reception_id = \$1`).
+		WithArgs("rec-1", true).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	expectOutbox(mock)
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	rec, err := repo.ReopenReception(context.Background(), "rec-1")
+	require.NoError(t, err)
+	assert.Equal(t, "reopened", rec.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReopenReception_NotLatest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewPostgresReceptionRepository(db)
+
+	expectReception(mock, "rec-old", "pvz-1", "close")
+	mock.ExpectQuery(`SELECT id FROM receptions WHERE pvz_id = \$1`).
+		WithArgs("pvz-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("rec-new"))
+	mock.ExpectRollback()
+
+	rec, err := repo.ReopenReception(context.Background(), "rec-old")
+	assert.Nil(t, rec)
+	assert.ErrorIs(t, err, ErrReceptionNotLatest)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReopenReception_Cancelled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewPostgresReceptionRepository(db)
+
+	expectReception(mock, "rec-1", "pvz-1", "cancelled")
+	mock.ExpectRollback()
+
+	rec, err := repo.ReopenReception(context.Background(), "rec-1")
+	assert.Nil(t, rec)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelReception_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewPostgresReceptionRepository(db)
+
+	mock.ExpectQuery(`SELECT pvz_id FROM receptions WHERE id = \$1`).
+		WithArgs("rec-404").
+		WillReturnError(sql.ErrNoRows)
+
+	rec, err := repo.CancelReception(context.Background(), "rec-404")
+	assert.Nil(t, rec)
+	assert.ErrorIs(t, err, ErrReceptionNotFound)
+}
+
+// expectPVZLock ожидает блокировку строки ПВЗ в начале транзакции.
+func expectPVZLock(mock sqlmock.Sqlmock, pvzID string) {
+	mock.ExpectQuery(`SELECT city, active FROM pvz WHERE id = \$1 FOR UPDATE`).
+		WithArgs(pvzID).
+		WillReturnRows(sqlmock.NewRows([]string{"city", "active"}).AddRow("Москва", true))
+}
+
+// expectOutbox ожидает запись событий в outbox перед коммитом.
+func expectOutbox(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`INSERT INTO outbox \(event_type, payload, created_at\) VALUES`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectAudit ожидает запись в журнал аудита перед коммитом.
+func expectAudit(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`INSERT INTO audit_log \(created_at, actor, role, action, entity_type, entity_id, before, after, request_id, client_ip\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
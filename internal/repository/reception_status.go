@@ -0,0 +1,48 @@
+package repository
+
+import "fmt"
+
+// Статусы приёмки.
+const (
+	StatusInProgress = "in_progress"
+	StatusClosed     = "close"
+	StatusReopened   = "reopened"
+	StatusCancelled  = "cancelled"
+)
+
+// receptionTransitions — единственное место, где описаны допустимые
+// переходы между статусами приёмки. cancelled — конечный статус.
+var receptionTransitions = map[string][]string{
+	StatusInProgress: {StatusClosed, StatusCancelled},
+	StatusClosed:     {StatusReopened, StatusCancelled},
+	StatusReopened:   {StatusClosed, StatusCancelled},
+}
+
+// ValidReceptionStatus сообщает, известен ли статус приёмки.
+func ValidReceptionStatus(status string) bool {
+	switch status {
+	case StatusInProgress, StatusClosed, StatusReopened, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// IsOpenStatus сообщает, можно ли работать с товарами приёмки в этом статусе.
+func IsOpenStatus(status string) bool {
+	return status == StatusInProgress || status == StatusReopened
+}
+
+// checkTransition проверяет переход from -> to. Повторное закрытие
+// возвращает прежнюю ErrReceptionClosed, остальные недопустимые
+// переходы — ErrInvalidTransition.
+func checkTransition(from, to string) error {
+	for _, next := range receptionTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	if to == StatusClosed && !IsOpenStatus(from) {
+		return ErrReceptionClosed
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"avito-pvz-service/internal/events"
+)
+
+// Ошибки бизнес-правил, общие для всех реализаций хранилища.
+var (
+	ErrCityNotAllowed      = errors.New("ПВЗ можно завести только в городе из справочника")
+	ErrCityNotFound        = errors.New("Город не найден")
+	ErrCityExists          = errors.New("Город уже есть в справочнике")
+	ErrCityInUse           = errors.New("В городе есть ПВЗ, удалить его нельзя")
+	ErrPVZNotFound         = errors.New("ПВЗ не найден")
+	ErrPVZInactive         = errors.New("ПВЗ деактивирован")
+	ErrPVZHasOpenReception = errors.New("У ПВЗ есть незакрытая приёмка")
+	ErrReceptionInProgress = errors.New("Нельзя создать новую приёмку: предыдущая не закрыта")
+	ErrNoReceptionToClose  = errors.New("Нет приемки для закрытия")
+	ErrReceptionNotFound   = errors.New("Приёмка не найдена")
+	ErrReceptionNotLatest  = errors.New("Переоткрыть можно только последнюю приёмку ПВЗ")
+	ErrInvalidTransition   = errors.New("Недопустимая смена статуса приёмки")
+	ErrReceptionClosed     = errors.New("Приемка уже закрыта")
+	ErrNoActiveReception   = errors.New("Нет активной приемки")
+	ErrNoProducts          = errors.New("Нет товаров для удаления")
+	ErrProductNotFound     = errors.New("Товар не найден")
+	ErrDuplicateBarcode    = errors.New("Товар с таким штрихкодом уже есть в открытой приёмке")
+	ErrBatchAborted        = errors.New("Пакет отклонён из-за ошибок в других позициях")
+	ErrInvalidBatchMode    = errors.New("Неизвестный режим пакетной загрузки")
+	ErrInvalidProductType  = errors.New("Invalid product type")
+	ErrProductTypeNotFound = errors.New("Тип товара не найден")
+	ErrProductTypeExists   = errors.New("Тип товара уже есть в справочнике")
+	ErrProductTypeInUse    = errors.New("Есть товары этого типа, удалить его нельзя")
+
+	ErrInvalidProductAttributes = errors.New("Неверные атрибуты товара")
+	ErrInvalidProductTypeSpec   = errors.New("Неверное описание атрибутов типа")
+	ErrInvalidDeletionReason    = errors.New("Неизвестная причина удаления товара")
+	ErrUserExists               = errors.New("user with this email already exists")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrRefreshTokenInvalid      = errors.New("Invalid refresh token")
+	ErrRefreshTokenReused       = errors.New("Refresh token reuse detected")
+	ErrAssignmentNotFound       = errors.New("Сотрудник не назначен в этот ПВЗ")
+	ErrInvalidWebhook           = errors.New("Неверная подписка на вебхуки")
+	ErrWebhookNotFound          = errors.New("Подписка на вебхуки не найдена")
+)
+
+// PVZRepository — работа с пунктами выдачи.
+type PVZRepository interface {
+	CreatePVZ(ctx context.Context, city string) (*PVZ, error)
+	GetPVZRecords(ctx context.Context, startDate, endDate *time.Time, includeDeleted bool, page, limit int) ([]PVZRecord, error)
+	GetPVZRecordsPage(ctx context.Context, startDate, endDate *time.Time, includeDeleted bool, after *PVZCursor, limit int) ([]PVZRecord, *PVZCursor, error)
+	GetAllPVZ(ctx context.Context) ([]PVZ, error)
+	GetPVZ(ctx context.Context, id string) (*PVZ, error)
+	UpdatePVZ(ctx context.Context, id string, upd PVZUpdate) (*PVZ, error)
+	DeletePVZ(ctx context.Context, id string) error
+}
+
+// ReceptionRepository — открытие и закрытие приёмок.
+type ReceptionRepository interface {
+	CreateReception(ctx context.Context, pvzId string) (*Reception, error)
+	CloseReception(ctx context.Context, pvzId string) (*Reception, error)
+	// ReopenReception и CancelReception меняют статус приёмки по её id
+	// через общую машину состояний (см. reception_status.go).
+	ReopenReception(ctx context.Context, id string) (*Reception, error)
+	CancelReception(ctx context.Context, id string) (*Reception, error)
+	GetReception(ctx context.Context, id string) (*ReceptionRecord, error)
+	CurrentReception(ctx context.Context, pvzId string) (*ReceptionRecord, error)
+	ListReceptions(ctx context.Context, pvzId string, f ReceptionFilter, page, limit int) ([]Reception, error)
+	ListReceptionsPage(ctx context.Context, pvzId string, f ReceptionFilter, after *ReceptionCursor, limit int) ([]Reception, *ReceptionCursor, error)
+	// CloseStaleReceptions закрывает приёмки, простаивающие с idleBefore
+	// (см. reception_autoclose.go).
+	CloseStaleReceptions(ctx context.Context, idleBefore time.Time) ([]Reception, error)
+}
+
+// ProductRepository — товары в рамках открытой приёмки.
+type ProductRepository interface {
+	AddProduct(ctx context.Context, pvzId string, in NewProduct) (*Product, error)
+	AddProducts(ctx context.Context, pvzId string, items []NewProduct, mode BatchMode) ([]ProductResult, error)
+	DeleteLastProduct(ctx context.Context, pvzId string) error
+	FindProductByBarcode(ctx context.Context, barcode string) (*ProductLocation, error)
+	GetProduct(ctx context.Context, id string) (*Product, error)
+	// DeleteProduct удаляет конкретный товар из открытой приёмки с записью в журнал.
+	DeleteProduct(ctx context.Context, productId string, del ProductDeletion) (*ProductDeletion, error)
+	ListProductDeletions(ctx context.Context, pvzId string) ([]ProductDeletion, error)
+}
+
+// UserRepository — пользователи, зарегистрированные по email.
+type UserRepository interface {
+	CreateUser(ctx context.Context, email, password, role string) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+}
+
+// TokenRepository — refresh-токены и список отозванных access-токенов.
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token RefreshToken) error
+	// RotateRefreshToken помечает токен с хэшем hash использованным и сохраняет
+	// вместо него next в том же семействе. Повторное предъявление уже
+	// использованного токена отзывает всё семейство и возвращает ErrRefreshTokenReused.
+	RotateRefreshToken(ctx context.Context, hash string, next RefreshToken) (*RefreshToken, error)
+	RevokeRefreshFamily(ctx context.Context, hash string) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// CityRepository — справочник городов.
+type CityRepository interface {
+	ListCities(ctx context.Context, includeDisabled bool) ([]City, error)
+	CreateCity(ctx context.Context, city City) (*City, error)
+	UpdateCity(ctx context.Context, name string, upd CityUpdate) (*City, error)
+	DeleteCity(ctx context.Context, name string) error
+}
+
+// ProductTypeRepository — справочник типов товаров.
+type ProductTypeRepository interface {
+	ListProductTypes(ctx context.Context) ([]ProductType, error)
+	CreateProductType(ctx context.Context, t ProductType) (*ProductType, error)
+	UpdateProductType(ctx context.Context, t ProductType) (*ProductType, error)
+	DeleteProductType(ctx context.Context, code string) error
+}
+
+// StaffRepository — назначения сотрудников в ПВЗ.
+type StaffRepository interface {
+	AssignStaff(ctx context.Context, subject, pvzId, assignedBy string) (*StaffAssignment, error)
+	UnassignStaff(ctx context.Context, subject, pvzId string) error
+	ListStaff(ctx context.Context, pvzId string) ([]StaffAssignment, error)
+	IsStaffAssigned(ctx context.Context, subject, pvzId string) (bool, error)
+}
+
+// WebhookRepository — подписки на вебхуки и очередь их доставки
+// (см. webhook_repository.go).
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, sub WebhookSubscription) (*WebhookSubscription, error)
+	ListWebhooks(ctx context.Context) ([]WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, subscriptionId, status string, limit int) ([]WebhookDelivery, error)
+
+	// Методы диспетчера.
+	FanOutOutbox(ctx context.Context, limit int) (int, error)
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]PendingDelivery, error)
+	MarkDelivered(ctx context.Context, id string, at time.Time) error
+	MarkFailed(ctx context.Context, id, lastError string, next *time.Time) error
+}
+
+// AuditRepository читает журнал аудита. Записи добавляют сами хранилища
+// в транзакциях изменений (см. audit.go).
+type AuditRepository interface {
+	ListAudit(ctx context.Context, f AuditFilter, page, limit int) ([]AuditEntry, error)
+}
+
+// IdempotencyRepository хранит ключи идемпотентности изменяющих запросов
+// и ответы на них. Ключи разделены по scope — sub из JWT.
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(ctx context.Context, scope, key, requestHash string, ttl time.Duration) (*IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, contentType string, response []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string) error
+}
+
+// Repositories собирает все хранилища сервиса, чтобы передавать их
+// в HTTP-хэндлеры и gRPC-сервер одним значением.
+type Repositories struct {
+	PVZ       PVZRepository
+	Reception ReceptionRepository
+	Product   ProductRepository
+	User      UserRepository
+	Token     TokenRepository
+	Staff     StaffRepository
+	City      CityRepository
+	// ProductType — справочник типов товаров, по которому AddProduct проверяет атрибуты.
+	ProductType ProductTypeRepository
+
+	// Events получает события об успешных изменениях приёмок и товаров.
+	Events *events.Bus
+	// Leader выбирает одну реплику для фоновых задач.
+	Leader LeaderLock
+	// Webhook — подписки на события и доставка их из outbox.
+	Webhook WebhookRepository
+	// Audit — журнал изменений с инициатором каждого.
+	Audit AuditRepository
+	// Idempotency — ключи идемпотентности и сохранённые ответы.
+	Idempotency IdempotencyRepository
+}
+
+// eventHistorySize — сколько последних событий хранится для переподключения подписчиков.
+const eventHistorySize = 10000
+
+// catalogCacheTTL — как долго CreatePVZ и AddProduct доверяют закэшированным
+// справочникам городов и типов товаров.
+const catalogCacheTTL = time.Minute
+
+// NewPostgresRepositories возвращает реализации поверх PostgreSQL.
+func NewPostgresRepositories(db *sql.DB) Repositories {
+	bus := events.NewBus(eventHistorySize)
+	// Один кэш на проверку в CreatePVZ и на ручки справочника,
+	// чтобы изменения модератора применялись сразу
+	cities := NewCityCache(NewPostgresCityRepository(db), catalogCacheTTL)
+	types := NewProductTypeCache(NewPostgresProductTypeRepository(db), catalogCacheTTL)
+	return Repositories{
+		PVZ:         &PostgresPVZRepository{db: db, cities: cities},
+		Reception:   &PostgresReceptionRepository{db: db, events: bus},
+		Product:     &PostgresProductRepository{db: db, types: types, events: bus},
+		User:        NewPostgresUserRepository(db),
+		Token:       NewPostgresTokenRepository(db),
+		Staff:       NewPostgresStaffRepository(db),
+		City:        cities,
+		ProductType: types,
+		Events:      bus,
+		Leader:      NewPostgresLeaderLock(db),
+		Webhook:     NewPostgresWebhookRepository(db),
+		Audit:       NewPostgresAuditRepository(db),
+		Idempotency: NewPostgresIdempotencyRepository(db),
+	}
+}
+
+// NewMemoryRepositories возвращает реализации в памяти процесса,
+// разделяющие одно общее состояние.
+func NewMemoryRepositories() Repositories {
+	store := NewMemoryStore()
+	store.events = events.NewBus(eventHistorySize)
+	return Repositories{
+		PVZ:         store,
+		Reception:   store,
+		Product:     store,
+		User:        store,
+		Token:       store,
+		Staff:       store,
+		City:        store,
+		ProductType: store,
+		Events:      store.events,
+		Leader:      &memoryLeaderLock{held: make(map[string]bool)},
+		Webhook:     store,
+		Audit:       store,
+		Idempotency: store,
+	}
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// StaffAssignment — сотрудник, допущенный к работе в ПВЗ.
+type StaffAssignment struct {
+	Subject    string    `json:"subject"`
+	PVZId      string    `json:"pvzId"`
+	AssignedBy string    `json:"assignedBy"`
+	AssignedAt time.Time `json:"assignedAt"`
+}
+
+// PostgresStaffRepository хранит назначения сотрудников в PostgreSQL.
+type PostgresStaffRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresStaffRepository(db *sql.DB) *PostgresStaffRepository {
+	return &PostgresStaffRepository{db: db}
+}
+
+func (r *PostgresStaffRepository) AssignStaff(ctx context.Context, subject, pvzId, assignedBy string) (*StaffAssignment, error) {
+	a := StaffAssignment{Subject: subject, PVZId: pvzId, AssignedBy: assignedBy}
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// Повторное назначение не меняет исходную запись
+		err := tx.QueryRowContext(ctx, `
+            INSERT INTO staff_assignments (subject, pvz_id, assigned_by)
+            SELECT $1, id, $3 FROM pvz WHERE id = $2
+            ON CONFLICT (subject, pvz_id) DO UPDATE SET subject = EXCLUDED.subject
+            RETURNING assigned_by, assigned_at`, subject, pvzId, assignedBy).
+			Scan(&a.AssignedBy, &a.AssignedAt)
+		if err == sql.ErrNoRows || isInvalidText(err) {
+			return ErrPVZNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: AuditStaffAssign, entityType: EntityStaffAssignment, entityId: staffEntityID(subject, pvzId), after: a,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// staffEntityID — id назначения в журнале аудита.
+func staffEntityID(subject, pvzId string) string {
+	return pvzId + "/" + subject
+}
+
+func (r *PostgresStaffRepository) UnassignStaff(ctx context.Context, subject, pvzId string) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		before := StaffAssignment{Subject: subject, PVZId: pvzId}
+		err := tx.QueryRowContext(ctx,
+			"DELETE FROM staff_assignments WHERE subject = $1 AND pvz_id = $2 RETURNING assigned_by, assigned_at", subject, pvzId).
+			Scan(&before.AssignedBy, &before.AssignedAt)
+		if err == sql.ErrNoRows || isInvalidText(err) {
+			return ErrAssignmentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: AuditStaffUnassign, entityType: EntityStaffAssignment, entityId: staffEntityID(subject, pvzId), before: before,
+		})
+	})
+}
+
+func (r *PostgresStaffRepository) ListStaff(ctx context.Context, pvzId string) ([]StaffAssignment, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT subject, pvz_id, assigned_by, assigned_at
+        FROM staff_assignments
+        WHERE pvz_id = $1
+        ORDER BY assigned_at, subject`, pvzId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []StaffAssignment{}
+	for rows.Next() {
+		var a StaffAssignment
+		if err := rows.Scan(&a.Subject, &a.PVZId, &a.AssignedBy, &a.AssignedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, rows.Err()
+}
+
+func (r *PostgresStaffRepository) IsStaffAssigned(ctx context.Context, subject, pvzId string) (bool, error) {
+	var assigned bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM staff_assignments WHERE subject = $1 AND pvz_id = $2)",
+		subject, pvzId).Scan(&assigned)
+	if isInvalidText(err) {
+		return false, nil
+	}
+	return assigned, err
+}
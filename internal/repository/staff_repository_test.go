@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignStaff(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresStaffRepository(db)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO staff_assignments").
+		WithArgs("staff@example.com", "pvz-1", "moderator").
+		WillReturnRows(sqlmock.NewRows([]string{"assigned_by", "assigned_at"}).AddRow("moderator", now))
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	a, err := repo.AssignStaff(context.Background(), "staff@example.com", "pvz-1", "moderator")
+	require.NoError(t, err)
+	assert.Equal(t, "pvz-1", a.PVZId)
+	assert.Equal(t, now, a.AssignedAt)
+
+	// ПВЗ не существует — INSERT ... SELECT ничего не вставил
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO staff_assignments").
+		WithArgs("staff@example.com", "pvz-2", "moderator").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = repo.AssignStaff(context.Background(), "staff@example.com", "pvz-2", "moderator")
+	assert.ErrorIs(t, err, ErrPVZNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnassignStaff_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresStaffRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM staff_assignments").
+		WithArgs("staff@example.com", "pvz-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = repo.UnassignStaff(context.Background(), "staff@example.com", "pvz-1")
+	assert.ErrorIs(t, err, ErrAssignmentNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsStaffAssigned(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresStaffRepository(db)
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("staff@example.com", "pvz-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	ok, err := repo.IsStaffAssigned(context.Background(), "staff@example.com", "pvz-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RefreshToken — запись о выданном refresh-токене. Сам токен не хранится, только Hash.
+type RefreshToken struct {
+	ID        string
+	FamilyID  string
+	Subject   string
+	Role      string
+	Hash      string
+	ExpiresAt time.Time
+}
+
+// PostgresTokenRepository хранит refresh-токены и отозванные access-токены в PostgreSQL.
+type PostgresTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTokenRepository(db *sql.DB) *PostgresTokenRepository {
+	return &PostgresTokenRepository{db: db}
+}
+
+func (r *PostgresTokenRepository) CreateRefreshToken(ctx context.Context, t RefreshToken) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO refresh_tokens (id, family_id, subject, role, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)`,
+		t.ID, t.FamilyID, t.Subject, t.Role, t.Hash, t.ExpiresAt)
+	return err
+}
+
+func (r *PostgresTokenRepository) RotateRefreshToken(ctx context.Context, hash string, next RefreshToken) (*RefreshToken, error) {
+	var reused bool
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var old RefreshToken
+		var usedAt, revokedAt sql.NullTime
+		err := tx.QueryRowContext(ctx, `
+        SELECT id, family_id, subject, role, expires_at, used_at, revoked_at
+        FROM refresh_tokens
+        WHERE token_hash = $1
+        FOR UPDATE`, hash).
+			Scan(&old.ID, &old.FamilyID, &old.Subject, &old.Role, &old.ExpiresAt, &usedAt, &revokedAt)
+		if err == sql.ErrNoRows {
+			return ErrRefreshTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		if revokedAt.Valid {
+			return ErrRefreshTokenInvalid
+		}
+		if usedAt.Valid {
+			// Токен уже обменивали: его украли либо у клиента, либо у нас.
+			// Отзыв семейства должен закоммититься, поэтому ошибку возвращаем после транзакции.
+			reused = true
+			_, err = tx.ExecContext(ctx,
+				"UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL",
+				old.FamilyID)
+			return err
+		}
+		if time.Now().After(old.ExpiresAt) {
+			return ErrRefreshTokenInvalid
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1", old.ID); err != nil {
+			return err
+		}
+
+		next.FamilyID, next.Subject, next.Role = old.FamilyID, old.Subject, old.Role
+		_, err = tx.ExecContext(ctx, `
+        INSERT INTO refresh_tokens (id, family_id, subject, role, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)`,
+			next.ID, next.FamilyID, next.Subject, next.Role, next.Hash, next.ExpiresAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+	return &next, nil
+}
+
+func (r *PostgresTokenRepository) RevokeRefreshFamily(ctx context.Context, hash string) error {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE refresh_tokens SET revoked_at = NOW()
+        WHERE revoked_at IS NULL
+          AND family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1)`, hash)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRefreshTokenInvalid
+	}
+	return nil
+}
+
+func (r *PostgresTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	// Заодно чистим записи о токенах, которые истекли бы и без отзыва
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < NOW()"); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING",
+		jti, expiresAt)
+	return err
+}
+
+func (r *PostgresTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti).Scan(&revoked)
+	if isInvalidText(err) {
+		// jti не UUID — такой токен мы не выпускали и не отзывали
+		return false, nil
+	}
+	return revoked, err
+}
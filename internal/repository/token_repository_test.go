@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func refreshRow(usedAt, revokedAt driver.Value, expiresAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "family_id", "subject", "role", "expires_at", "used_at", "revoked_at"}).
+		AddRow("old-id", "family-1", "user@example.com", "staff", expiresAt, usedAt, revokedAt)
+}
+
+func TestRotateRefreshToken_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresTokenRepository(db)
+	expires := time.Now().Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, family_id, subject, role, expires_at, used_at, revoked_at").
+		WithArgs("old-hash").
+		WillReturnRows(refreshRow(nil, nil, expires))
+	mock.ExpectExec("UPDATE refresh_tokens SET used_at").
+		WithArgs("old-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO refresh_tokens").
+		WithArgs("new-id", "family-1", "user@example.com", "staff", "new-hash", expires).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	next, err := repo.RotateRefreshToken(context.Background(), "old-hash", RefreshToken{ID: "new-id", Hash: "new-hash", ExpiresAt: expires})
+	require.NoError(t, err)
+	assert.Equal(t, "family-1", next.FamilyID)
+	assert.Equal(t, "user@example.com", next.Subject)
+	assert.Equal(t, "staff", next.Role)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRotateRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresTokenRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, family_id, subject, role, expires_at, used_at, revoked_at").
+		WithArgs("old-hash").
+		WillReturnRows(refreshRow(time.Now(), nil, time.Now().Add(time.Hour)))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\) WHERE family_id").
+		WithArgs("family-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// Отзыв семейства коммитится, хотя вызывающий получает ошибку
+	mock.ExpectCommit()
+
+	next, err := repo.RotateRefreshToken(context.Background(), "old-hash", RefreshToken{ID: "new-id", Hash: "new-hash"})
+	assert.Nil(t, next)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRotateRefreshToken_Invalid(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresTokenRepository(db)
+
+	// Не найден
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, family_id").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	_, err = repo.RotateRefreshToken(context.Background(), "missing", RefreshToken{})
+	assert.ErrorIs(t, err, ErrRefreshTokenInvalid)
+
+	// Отозван
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, family_id").
+		WithArgs("revoked").
+		WillReturnRows(refreshRow(nil, time.Now(), time.Now().Add(time.Hour)))
+	mock.ExpectRollback()
+	_, err = repo.RotateRefreshToken(context.Background(), "revoked", RefreshToken{})
+	assert.ErrorIs(t, err, ErrRefreshTokenInvalid)
+
+	// Истёк
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, family_id").
+		WithArgs("expired").
+		WillReturnRows(refreshRow(nil, nil, time.Now().Add(-time.Hour)))
+	mock.ExpectRollback()
+	_, err = repo.RotateRefreshToken(context.Background(), "expired", RefreshToken{})
+	assert.ErrorIs(t, err, ErrRefreshTokenInvalid)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccessTokenRevocation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresTokenRepository(db)
+	exp := time.Now().Add(time.Minute)
+
+	mock.ExpectExec("DELETE FROM revoked_tokens WHERE expires_at < NOW\\(\\)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO revoked_tokens").
+		WithArgs("jti-1", exp).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.RevokeAccessToken(context.Background(), "jti-1", exp))
+
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM revoked_tokens").
+		WithArgs("jti-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	revoked, err := repo.IsAccessTokenRevoked(context.Background(), "jti-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// withTx выполняет fn в транзакции: коммит при успехе, откат при ошибке.
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockPVZ берёт блокировку строки ПВЗ до конца транзакции и возвращает
+// город ПВЗ и признак активности. Все операции с приёмками и товарами
+// одного ПВЗ, а также его деактивация и удаление сериализуются на этой блокировке.
+func lockPVZ(ctx context.Context, tx *sql.Tx, pvzId string) (city string, active bool, err error) {
+	err = tx.QueryRowContext(ctx, "SELECT city, active FROM pvz WHERE id = $1 FOR UPDATE", pvzId).Scan(&city, &active)
+	if err == sql.ErrNoRows || isInvalidText(err) {
+		return "", false, ErrPVZNotFound
+	}
+	return city, active, err
+}
+
+// isInvalidText — строка не приводится к типу колонки (например, невалидный UUID).
+func isInvalidText(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "22P02"
+}
+
+// isUniqueViolation сообщает, нарушено ли ограничение уникальности constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+// isForeignKeyViolation сообщает, нарушен ли внешний ключ constraint.
+func isForeignKeyViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == constraint
+}
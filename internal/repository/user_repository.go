@@ -1,11 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"time"
 
-	"avito-pvz-service/internal/database"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -18,14 +17,23 @@ type User struct {
 	CreatedAt time.Time
 }
 
-func CreateUser(email, password, role string) (*User, error) {
+// PostgresUserRepository хранит пользователей в PostgreSQL.
+type PostgresUserRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+func (r *PostgresUserRepository) CreateUser(ctx context.Context, email, password, role string) (*User, error) {
 	var exists bool
-	err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE email=$1)", email).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE email=$1)", email).Scan(&exists)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("user with this email already exists")
+		return nil, ErrUserExists
 	}
 
 	// хэширую пароль с использованием bcrypt
@@ -36,7 +44,7 @@ func CreateUser(email, password, role string) (*User, error) {
 
 	id := uuid.New().String()
 	createdAt := time.Now()
-	_, err = database.DB.Exec(
+	_, err = r.db.ExecContext(ctx,
 		"INSERT INTO users (id, email, password, role, created_at) VALUES ($1, $2, $3, $4, $5)",
 		id, email, string(hashedPassword), role, createdAt,
 	)
@@ -47,13 +55,13 @@ func CreateUser(email, password, role string) (*User, error) {
 	return &User{ID: id, Email: email, Password: string(hashedPassword), Role: role, CreatedAt: createdAt}, nil
 }
 
-func GetUserByEmail(email string) (*User, error) {
+func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	var user User
-	err := database.DB.QueryRow("SELECT id, email, password, role, created_at FROM users WHERE email=$1", email).
+	err := r.db.QueryRowContext(ctx, "SELECT id, email, password, role, created_at FROM users WHERE email=$1", email).
 		Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
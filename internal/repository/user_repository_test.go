@@ -1,7 +1,7 @@
 package repository
 
 import (
-	"avito-pvz-service/internal/database"
+	"context"
 	"testing"
 	"time"
 
@@ -15,9 +15,7 @@ func TestCreateUser_Success(t *testing.T) {
     require.NoError(t, err)
     defer db.Close()
 
-    original := database.DB
-    database.DB = db
-    defer func() { database.DB = original }()
+    repo := NewPostgresUserRepository(db)
 
     email := "test@example.com"
     password := "pass123"
@@ -31,7 +29,7 @@ func TestCreateUser_Success(t *testing.T) {
         WithArgs(sqlmock.AnyArg(), email, sqlmock.AnyArg(), role, sqlmock.AnyArg()).
         WillReturnResult(sqlmock.NewResult(1, 1))
 
-    user, err := CreateUser(email, password, role)
+    user, err := repo.CreateUser(context.Background(), email, password, role)
     require.NoError(t, err)
     assert.Equal(t, email, user.Email)
     assert.Equal(t, role, user.Role)
@@ -43,9 +41,7 @@ func TestCreateUser_Duplicate(t *testing.T) {
     require.NoError(t, err)
     defer db.Close()
 
-    original := database.DB
-    database.DB = db
-    defer func() { database.DB = original }()
+    repo := NewPostgresUserRepository(db)
 
     email := "duplicate@example.com"
 
@@ -53,7 +49,7 @@ func TestCreateUser_Duplicate(t *testing.T) {
         WithArgs(email).
         WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
 
-    user, err := CreateUser(email, "pass", "moderator")
+    user, err := repo.CreateUser(context.Background(), email, "pass", "moderator")
     assert.Nil(t, user)
     assert.EqualError(t, err, "user with this email already exists")
 }
@@ -0,0 +1,390 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"avito-pvz-service/internal/events"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// WebhookSubscription — адрес, на который отправляются события. Secret
+// возвращается только при создании подписки.
+type WebhookSubscription struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// EventTypes — на какие события подписка; пустой список — на все.
+	EventTypes []string  `json:"eventTypes"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedBy  string    `json:"createdBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Статусы доставки вебхука.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery — доставка одного события одному подписчику.
+type WebhookDelivery struct {
+	ID             string       `json:"id"`
+	SubscriptionID string       `json:"subscriptionId"`
+	Event          WebhookEvent `json:"event"`
+	Status         string       `json:"status"`
+	Attempts       int          `json:"attempts"`
+	NextAttemptAt  *time.Time   `json:"nextAttemptAt,omitempty"`
+	LastError      string       `json:"lastError,omitempty"`
+	DeliveredAt    *time.Time   `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time    `json:"createdAt"`
+}
+
+// PendingDelivery — доставка, которую пора отправить, с адресом и секретом подписки.
+type PendingDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// webhookEventTypes — события, на которые можно подписаться.
+var webhookEventTypes = map[string]bool{
+	string(events.ReceptionOpened):    true,
+	string(events.ReceptionClosed):    true,
+	string(events.ReceptionReopened):  true,
+	string(events.ReceptionCancelled): true,
+	string(events.ProductAdded):       true,
+	string(events.ProductRemoved):     true,
+}
+
+// ValidDeliveryStatus сообщает, известен ли статус доставки.
+func ValidDeliveryStatus(status string) bool {
+	return status == DeliveryPending || status == DeliveryDelivered || status == DeliveryDead
+}
+
+// maxWebhookSecretLen совпадает с размером колонки webhook_subscriptions.secret.
+const maxWebhookSecretLen = 128
+
+// prepareWebhook проверяет подписку и дополняет её id и, если секрет
+// не задан, случайным секретом.
+func prepareWebhook(sub *WebhookSubscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	for _, t := range sub.EventTypes {
+		if !webhookEventTypes[t] {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, t)
+		}
+	}
+	if utf8.RuneCountInString(sub.Secret) > maxWebhookSecretLen {
+		return fmt.Errorf("%w: secret must be at most %d characters", ErrInvalidWebhook, maxWebhookSecretLen)
+	}
+	if sub.EventTypes == nil {
+		sub.EventTypes = []string{}
+	}
+	if sub.Secret == "" {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return err
+		}
+		sub.Secret = hex.EncodeToString(raw)
+	}
+	sub.ID = uuid.New().String()
+	return nil
+}
+
+// subscribed сообщает, нужно ли отправлять подписке событие типа t.
+func subscribed(eventTypes []string, t string) bool {
+	if len(eventTypes) == 0 {
+		return true
+	}
+	for _, et := range eventTypes {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+// PostgresWebhookRepository хранит подписки и доставки в PostgreSQL.
+type PostgresWebhookRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWebhookRepository(db *sql.DB) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+func (r *PostgresWebhookRepository) CreateWebhook(ctx context.Context, sub WebhookSubscription) (*WebhookSubscription, error) {
+	if err := prepareWebhook(&sub); err != nil {
+		return nil, err
+	}
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+            INSERT INTO webhook_subscriptions (id, url, secret, event_types, created_by)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING created_at`,
+			sub.ID, sub.URL, sub.Secret, pq.Array(sub.EventTypes), sub.CreatedBy).Scan(&sub.CreatedAt)
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{
+			action: AuditWebhookCreate, entityType: EntityWebhook, entityId: sub.ID, after: withoutSecret(sub),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// withoutSecret скрывает секрет подписки, например для журнала аудита.
+func withoutSecret(sub WebhookSubscription) WebhookSubscription {
+	sub.Secret = ""
+	return sub
+}
+
+func (r *PostgresWebhookRepository) ListWebhooks(ctx context.Context) ([]WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, url, event_types, created_by, created_at
+        FROM webhook_subscriptions
+        ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []WebhookSubscription
+	for rows.Next() {
+		var s WebhookSubscription
+		if err := rows.Scan(&s.ID, &s.URL, pq.Array(&s.EventTypes), &s.CreatedBy, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		if s.EventTypes == nil {
+			s.EventTypes = []string{}
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		before := WebhookSubscription{ID: id}
+		err := tx.QueryRowContext(ctx, `
+            DELETE FROM webhook_subscriptions WHERE id = $1
+            RETURNING url, event_types, created_by, created_at`, id).
+			Scan(&before.URL, pq.Array(&before.EventTypes), &before.CreatedBy, &before.CreatedAt)
+		if err == sql.ErrNoRows || isInvalidText(err) {
+			return ErrWebhookNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, auditChange{action: AuditWebhookDelete, entityType: EntityWebhook, entityId: id, before: before})
+	})
+}
+
+// ListDeliveries возвращает последние доставки подписки, новые первыми.
+// Пустой status — доставки в любом статусе.
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, subscriptionId, status string, limit int) ([]WebhookDelivery, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM webhook_subscriptions WHERE id = $1)", subscriptionId).Scan(&exists)
+	if isInvalidText(err) || (err == nil && !exists) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT d.id, d.subscription_id, d.status, d.attempts, d.next_attempt_at,
+               COALESCE(d.last_error, ''), d.delivered_at, d.created_at, o.id, o.payload
+        FROM webhook_deliveries d
+        JOIN outbox o ON o.id = d.outbox_id
+        WHERE d.subscription_id = $1 AND ($2 = '' OR d.status = $2)
+        ORDER BY d.created_at DESC, o.id DESC
+        LIMIT $3`, subscriptionId, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var next, delivered sql.NullTime
+		var payload []byte
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.Status, &d.Attempts, &next,
+			&d.LastError, &delivered, &d.CreatedAt, &d.Event.ID, &payload); err != nil {
+			return nil, err
+		}
+		if err := fillDelivery(&d, next, delivered, payload); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func fillDelivery(d *WebhookDelivery, next, delivered sql.NullTime, payload []byte) error {
+	id := d.Event.ID
+	if err := json.Unmarshal(payload, &d.Event); err != nil {
+		return err
+	}
+	d.Event.ID = id
+	if next.Valid {
+		d.NextAttemptAt = &next.Time
+	}
+	if delivered.Valid {
+		d.DeliveredAt = &delivered.Time
+	}
+	return nil
+}
+
+// FanOutOutbox раскладывает до limit новых событий outbox по доставкам
+// подписчикам и возвращает число разобранных событий. События, разобранные
+// до создания подписки, ей не достаются.
+func (r *PostgresWebhookRepository) FanOutOutbox(ctx context.Context, limit int) (int, error) {
+	var n int
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+            SELECT id, event_type
+            FROM outbox
+            WHERE dispatched_at IS NULL
+            ORDER BY id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED`, limit)
+		if err != nil {
+			return err
+		}
+		type entry struct {
+			id        int64
+			eventType string
+		}
+		var entries []entry
+		for rows.Next() {
+			var e entry
+			if err := rows.Scan(&e.id, &e.eventType); err != nil {
+				rows.Close()
+				return err
+			}
+			entries = append(entries, e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		subs, err := tx.QueryContext(ctx, "SELECT id, event_types FROM webhook_subscriptions")
+		if err != nil {
+			return err
+		}
+		type subscription struct {
+			id         string
+			eventTypes []string
+		}
+		var all []subscription
+		for subs.Next() {
+			var s subscription
+			if err := subs.Scan(&s.id, pq.Array(&s.eventTypes)); err != nil {
+				subs.Close()
+				return err
+			}
+			all = append(all, s)
+		}
+		subs.Close()
+		if err := subs.Err(); err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, len(entries))
+		for _, e := range entries {
+			ids = append(ids, e.id)
+			for _, s := range all {
+				if !subscribed(s.eventTypes, e.eventType) {
+					continue
+				}
+				_, err := tx.ExecContext(ctx, `
+                    INSERT INTO webhook_deliveries (id, subscription_id, outbox_id, status, next_attempt_at)
+                    VALUES ($1, $2, $3, 'pending', NOW())`, uuid.New().String(), s.id, e.id)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		n = len(entries)
+		_, err = tx.ExecContext(ctx, "UPDATE outbox SET dispatched_at = NOW() WHERE id = ANY($1)", pq.Array(ids))
+		return err
+	})
+	return n, err
+}
+
+// DueDeliveries возвращает до limit доставок, которые пора отправить.
+func (r *PostgresWebhookRepository) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]PendingDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT d.id, d.subscription_id, d.status, d.attempts, d.next_attempt_at, COALESCE(d.last_error, ''),
+               d.delivered_at, d.created_at, o.id, o.payload, s.url, s.secret
+        FROM webhook_deliveries d
+        JOIN outbox o ON o.id = d.outbox_id
+        JOIN webhook_subscriptions s ON s.id = d.subscription_id
+        WHERE d.status = 'pending' AND d.next_attempt_at <= $1
+        ORDER BY d.next_attempt_at, o.id
+        LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []PendingDelivery
+	for rows.Next() {
+		var p PendingDelivery
+		var next, delivered sql.NullTime
+		var payload []byte
+		if err := rows.Scan(&p.ID, &p.SubscriptionID, &p.Status, &p.Attempts, &next, &p.LastError,
+			&delivered, &p.CreatedAt, &p.Event.ID, &payload, &p.URL, &p.Secret); err != nil {
+			return nil, err
+		}
+		if err := fillDelivery(&p.WebhookDelivery, next, delivered, payload); err != nil {
+			return nil, err
+		}
+		due = append(due, p)
+	}
+	return due, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) MarkDelivered(ctx context.Context, id string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_deliveries
+        SET status = 'delivered', attempts = attempts + 1, delivered_at = $2,
+            next_attempt_at = NULL, last_error = NULL
+        WHERE id = $1`, id, at)
+	return err
+}
+
+// MarkFailed учитывает неудачную попытку. next — время следующей попытки;
+// nil переводит доставку в dead.
+func (r *PostgresWebhookRepository) MarkFailed(ctx context.Context, id, lastError string, next *time.Time) error {
+	status := DeliveryPending
+	if next == nil {
+		status = DeliveryDead
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_deliveries
+        SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4
+        WHERE id = $1`, id, status, lastError, next)
+	return err
+}
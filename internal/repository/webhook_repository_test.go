@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWebhook_Invalid(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresWebhookRepository(db)
+	_, err = repo.CreateWebhook(context.Background(), WebhookSubscription{URL: "ftp://billing.local"})
+	assert.True(t, errors.Is(err, ErrInvalidWebhook))
+	_, err = repo.CreateWebhook(context.Background(), WebhookSubscription{
+		URL:        "https://billing.local/hook",
+		EventTypes: []string{"pvz_opened"},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidWebhook))
+	// Секрет длиннее колонки отклоняется до запроса к БД
+	_, err = repo.CreateWebhook(context.Background(), WebhookSubscription{
+		URL:    "https://billing.local/hook",
+		Secret: strings.Repeat("s", maxWebhookSecretLen+1),
+	})
+	assert.True(t, errors.Is(err, ErrInvalidWebhook))
+}
+
+func TestCreateWebhook_GeneratesSecret(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresWebhookRepository(db)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO webhook_subscriptions`).
+		WithArgs(sqlmock.AnyArg(), "https://billing.local/hook", sqlmock.AnyArg(), pq.Array([]string{}), "moderator").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	expectAudit(mock)
+	mock.ExpectCommit()
+
+	sub, err := repo.CreateWebhook(context.Background(), WebhookSubscription{URL: "https://billing.local/hook", CreatedBy: "moderator"})
+	require.NoError(t, err)
+	assert.Len(t, sub.Secret, 64)
+	assert.NotEmpty(t, sub.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFanOutOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresWebhookRepository(db)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, event_type FROM outbox WHERE dispatched_at IS NULL ORDER BY id LIMIT \$1 FOR UPDATE SKIP LOCKED`).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type"}).
+			AddRow(1, "reception_opened").
+			AddRow(2, "reception_closed"))
+	mock.ExpectQuery(`SELECT id, event_types FROM webhook_subscriptions`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_types"}).
+			AddRow("sub-all", "{}").
+			AddRow("sub-closed", "{reception_closed}"))
+	// Открытие — только подписке на все события, закрытие — обеим
+	mock.ExpectExec(`INSERT INTO webhook_deliveries`).
+		WithArgs(sqlmock.AnyArg(), "sub-all", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO webhook_deliveries`).
+		WithArgs(sqlmock.AnyArg(), "sub-all", int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO webhook_deliveries`).
+		WithArgs(sqlmock.AnyArg(), "sub-closed", int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE outbox SET dispatched_at = NOW\(\) WHERE id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]int64{1, 2})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	n, err := repo.FanOutOutbox(context.Background(), 100)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkFailed_Dead(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresWebhookRepository(db)
+	next := time.Now().Add(time.Minute)
+	mock.ExpectExec(`UPDATE webhook_deliveries SET status = \$2`).
+		WithArgs("d-1", DeliveryPending, "получатель ответил 500", next).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE webhook_deliveries SET status = \$2`).
+		WithArgs("d-1", DeliveryDead, "получатель ответил 500", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.MarkFailed(context.Background(), "d-1", "получатель ответил 500", &next))
+	require.NoError(t, repo.MarkFailed(context.Background(), "d-1", "получатель ответил 500", nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListDeliveries_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPostgresWebhookRepository(db)
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs("sub-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	_, err = repo.ListDeliveries(context.Background(), "sub-1", "", 10)
+	assert.Equal(t, ErrWebhookNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
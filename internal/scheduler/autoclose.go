@@ -0,0 +1,74 @@
+// Package scheduler — фоновые задачи сервиса.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"avito-pvz-service/internal/metrics"
+	"avito-pvz-service/internal/repository"
+)
+
+// autoCloseLock — имя блокировки лидера для автозакрытия приёмок.
+const autoCloseLock = "reception-auto-close"
+
+// AutoCloser периодически закрывает приёмки, в которые дольше Idle
+// не добавляли товаров. На нескольких репликах проход выполняет та,
+// что взяла блокировку лидера.
+type AutoCloser struct {
+	Receptions repository.ReceptionRepository
+	Leader     repository.LeaderLock
+	// Idle — сколько приёмка может простаивать со времени последнего товара.
+	Idle time.Duration
+	// Interval — как часто искать зависшие приёмки.
+	Interval time.Duration
+
+	now func() time.Time
+}
+
+func NewAutoCloser(repos repository.Repositories, idle, interval time.Duration) *AutoCloser {
+	return &AutoCloser{
+		Receptions: repos.Reception,
+		Leader:     repos.Leader,
+		Idle:       idle,
+		Interval:   interval,
+		now:        time.Now,
+	}
+}
+
+// Run выполняет проходы каждые Interval до отмены ctx.
+func (a *AutoCloser) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := a.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Println("Автозакрытие приёмок: ошибка:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce выполняет один проход и возвращает закрытые приёмки. Если
+// блокировку держит другая реплика, ничего не делает.
+func (a *AutoCloser) RunOnce(ctx context.Context) ([]repository.Reception, error) {
+	var closed []repository.Reception
+	leader, err := a.Leader.RunExclusive(ctx, autoCloseLock, func(ctx context.Context) error {
+		var err error
+		closed, err = a.Receptions.CloseStaleReceptions(ctx, a.now().Add(-a.Idle))
+		// Приёмки, закрытые до ошибки, тоже учитываем
+		for _, rec := range closed {
+			metrics.ReceptionsAutoClosedTotal.Inc()
+			log.Printf("Автозакрытие приёмок: закрыта id=%s, PVZ=%s\n", rec.ID, rec.PVZId)
+		}
+		return err
+	})
+	if !leader && err == nil {
+		log.Println("Автозакрытие приёмок: проход выполняет другая реплика")
+	}
+	return closed, err
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"avito-pvz-service/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoCloser_ClosesIdleReceptions(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+
+	idle, err := repos.PVZ.CreatePVZ(ctx, "Москва")
+	require.NoError(t, err)
+	busy, err := repos.PVZ.CreatePVZ(ctx, "Казань")
+	require.NoError(t, err)
+	stale, err := repos.Reception.CreateReception(ctx, idle.ID)
+	require.NoError(t, err)
+	_, err = repos.Reception.CreateReception(ctx, busy.ID)
+	require.NoError(t, err)
+
+	a := NewAutoCloser(repos, 50*time.Millisecond, time.Minute)
+	closed, err := a.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, closed, "ещё никто не простаивает")
+
+	// Товар продлевает приёмку: простой считается от последнего товара
+	time.Sleep(60 * time.Millisecond)
+	_, err = repos.Product.AddProduct(ctx, busy.ID, repository.NewProduct{Type: "обувь"})
+	require.NoError(t, err)
+	closed, err = a.RunOnce(ctx)
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.Equal(t, stale.ID, closed[0].ID)
+	assert.Equal(t, repository.CloseReasonAutoTimeout, closed[0].CloseReason)
+
+	record, err := repos.Reception.GetReception(ctx, stale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, repository.StatusClosed, record.Reception.Status)
+	assert.Equal(t, repository.CloseReasonAutoTimeout, record.Reception.CloseReason)
+
+	// После автозакрытия ПВЗ снова может открыть приёмку
+	_, err = repos.Reception.CreateReception(ctx, idle.ID)
+	assert.NoError(t, err)
+	_, err = repos.Reception.CurrentReception(ctx, busy.ID)
+	assert.NoError(t, err)
+}
+
+func TestAutoCloser_SkipsWithoutLeadership(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+
+	pvz, err := repos.PVZ.CreatePVZ(ctx, "Москва")
+	require.NoError(t, err)
+	_, err = repos.Reception.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+
+	a := NewAutoCloser(repos, time.Hour, time.Minute)
+	a.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	// Пока блокировку держит «другая реплика», проход ничего не делает
+	ran, err := repos.Leader.RunExclusive(ctx, autoCloseLock, func(ctx context.Context) error {
+		closed, err := a.RunOnce(ctx)
+		assert.Empty(t, closed)
+		return err
+	})
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	closed, err := a.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Len(t, closed, 1)
+}
@@ -0,0 +1,242 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"avito-pvz-service/internal/metrics"
+	"avito-pvz-service/internal/repository"
+)
+
+// webhookLock — имя блокировки лидера для рассылки вебхуков.
+const webhookLock = "webhook-dispatch"
+
+// Заголовки запроса вебхука.
+const (
+	HeaderEvent     = "X-PVZ-Event"
+	HeaderDelivery  = "X-PVZ-Delivery"
+	HeaderTimestamp = "X-PVZ-Timestamp"
+	HeaderSignature = "X-PVZ-Signature"
+)
+
+// SignWebhook возвращает подпись тела вебхука: HMAC-SHA256 по строке
+// "<timestamp>.<body>" на секрете подписки, в hex. Получатель считает её
+// так же и сравнивает со значением X-PVZ-Signature после префикса "sha256=".
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDispatcher разбирает outbox по подпискам и отправляет доставки.
+// Неудачная доставка повторяется с экспоненциальной задержкой, после
+// MaxAttempts попыток она переходит в статус dead.
+type WebhookDispatcher struct {
+	Webhooks repository.WebhookRepository
+	Leader   repository.LeaderLock
+	Client   *http.Client
+	// Interval — как часто проверять outbox и очередь доставок.
+	Interval time.Duration
+	// MaxAttempts — сколько раз пытаться доставить событие.
+	MaxAttempts int
+	// BaseBackoff — задержка после первой неудачи, дальше она удваивается
+	// до MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// BatchSize — сколько событий и доставок обрабатывать за проход.
+	BatchSize int
+	// Concurrency — сколько подписок обслуживается одновременно. Доставки
+	// одной подписки отправляются по очереди.
+	Concurrency int
+	// SubscriptionBudget — сколько времени за проход можно потратить на одну
+	// подписку; её оставшиеся доставки ждут следующего прохода, чтобы
+	// медленный получатель не держал блокировку лидера и не задерживал других.
+	SubscriptionBudget time.Duration
+
+	now func() time.Time
+}
+
+func NewWebhookDispatcher(repos repository.Repositories, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		Webhooks:           repos.Webhook,
+		Leader:             repos.Leader,
+		Client:             &http.Client{Timeout: 10 * time.Second},
+		Interval:           interval,
+		MaxAttempts:        8,
+		BaseBackoff:        10 * time.Second,
+		MaxBackoff:         time.Hour,
+		BatchSize:          100,
+		Concurrency:        8,
+		SubscriptionBudget: 30 * time.Second,
+		now:                time.Now,
+	}
+}
+
+// Run выполняет проходы каждые Interval до отмены ctx.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Println("Рассылка вебхуков: ошибка:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce разбирает новые события outbox и отправляет доставки, которым
+// пришло время. Возвращает число попыток отправки. Если блокировку держит
+// другая реплика, ничего не делает.
+func (d *WebhookDispatcher) RunOnce(ctx context.Context) (int, error) {
+	var sent int
+	_, err := d.Leader.RunExclusive(ctx, webhookLock, func(ctx context.Context) error {
+		if _, err := d.Webhooks.FanOutOutbox(ctx, d.BatchSize); err != nil {
+			return err
+		}
+		due, err := d.Webhooks.DueDeliveries(ctx, d.now(), d.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var firstErr error
+		slots := make(chan struct{}, d.Concurrency)
+		for _, queue := range bySubscription(due) {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(queue []repository.PendingDelivery) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				n, err := d.deliver(ctx, queue)
+				mu.Lock()
+				defer mu.Unlock()
+				sent += n
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}(queue)
+		}
+		wg.Wait()
+		return firstErr
+	})
+	return sent, err
+}
+
+// bySubscription раскладывает доставки по подпискам, сохраняя порядок.
+func bySubscription(due []repository.PendingDelivery) [][]repository.PendingDelivery {
+	var queues [][]repository.PendingDelivery
+	index := make(map[string]int)
+	for _, p := range due {
+		i, ok := index[p.SubscriptionID]
+		if !ok {
+			i = len(queues)
+			index[p.SubscriptionID] = i
+			queues = append(queues, nil)
+		}
+		queues[i] = append(queues[i], p)
+	}
+	return queues
+}
+
+// deliver отправляет доставки одной подписки по очереди, пока не выйдет
+// SubscriptionBudget. Возвращает число попыток.
+func (d *WebhookDispatcher) deliver(ctx context.Context, queue []repository.PendingDelivery) (int, error) {
+	started := time.Now()
+	var sent int
+	for _, p := range queue {
+		if sent > 0 && time.Since(started) >= d.SubscriptionBudget {
+			break
+		}
+		if err := d.attempt(ctx, p); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// attempt отправляет одну доставку и записывает результат.
+func (d *WebhookDispatcher) attempt(ctx context.Context, p repository.PendingDelivery) error {
+	sendErr := d.send(ctx, p)
+	if sendErr == nil {
+		metrics.WebhookDeliveriesTotal.WithLabelValues("delivered").Inc()
+		return d.Webhooks.MarkDelivered(ctx, p.ID, d.now())
+	}
+	if ctx.Err() != nil {
+		// Попытку прервала остановка сервиса — её не учитываем
+		return ctx.Err()
+	}
+
+	attempts := p.Attempts + 1
+	if attempts >= d.MaxAttempts {
+		metrics.WebhookDeliveriesTotal.WithLabelValues("dead").Inc()
+		log.Printf("Рассылка вебхуков: доставка id=%s отброшена после %d попыток: %v\n", p.ID, attempts, sendErr)
+		return d.Webhooks.MarkFailed(ctx, p.ID, sendErr.Error(), nil)
+	}
+	metrics.WebhookDeliveriesTotal.WithLabelValues("failed").Inc()
+	next := d.now().Add(d.backoff(attempts))
+	return d.Webhooks.MarkFailed(ctx, p.ID, sendErr.Error(), &next)
+}
+
+// backoff возвращает задержку перед следующей попыткой после attempts неудач.
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.BaseBackoff
+	for i := 1; i < attempts && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		delay = d.MaxBackoff
+	}
+	return delay
+}
+
+func (d *WebhookDispatcher) send(ctx context.Context, p repository.PendingDelivery) error {
+	body, err := json.Marshal(p.Event)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, p.Event.Type)
+	req.Header.Set(HeaderDelivery, p.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+SignWebhook(p.Secret, timestamp, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Дочитываем ответ, чтобы соединение вернулось в пул
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("получатель ответил %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"avito-pvz-service/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver — тестовый получатель вебхуков, проверяющий подпись.
+type receiver struct {
+	t      *testing.T
+	secret string
+	status int
+
+	mu     sync.Mutex
+	events []repository.WebhookEvent
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	require.NoError(r.t, err)
+	ts := req.Header.Get(HeaderTimestamp)
+	assert.Equal(r.t, "sha256="+SignWebhook(r.secret, ts, body), req.Header.Get(HeaderSignature))
+	assert.NotEmpty(r.t, req.Header.Get(HeaderDelivery))
+
+	var ev repository.WebhookEvent
+	require.NoError(r.t, json.Unmarshal(body, &ev))
+	assert.Equal(r.t, ev.Type, req.Header.Get(HeaderEvent))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev)
+	w.WriteHeader(r.status)
+}
+
+func TestWebhookDispatcher_DeliversSigned(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	rcv := &receiver{t: t, secret: "s3cret", status: http.StatusNoContent}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	sub, err := repos.Webhook.CreateWebhook(ctx, repository.WebhookSubscription{
+		URL:        srv.URL,
+		Secret:     "s3cret",
+		EventTypes: []string{"reception_closed"},
+	})
+	require.NoError(t, err)
+
+	pvz, err := repos.PVZ.CreatePVZ(ctx, "Москва")
+	require.NoError(t, err)
+	reception, err := repos.Reception.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	_, err = repos.Reception.CloseReception(ctx, pvz.ID)
+	require.NoError(t, err)
+
+	d := NewWebhookDispatcher(repos, time.Minute)
+	sent, err := d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "открытие приёмки не входит в подписку")
+
+	require.Len(t, rcv.events, 1)
+	assert.Equal(t, "reception_closed", rcv.events[0].Type)
+	assert.Equal(t, reception.ID, rcv.events[0].ReceptionId)
+	assert.Equal(t, "Москва", rcv.events[0].City)
+
+	deliveries, err := repos.Webhook.ListDeliveries(ctx, sub.ID, "", 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, repository.DeliveryDelivered, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+
+	// Доставленное второй раз не отправляется
+	sent, err = d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+}
+
+func TestWebhookDispatcher_RetriesThenDead(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	rcv := &receiver{t: t, secret: "s3cret", status: http.StatusInternalServerError}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	sub, err := repos.Webhook.CreateWebhook(ctx, repository.WebhookSubscription{URL: srv.URL, Secret: "s3cret"})
+	require.NoError(t, err)
+	pvz, err := repos.PVZ.CreatePVZ(ctx, "Москва")
+	require.NoError(t, err)
+	_, err = repos.Reception.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+
+	// Часы диспетчера чуть впереди, чтобы доставка уже была в очереди
+	now := time.Now().Add(time.Minute)
+	d := NewWebhookDispatcher(repos, time.Minute)
+	d.MaxAttempts = 3
+	d.BaseBackoff = time.Second
+	d.now = func() time.Time { return now }
+
+	sent, err := d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	deliveries, err := repos.Webhook.ListDeliveries(ctx, sub.ID, repository.DeliveryPending, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, now.Add(time.Second), *deliveries[0].NextAttemptAt)
+	assert.Contains(t, deliveries[0].LastError, "500")
+
+	// До истечения задержки повтора нет
+	sent, err = d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	// Вторая неудача удваивает задержку
+	now = now.Add(time.Second)
+	_, err = d.RunOnce(ctx)
+	require.NoError(t, err)
+	deliveries, err = repos.Webhook.ListDeliveries(ctx, sub.ID, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(2*time.Second), *deliveries[0].NextAttemptAt)
+
+	// Третья неудача исчерпывает попытки
+	now = now.Add(2 * time.Second)
+	_, err = d.RunOnce(ctx)
+	require.NoError(t, err)
+	deliveries, err = repos.Webhook.ListDeliveries(ctx, sub.ID, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, repository.DeliveryDead, deliveries[0].Status)
+	assert.Equal(t, 3, deliveries[0].Attempts)
+	assert.Nil(t, deliveries[0].NextAttemptAt)
+	assert.Len(t, rcv.events, 3)
+
+	now = now.Add(time.Hour)
+	sent, err = d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+}
+
+func TestWebhookDispatcher_SubscriptionBudget(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	fast := &receiver{t: t, secret: "fast", status: http.StatusNoContent}
+	fastSrv := httptest.NewServer(fast)
+	defer fastSrv.Close()
+	slow := &receiver{t: t, secret: "slow", status: http.StatusNoContent}
+	slowSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		slow.ServeHTTP(w, req)
+	}))
+	defer slowSrv.Close()
+
+	for _, rcv := range []struct{ url, secret string }{{slowSrv.URL, "slow"}, {fastSrv.URL, "fast"}} {
+		_, err := repos.Webhook.CreateWebhook(ctx, repository.WebhookSubscription{URL: rcv.url, Secret: rcv.secret})
+		require.NoError(t, err)
+	}
+	pvz, err := repos.PVZ.CreatePVZ(ctx, "Москва")
+	require.NoError(t, err)
+	_, err = repos.Reception.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	_, err = repos.Reception.CloseReception(ctx, pvz.ID)
+	require.NoError(t, err)
+	_, err = repos.Reception.CreateReception(ctx, pvz.ID)
+	require.NoError(t, err)
+
+	// Медленный получатель исчерпывает бюджет на первой доставке,
+	// а быстрый за тот же проход получает все события
+	d := NewWebhookDispatcher(repos, time.Minute)
+	d.SubscriptionBudget = 50 * time.Millisecond
+	sent, err := d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, sent)
+	assert.Len(t, fast.events, 3)
+	assert.Len(t, slow.events, 1)
+
+	sent, err = d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, slow.events, 2)
+}
+
+func TestWebhookDispatcher_Backoff(t *testing.T) {
+	d := &WebhookDispatcher{BaseBackoff: 10 * time.Second, MaxBackoff: time.Minute}
+	assert.Equal(t, 10*time.Second, d.backoff(1))
+	assert.Equal(t, 20*time.Second, d.backoff(2))
+	assert.Equal(t, 40*time.Second, d.backoff(3))
+	assert.Equal(t, time.Minute, d.backoff(4))
+	assert.Equal(t, time.Minute, d.backoff(20))
+}
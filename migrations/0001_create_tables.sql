@@ -1,3 +1,4 @@
+-- +migrate Up
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
 -- Таблица для пользователей
@@ -31,4 +32,10 @@ CREATE TABLE IF NOT EXISTS products (
     type VARCHAR(50) NOT NULL,
     reception_id UUID NOT NULL REFERENCES receptions(id) ON DELETE CASCADE,
     pvz_id UUID NOT NULL REFERENCES pvz(id) ON DELETE CASCADE
-);
\ No newline at end of file
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS receptions;
+DROP TABLE IF EXISTS pvz;
+DROP TABLE IF EXISTS users;
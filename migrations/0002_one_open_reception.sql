@@ -0,0 +1,14 @@
+-- +migrate Up
+-- Не больше одной открытой приёмки на ПВЗ.
+-- Защищает от гонки, даже если запись идёт в обход блокировки строки ПВЗ.
+CREATE UNIQUE INDEX IF NOT EXISTS receptions_one_in_progress_per_pvz
+    ON receptions (pvz_id)
+    WHERE status = 'in_progress';
+
+-- Выборка последней приёмки ПВЗ выполняется на каждую операцию с товаром.
+CREATE INDEX IF NOT EXISTS receptions_pvz_id_date_time_idx
+    ON receptions (pvz_id, date_time DESC);
+
+-- +migrate Down
+DROP INDEX IF EXISTS receptions_pvz_id_date_time_idx;
+DROP INDEX IF EXISTS receptions_one_in_progress_per_pvz;
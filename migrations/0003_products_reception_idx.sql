@@ -0,0 +1,7 @@
+-- +migrate Up
+-- Товары выбираются пачкой по списку приёмок (WHERE reception_id = ANY($1)).
+CREATE INDEX IF NOT EXISTS products_reception_id_date_time_idx
+    ON products (reception_id, date_time);
+
+-- +migrate Down
+DROP INDEX IF EXISTS products_reception_id_date_time_idx;
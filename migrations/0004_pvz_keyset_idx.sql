@@ -0,0 +1,7 @@
+-- +migrate Up
+-- Курсорная пагинация списка ПВЗ идёт по (registration_date, id) по убыванию.
+CREATE INDEX IF NOT EXISTS pvz_registration_date_id_idx
+    ON pvz (registration_date DESC, id DESC);
+
+-- +migrate Down
+DROP INDEX IF EXISTS pvz_registration_date_id_idx;
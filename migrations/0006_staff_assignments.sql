@@ -0,0 +1,17 @@
+-- +migrate Up
+-- Сотрудник может работать с приёмками и товарами только в назначенных ПВЗ.
+-- subject — это sub из JWT: email зарегистрированного пользователя
+-- или имя роли для токенов /dummyLogin.
+CREATE TABLE IF NOT EXISTS staff_assignments (
+    subject VARCHAR(255) NOT NULL,
+    pvz_id UUID NOT NULL REFERENCES pvz(id) ON DELETE CASCADE,
+    assigned_by VARCHAR(255) NOT NULL,
+    assigned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (subject, pvz_id)
+);
+
+CREATE INDEX IF NOT EXISTS staff_assignments_pvz_id_idx
+    ON staff_assignments (pvz_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS staff_assignments;
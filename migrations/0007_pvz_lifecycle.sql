@@ -0,0 +1,15 @@
+-- +migrate Up
+-- Название и адрес ПВЗ, мягкая деактивация: неактивный ПВЗ не принимает
+-- новые приёмки, closed_at — момент деактивации.
+ALTER TABLE pvz
+    ADD COLUMN IF NOT EXISTS name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS address TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP WITH TIME ZONE;
+
+-- +migrate Down
+ALTER TABLE pvz
+    DROP COLUMN IF EXISTS closed_at,
+    DROP COLUMN IF EXISTS active,
+    DROP COLUMN IF EXISTS address,
+    DROP COLUMN IF EXISTS name;
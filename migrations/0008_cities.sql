@@ -0,0 +1,24 @@
+-- +migrate Up
+-- Справочник городов, в которых можно открывать ПВЗ. Отключённый город
+-- остаётся в справочнике, но новые ПВЗ в нём не заводятся.
+CREATE TABLE IF NOT EXISTS cities (
+    name VARCHAR(255) PRIMARY KEY,
+    region_code VARCHAR(16) NOT NULL,
+    timezone VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO cities (name, region_code, timezone) VALUES
+    ('Москва', '77', 'Europe/Moscow'),
+    ('Санкт-Петербург', '78', 'Europe/Moscow'),
+    ('Казань', '16', 'Europe/Moscow')
+ON CONFLICT (name) DO NOTHING;
+
+-- Город ПВЗ обязан быть в справочнике; удалить город с ПВЗ нельзя
+ALTER TABLE pvz
+    ADD CONSTRAINT pvz_city_fkey FOREIGN KEY (city) REFERENCES cities (name);
+
+-- +migrate Down
+ALTER TABLE pvz DROP CONSTRAINT IF EXISTS pvz_city_fkey;
+DROP TABLE IF EXISTS cities;
@@ -0,0 +1,30 @@
+-- +migrate Up
+-- Справочник типов товаров: названия на разных языках, описание атрибутов
+-- (вес, габариты и т.п.) и признак хрупкости.
+CREATE TABLE IF NOT EXISTS product_types (
+    code VARCHAR(50) PRIMARY KEY,
+    names JSONB NOT NULL DEFAULT '{}',
+    attributes JSONB NOT NULL DEFAULT '[]',
+    fragile BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Прежние три типа остаются без обязательных атрибутов,
+-- чтобы существующие клиенты продолжали работать
+INSERT INTO product_types (code, names, fragile) VALUES
+    ('электроника', '{"ru": "Электроника", "en": "Electronics"}', TRUE),
+    ('одежда', '{"ru": "Одежда", "en": "Clothing"}', FALSE),
+    ('обувь', '{"ru": "Обувь", "en": "Shoes"}', FALSE)
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+-- Тип товара обязан быть в справочнике; удалить тип с товарами нельзя
+ALTER TABLE products
+    ADD CONSTRAINT products_type_fkey FOREIGN KEY (type) REFERENCES product_types (code);
+
+-- +migrate Down
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_type_fkey;
+ALTER TABLE products DROP COLUMN IF EXISTS attributes;
+DROP TABLE IF EXISTS product_types;
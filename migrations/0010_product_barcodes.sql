@@ -0,0 +1,28 @@
+-- +migrate Up
+-- Штрихкод или внешний номер заказа посылки. Пока приёмка открыта, один
+-- штрихкод может быть только у одного товара; reception_open повторяет
+-- статус приёмки, потому что частичный индекс не может сослаться на receptions.
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS barcode VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS reception_open BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE products p
+SET reception_open = (r.status = 'in_progress')
+FROM receptions r
+WHERE r.id = p.reception_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS products_open_barcode_key
+    ON products (barcode)
+    WHERE reception_open AND barcode IS NOT NULL;
+
+-- Поиск посылки по штрихкоду, в том числе в закрытых приёмках
+CREATE INDEX IF NOT EXISTS products_barcode_idx
+    ON products (barcode, date_time DESC)
+    WHERE barcode IS NOT NULL;
+
+-- +migrate Down
+DROP INDEX IF EXISTS products_barcode_idx;
+DROP INDEX IF EXISTS products_open_barcode_key;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS reception_open,
+    DROP COLUMN IF EXISTS barcode;
@@ -0,0 +1,21 @@
+-- +migrate Up
+-- Журнал удалений товаров из открытых приёмок не по LIFO. Запись хранит
+-- копию товара, потому что сама строка в products удаляется.
+CREATE TABLE IF NOT EXISTS product_deletions (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    reception_id UUID NOT NULL REFERENCES receptions(id) ON DELETE CASCADE,
+    pvz_id UUID NOT NULL REFERENCES pvz(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    barcode VARCHAR(64),
+    reason VARCHAR(32) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    deleted_by VARCHAR(255) NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS product_deletions_pvz_idx
+    ON product_deletions (pvz_id, deleted_at DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS product_deletions;
@@ -0,0 +1,22 @@
+-- +migrate Up
+-- Статусы приёмки: in_progress -> close -> reopened -> close ...,
+-- из любого статуса кроме cancelled — в cancelled. Переоткрытая приёмка
+-- считается открытой, поэтому индекс «одна открытая приёмка на ПВЗ»
+-- распространяется и на неё.
+DROP INDEX IF EXISTS receptions_one_in_progress_per_pvz;
+CREATE UNIQUE INDEX IF NOT EXISTS receptions_one_open_per_pvz
+    ON receptions (pvz_id)
+    WHERE status IN ('in_progress', 'reopened');
+
+ALTER TABLE receptions
+    ADD CONSTRAINT receptions_status_check
+    CHECK (status IN ('in_progress', 'close', 'reopened', 'cancelled'));
+
+-- +migrate Down
+ALTER TABLE receptions DROP CONSTRAINT IF EXISTS receptions_status_check;
+UPDATE receptions SET status = 'close' WHERE status = 'cancelled';
+UPDATE receptions SET status = 'in_progress' WHERE status = 'reopened';
+DROP INDEX IF EXISTS receptions_one_open_per_pvz;
+CREATE UNIQUE INDEX IF NOT EXISTS receptions_one_in_progress_per_pvz
+    ON receptions (pvz_id)
+    WHERE status = 'in_progress';
@@ -0,0 +1,10 @@
+-- +migrate Up
+-- Почему приёмка закрыта в последний раз: manual — сотрудником,
+-- auto_timeout — фоновым закрытием зависших приёмок. У приёмок,
+-- закрытых до миграции, причина не известна и остаётся NULL.
+ALTER TABLE receptions
+    ADD COLUMN IF NOT EXISTS close_reason VARCHAR(32)
+    CONSTRAINT receptions_close_reason_check CHECK (close_reason IN ('manual', 'auto_timeout'));
+
+-- +migrate Down
+ALTER TABLE receptions DROP COLUMN IF EXISTS close_reason;
@@ -0,0 +1,26 @@
+-- +migrate Up
+-- Удалённый товар остаётся в таблице с отметкой, кто и когда его удалил:
+-- факт сканирования нужен для контроля потерь. Отчёты по умолчанию
+-- удалённые товары не показывают.
+-- С этой миграции строка в products при удалении не стирается, вопреки
+-- комментарию к 0011: копия товара в product_deletions остаётся ради
+-- причины удаления и истории, а сам товар по-прежнему есть в products.
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+
+-- Штрихкод удалённого товара можно снова принять в открытую приёмку
+DROP INDEX IF EXISTS products_open_barcode_key;
+CREATE UNIQUE INDEX IF NOT EXISTS products_open_barcode_key
+    ON products (barcode)
+    WHERE reception_open AND barcode IS NOT NULL AND deleted_at IS NULL;
+
+-- +migrate Down
+DELETE FROM products WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS products_open_barcode_key;
+CREATE UNIQUE INDEX IF NOT EXISTS products_open_barcode_key
+    ON products (barcode)
+    WHERE reception_open AND barcode IS NOT NULL;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
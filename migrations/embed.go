@@ -0,0 +1,10 @@
+// Package migrations встраивает SQL-миграции в бинарник.
+package migrations
+
+import "embed"
+
+// FS содержит файлы вида NNNN_name.sql с секциями
+// "-- +migrate Up" и "-- +migrate Down".
+//
+//go:embed *.sql
+var FS embed.FS
@@ -20,8 +20,9 @@ func Test_FullFlow_LiveIntegration(t *testing.T) {
 	pvzResp := postJSON(t, "/pvz", map[string]string{"city": "Москва"}, modToken)
 	pvzID := pvzResp["id"].(string)
 
-	// Получаем токен сотрудника
+	// Получаем токен сотрудника и назначаем его в ПВЗ
 	staffToken := getToken(t, "staff")
+	postJSON(t, "/pvz/"+pvzID+"/staff", map[string]string{"subject": "staff"}, modToken)
 
 	// Создаем приёмку
 	recResp := postJSON(t, "/receptions", map[string]string{"pvzId": pvzID}, staffToken)